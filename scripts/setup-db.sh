@@ -103,24 +103,10 @@ docker exec eeye-db psql -U admin -d eeye -c "CREATE EXTENSION IF NOT EXISTS tim
     exit 1
 }
 
-# Apply schema
-echo "Applying database schema..."
-
-# Construct and normalize schema path
-SCHEMA_PATH="$PROJECT_ROOT/sql/SCHEMA.sql"
-NORMALIZED_SCHEMA_PATH=$(normalize_path "$SCHEMA_PATH")
-
-echo "Looking for schema at: $NORMALIZED_SCHEMA_PATH"
-
-if [ ! -f "$NORMALIZED_SCHEMA_PATH" ]; then
-    echo "Error: SCHEMA.sql not found at $NORMALIZED_SCHEMA_PATH"
-    exit 1
-fi
-
-echo "Applying schema to database..."
-# Read the schema file and pipe it directly to psql in the container
-if ! cat "$NORMALIZED_SCHEMA_PATH" | docker exec -i eeye-db psql -U admin -d eeye; then
-    echo "Error: Failed to apply schema"
+# Apply schema migrations (embedded in the binary, see src/db/migrations)
+echo "Applying database migrations..."
+if ! (cd "$PROJECT_ROOT" && go run ./src/main.go migrate --verbose up); then
+    echo "Error: Failed to apply migrations"
     exit 1
 fi
 
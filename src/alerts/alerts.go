@@ -0,0 +1,293 @@
+// Package alerts watches stocks for their close crossing a price, an EMA or a
+// support/resistance level. Alerts are evaluated against the candles ingested since their
+// last evaluation after every ingestion and trigger once.
+package alerts
+
+import (
+	"context"
+	"eeye/src/calendar"
+	"eeye/src/dataflow"
+	"eeye/src/db"
+	"eeye/src/models"
+	"eeye/src/notify"
+	"eeye/src/steps"
+	"eeye/src/utils"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math"
+	"strings"
+	"time"
+)
+
+const (
+	// lookback is the number of latest candles alerts are evaluated on: enough for the
+	// longest EMA and a year of support/resistance levels
+	lookback = 250
+
+	// maxPeriod is the longest EMA an alert can watch
+	maxPeriod = 200
+
+	// The support/resistance levels are those of the Fake Breakdown strategy:
+	// local extremes over 5 sessions on each side, clustered within 1% and touched 3 times
+	levelWindow    = 5
+	levelTolerance = 0.01
+	levelStrength  = 3
+)
+
+// validate checks the condition, level and direction of a new alert, which expires
+// on or after today
+func validate(alert *models.Alert, today time.Time) error {
+	if alert.Symbol == "" {
+		return errors.New("symbol is required")
+	}
+
+	switch alert.Condition {
+	case models.AlertPrice:
+		if alert.Level <= 0 {
+			return fmt.Errorf("invalid price level %v, should be > 0", alert.Level)
+		}
+	case models.AlertEMA:
+		if alert.Period < 2 || alert.Period > maxPeriod {
+			return fmt.Errorf("invalid EMA period %v, should be between 2 and %d", alert.Period, maxPeriod)
+		}
+	case models.AlertSupport, models.AlertResistance:
+	default:
+		return fmt.Errorf(
+			"unknown condition %q, available: %v, %v, %v, %v",
+			alert.Condition,
+			models.AlertPrice,
+			models.AlertEMA,
+			models.AlertSupport,
+			models.AlertResistance,
+		)
+	}
+
+	if alert.Direction != models.CrossAbove && alert.Direction != models.CrossBelow {
+		return fmt.Errorf("unknown direction %q, available: %v, %v", alert.Direction, models.CrossAbove, models.CrossBelow)
+	}
+
+	if alert.ExpiresOn != nil && alert.ExpiresOn.Format("2006-01-02") < today.Format("2006-01-02") {
+		return fmt.Errorf("expiry %v is in the past", alert.ExpiresOn.Format("2006-01-02"))
+	}
+
+	return nil
+}
+
+// Normalize trims the symbol, condition and direction of a new alert, upper-cases the
+// symbol and lower-cases the condition and direction
+func Normalize(alert *models.Alert) {
+	alert.Symbol = strings.ToUpper(strings.TrimSpace(alert.Symbol))
+	alert.Condition = strings.ToLower(strings.TrimSpace(alert.Condition))
+	alert.Direction = strings.ToLower(strings.TrimSpace(alert.Direction))
+}
+
+// Create normalizes, validates and stores a new alert of a stock with stored candles
+func Create(ctx context.Context, alert *models.Alert) error {
+	Normalize(alert)
+
+	if err := validate(alert, calendar.NSE.Day(utils.Now())); err != nil {
+		return err
+	}
+
+	last, err := db.GetLastCandle(ctx, alert.Symbol)
+	if err != nil {
+		return err
+	}
+	if last.Close == 0 {
+		return fmt.Errorf("no candles stored for %v", alert.Symbol)
+	}
+
+	if err := db.CreateAlert(ctx, alert); err != nil {
+		return err
+	}
+
+	slog.Info("alert created", "id", alert.ID, "symbol", alert.Symbol, "condition", alert.Spec(), "direction", alert.Direction)
+	return nil
+}
+
+// crossed reports whether the close moved across the level in the given direction
+// between the previous and the latest candle
+func crossed(direction string, prevClose float64, lastClose float64, prevLevel float64, lastLevel float64) bool {
+	if direction == models.CrossAbove {
+		return prevClose < prevLevel && lastClose >= lastLevel
+	}
+	return prevClose > prevLevel && lastClose <= lastLevel
+}
+
+// evaluate returns the level crossed by the last of the candles, if the alert triggers.
+// Support/resistance levels are computed without the latest candle, so that it does
+// not form the level it crosses; if several levels are crossed the nearest one is returned.
+func evaluate(alert *models.Alert, candles []models.Candle) (float64, bool) {
+	n := len(candles)
+	if n < 2 {
+		return 0, false
+	}
+
+	var (
+		prevClose = candles[n-2].Close
+		lastClose = candles[n-1].Close
+	)
+
+	switch alert.Condition {
+	case models.AlertPrice:
+		return alert.Level, crossed(alert.Direction, prevClose, lastClose, alert.Level, alert.Level)
+
+	case models.AlertEMA:
+		ema := steps.ComputeEma(candles, alert.Period)
+		if len(ema) < 2 {
+			return 0, false
+		}
+		prevEma, lastEma := ema[len(ema)-2], ema[len(ema)-1]
+		return lastEma, crossed(alert.Direction, prevClose, lastClose, prevEma, lastEma)
+
+	case models.AlertSupport, models.AlertResistance:
+		supports, resistances := steps.GetLiquidityLevels(candles[:n-1], levelWindow, levelTolerance, levelStrength)
+		levels := supports
+		if alert.Condition == models.AlertResistance {
+			levels = resistances
+		}
+
+		var (
+			nearest = 0.0
+			found   = false
+		)
+		for _, level := range levels {
+			if !crossed(alert.Direction, prevClose, lastClose, level, level) {
+				continue
+			}
+			if !found || math.Abs(lastClose-level) < math.Abs(lastClose-nearest) {
+				nearest, found = level, true
+			}
+		}
+		return nearest, found
+	}
+
+	return 0, false
+}
+
+// check evaluates every pending alert on each candle after the day it was last evaluated
+// on, up to the given trading day and its expiry, and returns the alerts evaluated on new
+// candles with EvaluatedOn set to the latest one. An alert triggers on the first candle
+// crossing its level and is not evaluated on the later ones. Only the candles of sessions
+// closing after the creation of an alert are evaluated, so that a close the alert did not
+// exist for does not trigger it; alerts without such candles and stocks without candles
+// are skipped.
+func check(pending []models.Alert, candles map[string][]models.Candle, day time.Time) []models.Alert {
+	var (
+		session   = day.Format("2006-01-02")
+		evaluated = make([]models.Alert, 0)
+	)
+
+	for i := range pending {
+		alert := pending[i]
+		series := candles[alert.Symbol]
+		last := len(series) - 1
+		for last >= 0 && series[last].Timestamp.Format("2006-01-02") > session {
+			last--
+		}
+		if last < 0 {
+			continue
+		}
+
+		from := calendar.NSE.LastSession(alert.CreatedAt).AddDate(0, 0, 1).Format("2006-01-02")
+		if alert.EvaluatedOn != nil {
+			from = max(from, alert.EvaluatedOn.AddDate(0, 0, 1).Format("2006-01-02"))
+		}
+		if series[last].Timestamp.Format("2006-01-02") < from {
+			continue
+		}
+
+		for j := 1; j <= last; j++ {
+			candleDay := series[j].Timestamp.Format("2006-01-02")
+			if candleDay < from {
+				continue
+			}
+			if alert.ExpiresOn != nil && candleDay > alert.ExpiresOn.Format("2006-01-02") {
+				break
+			}
+
+			level, ok := evaluate(&alert, series[:j+1])
+			if !ok {
+				continue
+			}
+
+			triggeredOn := series[j].Timestamp
+			alert.TriggeredOn = &triggeredOn
+			alert.TriggeredLevel = utils.Round2(level)
+			alert.TriggeredClose = series[j].Close
+			break
+		}
+
+		evaluatedOn := series[last].Timestamp
+		alert.EvaluatedOn = &evaluatedOn
+		evaluated = append(evaluated, alert)
+	}
+
+	return evaluated
+}
+
+// Evaluate checks the pending alerts against the candles ingested since their last
+// evaluation, up to the trading day of the latest stored candle, stores the evaluation,
+// and sends the triggered alerts to the sinks of the dispatcher (if not nil). It is run
+// after every ingestion.
+func Evaluate(ctx context.Context, dispatcher *notify.Dispatcher) error {
+	pending, err := db.FetchPendingAlerts(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to fetch the pending alerts: %w", err)
+	}
+	if len(pending) == 0 {
+		return nil
+	}
+
+	day, err := dataflow.LatestSession(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to find the ingested trading day: %w", err)
+	}
+
+	var (
+		symbols = make([]string, 0, len(pending))
+		seen    = make(map[string]struct{}, len(pending))
+	)
+	for i := range pending {
+		if _, ok := seen[pending[i].Symbol]; !ok {
+			seen[pending[i].Symbol] = struct{}{}
+			symbols = append(symbols, pending[i].Symbol)
+		}
+	}
+
+	candles, err := db.FetchCandlesBatch(ctx, symbols, lookback)
+	if err != nil {
+		return fmt.Errorf("failed to fetch the candles of the alerts: %w", err)
+	}
+
+	evaluated := check(pending, candles, day)
+	if err := db.SaveAlertEvaluations(ctx, evaluated); err != nil {
+		return fmt.Errorf("failed to store the evaluated alerts: %w", err)
+	}
+
+	triggered := make([]models.Alert, 0)
+	for i := range evaluated {
+		if evaluated[i].TriggeredOn != nil {
+			triggered = append(triggered, evaluated[i])
+		}
+	}
+
+	for i := range triggered {
+		slog.Info(
+			"alert triggered",
+			"id", triggered[i].ID,
+			"symbol", triggered[i].Symbol,
+			"condition", triggered[i].Spec(),
+			"direction", triggered[i].Direction,
+			"level", triggered[i].TriggeredLevel,
+			"close", triggered[i].TriggeredClose,
+		)
+	}
+	slog.Info("alerts evaluated", "pending", len(pending), "evaluated", len(evaluated), "triggered", len(triggered))
+
+	if dispatcher == nil {
+		return nil
+	}
+	return dispatcher.SendAlerts(ctx, day, triggered)
+}
@@ -0,0 +1,228 @@
+package alerts
+
+import (
+	"eeye/src/models"
+	"eeye/src/testutil"
+	"math"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestValidate(t *testing.T) {
+	var (
+		today     = time.Date(2024, 3, 15, 0, 0, 0, 0, time.UTC)
+		yesterday = today.AddDate(0, 0, -1)
+	)
+
+	tests := []struct {
+		name    string
+		alert   models.Alert
+		wantErr string
+	}{
+		{name: "price", alert: models.Alert{Symbol: "TCS", Condition: models.AlertPrice, Level: 4200, Direction: models.CrossAbove}},
+		{name: "ema", alert: models.Alert{Symbol: "TCS", Condition: models.AlertEMA, Period: 50, Direction: models.CrossBelow}},
+		{name: "expires today", alert: models.Alert{Symbol: "TCS", Condition: models.AlertSupport, Direction: models.CrossBelow, ExpiresOn: &today}},
+		{name: "no symbol", alert: models.Alert{Condition: models.AlertSupport, Direction: models.CrossBelow}, wantErr: "symbol is required"},
+		{name: "no price level", alert: models.Alert{Symbol: "TCS", Condition: models.AlertPrice, Direction: models.CrossAbove}, wantErr: "invalid price level"},
+		{name: "long ema", alert: models.Alert{Symbol: "TCS", Condition: models.AlertEMA, Period: 500, Direction: models.CrossAbove}, wantErr: "invalid EMA period"},
+		{name: "unknown condition", alert: models.Alert{Symbol: "TCS", Condition: "rsi", Direction: models.CrossAbove}, wantErr: "unknown condition"},
+		{name: "unknown direction", alert: models.Alert{Symbol: "TCS", Condition: models.AlertSupport, Direction: "through"}, wantErr: "unknown direction"},
+		{name: "expired", alert: models.Alert{Symbol: "TCS", Condition: models.AlertSupport, Direction: models.CrossBelow, ExpiresOn: &yesterday}, wantErr: "in the past"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validate(&tt.alert, today)
+			switch {
+			case tt.wantErr == "" && err != nil:
+				t.Errorf("validate() error = %v, want nil", err)
+			case tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)):
+				t.Errorf("validate() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestEvaluate(t *testing.T) {
+	tests := []struct {
+		name      string
+		alert     models.Alert
+		series    *testutil.Series
+		want      bool
+		wantLevel float64
+	}{
+		{
+			name:      "close crosses above a price",
+			alert:     models.Alert{Condition: models.AlertPrice, Level: 100, Direction: models.CrossAbove},
+			series:    testutil.NewSeries("X").Flat(5, 99).Flat(1, 101),
+			want:      true,
+			wantLevel: 100,
+		},
+		{
+			name:   "close stays above a price",
+			alert:  models.Alert{Condition: models.AlertPrice, Level: 100, Direction: models.CrossAbove},
+			series: testutil.NewSeries("X").Flat(5, 101).Flat(1, 102),
+		},
+		{
+			name:      "close crosses below a price",
+			alert:     models.Alert{Condition: models.AlertPrice, Level: 100, Direction: models.CrossBelow},
+			series:    testutil.NewSeries("X").Flat(5, 101).Flat(1, 100),
+			want:      true,
+			wantLevel: 100,
+		},
+		{
+			name:   "close crosses a price in the other direction",
+			alert:  models.Alert{Condition: models.AlertPrice, Level: 100, Direction: models.CrossBelow},
+			series: testutil.NewSeries("X").Flat(5, 99).Flat(1, 101),
+		},
+		{
+			name:   "close gaps above the EMA of a downtrend",
+			alert:  models.Alert{Condition: models.AlertEMA, Period: 20, Direction: models.CrossAbove},
+			series: testutil.NewSeries("X").Trend(30, -0.01).Gap(0.2),
+			want:   true,
+		},
+		{
+			name:   "close stays below the EMA of a downtrend",
+			alert:  models.Alert{Condition: models.AlertEMA, Period: 20, Direction: models.CrossBelow},
+			series: testutil.NewSeries("X").Trend(30, -0.01).Gap(-0.1),
+		},
+		{
+			name:   "not enough candles for the EMA",
+			alert:  models.Alert{Condition: models.AlertEMA, Period: 50, Direction: models.CrossAbove},
+			series: testutil.NewSeries("X").Trend(30, -0.01).Gap(0.1),
+		},
+		{
+			name:      "close breaks a support",
+			alert:     models.Alert{Condition: models.AlertSupport, Direction: models.CrossBelow},
+			series:    testutil.NewSeries("X").Range(40, 95, 105).Flat(1, 100).Flat(1, 90),
+			want:      true,
+			wantLevel: 95,
+		},
+		{
+			name:   "close stays above a support",
+			alert:  models.Alert{Condition: models.AlertSupport, Direction: models.CrossBelow},
+			series: testutil.NewSeries("X").Range(40, 95, 105).Flat(1, 100).Flat(1, 98),
+		},
+		{
+			name:      "close breaks out of a resistance",
+			alert:     models.Alert{Condition: models.AlertResistance, Direction: models.CrossAbove},
+			series:    testutil.NewSeries("X").Range(40, 95, 105).Flat(1, 100).Flat(1, 110),
+			want:      true,
+			wantLevel: 105,
+		},
+		{
+			name:   "no levels",
+			alert:  models.Alert{Condition: models.AlertResistance, Direction: models.CrossAbove},
+			series: testutil.NewSeries("X").Trend(40, -0.02).Gap(0.2),
+		},
+		{
+			name:   "single candle",
+			alert:  models.Alert{Condition: models.AlertPrice, Level: 100, Direction: models.CrossAbove},
+			series: testutil.NewSeries("X").Flat(1, 101),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			level, ok := evaluate(&tt.alert, tt.series.Candles())
+			if ok != tt.want {
+				t.Fatalf("evaluate() = %v, %v, want %v", level, ok, tt.want)
+			}
+			// support/resistance levels are the mean of the clustered lows/highs
+			if tt.wantLevel != 0 && math.Abs(level-tt.wantLevel) > 1 {
+				t.Errorf("evaluate() level = %v, want about %v", level, tt.wantLevel)
+			}
+		})
+	}
+}
+
+func TestCheck(t *testing.T) {
+	var (
+		// the close crosses 100 upwards on the 4th and the 6th of January
+		series  = testutil.NewSeries("TCS").Flat(3, 99).Flat(1, 101).Flat(1, 99).Flat(1, 101).Candles()
+		candles = map[string][]models.Candle{"TCS": series}
+		on      = func(d int) time.Time {
+			return time.Date(2024, time.January, d, 0, 0, 0, 0, time.UTC)
+		}
+		at = func(d int) *time.Time {
+			t := on(d)
+			return &t
+		}
+	)
+
+	tests := []struct {
+		name    string
+		symbol  string
+		created int
+		// createdAfterClose creates the alert in the evening of the created day instead of
+		// its midnight
+		createdAfterClose bool
+		evaluated         *time.Time
+		expires           *time.Time
+		day               int
+		// wantEvaluated is the day the alert is evaluated up to, 0 if it is not evaluated
+		wantEvaluated int
+		// wantTriggered is the day the alert triggers on, 0 if it does not trigger
+		wantTriggered int
+	}{
+		{name: "crossing on the latest candle", created: 1, evaluated: at(5), day: 6, wantEvaluated: 6, wantTriggered: 6},
+		{name: "crossing in a session without evaluation", created: 1, evaluated: at(3), day: 6, wantEvaluated: 6, wantTriggered: 4},
+		{name: "first evaluation", created: 2, day: 6, wantEvaluated: 6, wantTriggered: 4},
+		{name: "crossing before the creation", created: 5, day: 6, wantEvaluated: 6, wantTriggered: 6},
+		{name: "created after the close of the crossing", created: 4, createdAfterClose: true, day: 6, wantEvaluated: 6, wantTriggered: 6},
+		{name: "created after the close of the latest candle", created: 5, createdAfterClose: true, day: 5},
+		{name: "expired before the crossing", created: 5, expires: at(5), day: 6, wantEvaluated: 6},
+		{name: "latest candle after the ingested day", created: 1, evaluated: at(3), day: 5, wantEvaluated: 5, wantTriggered: 4},
+		{name: "no new candle", created: 1, evaluated: at(6), day: 7},
+		{name: "created after the day", created: 9, day: 6},
+		{name: "no candles", symbol: "INFY", created: 1, day: 6},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			symbol := tt.symbol
+			if symbol == "" {
+				symbol = "TCS"
+			}
+			created := on(tt.created)
+			if tt.createdAfterClose {
+				created = created.Add(23 * time.Hour)
+			}
+			pending := []models.Alert{{
+				ID:          1,
+				Symbol:      symbol,
+				Condition:   models.AlertPrice,
+				Level:       100,
+				Direction:   models.CrossAbove,
+				CreatedAt:   created,
+				EvaluatedOn: tt.evaluated,
+				ExpiresOn:   tt.expires,
+			}}
+
+			evaluated := check(pending, candles, on(tt.day))
+			if tt.wantEvaluated == 0 {
+				if len(evaluated) != 0 {
+					t.Errorf("check() = %+v, want no alert evaluated", evaluated)
+				}
+				return
+			}
+			if len(evaluated) != 1 || !evaluated[0].EvaluatedOn.Equal(on(tt.wantEvaluated)) {
+				t.Fatalf("check() = %+v, want the alert evaluated up to %v", evaluated, on(tt.wantEvaluated))
+			}
+
+			alert := evaluated[0]
+			switch {
+			case tt.wantTriggered == 0 && alert.TriggeredOn != nil:
+				t.Errorf("check() triggered on %v, want no trigger", alert.TriggeredOn)
+			case tt.wantTriggered != 0 && (alert.TriggeredOn == nil || !alert.TriggeredOn.Equal(on(tt.wantTriggered))):
+				t.Errorf("check() triggered on %v, want %v", alert.TriggeredOn, on(tt.wantTriggered))
+			case tt.wantTriggered != 0 && (alert.TriggeredLevel != 100 || alert.TriggeredClose != 101):
+				t.Errorf("check() = %+v, want triggered at 100 with close 101", alert)
+			}
+			if pending[0].TriggeredOn != nil || pending[0].EvaluatedOn != tt.evaluated {
+				t.Error("check() modified the pending alerts")
+			}
+		})
+	}
+}
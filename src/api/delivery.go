@@ -0,0 +1,114 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"eeye/src/config"
+	"eeye/src/models"
+	"eeye/src/utils"
+	"encoding/csv"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Columns of the security-wise delivery file used by the application
+const (
+	deliverySymbolColumn   = "SYMBOL"
+	deliverySeriesColumn   = "SERIES"
+	deliveryTurnoverColumn = "TURNOVER_LACS"
+	deliveryQtyColumn      = "DELIV_QTY"
+	deliveryPctColumn      = "DELIV_PER"
+)
+
+// lakh is the unit of the turnover in the delivery file
+const lakh = 100000
+
+// parseDeliveryNumber parses a numeric cell of the delivery file.
+// Securities without delivery data (e.g. non EQ series) have "-" instead of a number.
+func parseDeliveryNumber(value string) (float64, error) {
+	value = strings.TrimSpace(value)
+	if value == "" || value == "-" {
+		return 0, nil
+	}
+	return strconv.ParseFloat(value, 64)
+}
+
+// ParseDeliveryData parses a security-wise delivery file (sec_bhavdata_full).
+// Headers and cells of the file are padded with spaces, which are trimmed.
+func ParseDeliveryData(data []byte) ([]models.NSEDeliveryData, error) {
+	empty := utils.EmptySlice[models.NSEDeliveryData]()
+
+	records, err := csv.NewReader(bytes.NewReader(data)).ReadAll()
+	if err != nil {
+		return empty, fmt.Errorf("ParseDeliveryData: %w", err)
+	}
+
+	if len(records) == 0 {
+		return empty, fmt.Errorf("ParseDeliveryData: file is empty")
+	}
+
+	index := make(map[string]int)
+	for i, header := range records[0] {
+		index[strings.TrimSpace(header)] = i
+	}
+
+	for _, column := range []string{deliverySymbolColumn, deliverySeriesColumn, deliveryTurnoverColumn, deliveryQtyColumn, deliveryPctColumn} {
+		if _, ok := index[column]; !ok {
+			return empty, fmt.Errorf("ParseDeliveryData: missing column %v", column)
+		}
+	}
+
+	rows := make([]models.NSEDeliveryData, 0, len(records)-1)
+	for _, record := range records[1:] {
+		turnover, err := parseDeliveryNumber(record[index[deliveryTurnoverColumn]])
+		if err != nil {
+			return empty, fmt.Errorf("ParseDeliveryData: invalid turnover: %w", err)
+		}
+
+		qty, err := parseDeliveryNumber(record[index[deliveryQtyColumn]])
+		if err != nil {
+			return empty, fmt.Errorf("ParseDeliveryData: invalid delivery quantity: %w", err)
+		}
+
+		pct, err := parseDeliveryNumber(record[index[deliveryPctColumn]])
+		if err != nil {
+			return empty, fmt.Errorf("ParseDeliveryData: invalid delivery percentage: %w", err)
+		}
+
+		rows = append(rows, models.NSEDeliveryData{
+			Symbol:      strings.TrimSpace(record[index[deliverySymbolColumn]]),
+			Series:      strings.TrimSpace(record[index[deliverySeriesColumn]]),
+			DeliveryQty: uint64(qty),
+			DeliveryPct: pct,
+			Turnover:    turnover * lakh,
+		})
+	}
+
+	return rows, nil
+}
+
+// DownloadDeliveryData downloads the NSE security-wise delivery file of the given trading day
+func DownloadDeliveryData(ctx context.Context, day time.Time) ([]models.NSEDeliveryData, error) {
+	empty := utils.EmptySlice[models.NSEDeliveryData]()
+
+	// Format: sec_bhavdata_full_DDMMYYYY.csv
+	url := fmt.Sprintf("%v/sec_bhavdata_full_%s.csv", config.NSE.DeliveryBaseURL, day.Format("02012006"))
+
+	slog.Debug("fetching delivery data", "url", url)
+	resp, err := NseClient.
+		R().
+		SetContext(ctx).
+		Get(url)
+	if err != nil {
+		return empty, fmt.Errorf("DownloadDeliveryData: %w", err)
+	}
+
+	if resp.IsError() {
+		return empty, fmt.Errorf("DownloadDeliveryData: %w", newHTTPError(resp.StatusCode(), resp.Header()))
+	}
+
+	return ParseDeliveryData(resp.Body())
+}
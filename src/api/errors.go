@@ -0,0 +1,78 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// HTTPError is returned when a provider responds with an error status code.
+type HTTPError struct {
+	// StatusCode is the HTTP status code of the response
+	StatusCode int
+
+	// RetryAfter is the wait requested by the server (Retry-After header), if any
+	RetryAfter time.Duration
+}
+
+//revive:disable-next-line exported
+func (e *HTTPError) Error() string {
+	return fmt.Sprintf("network request failed with status %v", e.StatusCode)
+}
+
+// newHTTPError creates an HTTPError from the status code and headers of a response
+func newHTTPError(statusCode int, header http.Header) *HTTPError {
+	return &HTTPError{
+		StatusCode: statusCode,
+		RetryAfter: parseRetryAfter(header.Get("Retry-After")),
+	}
+}
+
+// parseRetryAfter parses a Retry-After header given either in seconds or as an HTTP date
+func parseRetryAfter(value string) time.Duration {
+	if value == "" {
+		return 0
+	}
+
+	if seconds, err := strconv.Atoi(value); err == nil && seconds > 0 {
+		return time.Duration(seconds) * time.Second
+	}
+
+	if at, err := http.ParseTime(value); err == nil {
+		if d := time.Until(at); d > 0 {
+			return d
+		}
+	}
+
+	return 0
+}
+
+// IsTransient reports whether err is worth retrying: network failures,
+// rate limiting (429) and server side (5xx) errors. Cancelled requests are not
+// transient even though the HTTP client reports them as network errors.
+func IsTransient(err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+
+	var httpErr *HTTPError
+	if errors.As(err, &httpErr) {
+		return httpErr.StatusCode == http.StatusTooManyRequests || httpErr.StatusCode >= http.StatusInternalServerError
+	}
+
+	var netErr net.Error
+	return errors.As(err, &netErr)
+}
+
+// RetryAfter returns the wait requested by the server for err, or zero
+func RetryAfter(err error) time.Duration {
+	var httpErr *HTTPError
+	if errors.As(err, &httpErr) {
+		return httpErr.RetryAfter
+	}
+	return 0
+}
@@ -0,0 +1,99 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestIsTransient(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{name: "no error", err: nil, want: false},
+		{name: "rate limited", err: &HTTPError{StatusCode: http.StatusTooManyRequests}, want: true},
+		{name: "server error", err: &HTTPError{StatusCode: http.StatusInternalServerError}, want: true},
+		{name: "bad gateway", err: &HTTPError{StatusCode: http.StatusBadGateway}, want: true},
+		{name: "not found", err: &HTTPError{StatusCode: http.StatusNotFound}, want: false},
+		{name: "unauthorized", err: &HTTPError{StatusCode: http.StatusUnauthorized}, want: false},
+		{name: "wrapped server error", err: fmt.Errorf("GetCandles: %w", &HTTPError{StatusCode: http.StatusServiceUnavailable}), want: true},
+		{name: "network failure", err: &net.OpError{Op: "dial", Err: errors.New("connection refused")}, want: true},
+		{name: "cancelled", err: fmt.Errorf("GetCandles: %w", context.Canceled), want: false},
+		{name: "deadline exceeded", err: context.DeadlineExceeded, want: false},
+		{name: "parsing failure", err: errors.New("invalid character"), want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := IsTransient(tt.err); got != tt.want {
+				t.Errorf("IsTransient(%v) = %v, want %v", tt.err, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Now()
+
+	tests := []struct {
+		name  string
+		value string
+		// want is the expected wait; HTTP dates are only precise to the second, so
+		// waits up to a second shorter are accepted for them
+		want     time.Duration
+		fromDate bool
+	}{
+		{name: "missing", value: "", want: 0},
+		{name: "seconds", value: "120", want: 2 * time.Minute},
+		{name: "zero seconds", value: "0", want: 0},
+		{name: "negative seconds", value: "-5", want: 0},
+		{name: "http date", value: now.Add(90 * time.Second).UTC().Format(http.TimeFormat), want: 90 * time.Second, fromDate: true},
+		{name: "http date in the past", value: now.Add(-time.Minute).UTC().Format(http.TimeFormat), want: 0},
+		{name: "invalid", value: "soon", want: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := parseRetryAfter(tt.value)
+			if tt.fromDate {
+				if got <= tt.want-2*time.Second || got > tt.want {
+					t.Errorf("parseRetryAfter(%q) = %v, want about %v", tt.value, got, tt.want)
+				}
+				return
+			}
+			if got != tt.want {
+				t.Errorf("parseRetryAfter(%q) = %v, want %v", tt.value, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRetryAfter(t *testing.T) {
+	header := http.Header{}
+	header.Set("Retry-After", "3")
+
+	tests := []struct {
+		name string
+		err  error
+		want time.Duration
+	}{
+		{name: "http error", err: newHTTPError(http.StatusTooManyRequests, header), want: 3 * time.Second},
+		{name: "wrapped http error", err: fmt.Errorf("GetCandles: %w", newHTTPError(http.StatusServiceUnavailable, header)), want: 3 * time.Second},
+		{name: "without header", err: newHTTPError(http.StatusTooManyRequests, http.Header{}), want: 0},
+		{name: "other error", err: errors.New("timeout"), want: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := RetryAfter(tt.err); got != tt.want {
+				t.Errorf("RetryAfter(%v) = %v, want %v", tt.err, got, tt.want)
+			}
+		})
+	}
+}
@@ -1,20 +1,21 @@
 package api
 
 import (
+	"context"
 	"eeye/src/config"
 	"eeye/src/constants"
 	"eeye/src/models"
 	"eeye/src/utils"
 	"fmt"
-	"log"
+	"log/slog"
 	"time"
 )
 
 // GetCandles retrieves candlestick data for a given stock within a specified time range.
 // It returns an array of Candle objects containing OHLCV data. If startTime equals endTime,
 // or if there's an error in fetching data, it returns an empty slice and the error if any.
-func GetCandles(stock *models.Stock, startTime string, endTime string) ([]models.Candle, error) {
-	log.Printf("fetching candles for %v from %v to %v\n", stock.Symbol, startTime, endTime)
+func GetCandles(ctx context.Context, stock *models.Stock, startTime string, endTime string) ([]models.Candle, error) {
+	slog.Debug("fetching candles", "symbol", stock.Symbol, "from", startTime, "to", endTime)
 	var (
 		body  = models.CandlesResponse{}
 		empty = utils.EmptySlice[models.Candle]()
@@ -26,12 +27,13 @@ func GetCandles(stock *models.Stock, startTime string, endTime string) ([]models
 	}
 
 	if startTime >= endTime {
-		log.Printf("start time and end time are the same for %v, returning empty slice\n", stock.Symbol)
+		slog.Debug("start time and end time are the same, returning no candles", "symbol", stock.Symbol)
 		return empty, nil
 	}
 
 	resp, err := GrowwClient.
 		R().
+		SetContext(ctx).
 		SetQueryParam("exchange", stock.Exchange).
 		SetQueryParam("segment", stock.Segment).
 		SetQueryParam("trading_symbol", stock.Symbol).
@@ -46,7 +48,7 @@ func GetCandles(stock *models.Stock, startTime string, endTime string) ([]models
 	}
 
 	if resp.IsError() {
-		return empty, fmt.Errorf("unauthorized network request: %v", resp.StatusCode())
+		return empty, newHTTPError(resp.StatusCode(), resp.Header())
 	}
 
 	if body.Status != "SUCCESS" {
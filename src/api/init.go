@@ -7,6 +7,7 @@ import (
 	"eeye/src/config"
 	"eeye/src/constants"
 	"fmt"
+	"net/http"
 
 	"github.com/go-resty/resty/v2"
 )
@@ -18,6 +19,9 @@ var GrowwClient *resty.Client
 // NseClient is the shared HTTP client for making requests to the NSE API.
 var NseClient *resty.Client
 
+// growwLimiter bounds the rate of all requests made through GrowwClient
+var growwLimiter *rateLimiter
+
 // InitNseClient initializes the global HTTP client with proper configuration
 // for making requests to the NSE API. This includes setting up headers
 // and base URL.
@@ -29,15 +33,45 @@ func InitNseClient() {
 	NseClient.SetHeader("Pragma", "no-cache")
 	NseClient.SetHeader("Expires", "0")
 	NseClient.SetBaseURL(config.NSE.BaseURL)
+	useFixtures(NseClient, "nse", config.HTTP.Mode, config.HTTP.FixturesDir)
 }
 
 // InitGrowwTradingClient initializes the global HTTP client with proper configuration
 // for making requests to the trading API. This includes setting up authentication,
 // API version headers, and base URL.
+//
+// Every request made through the client passes a shared token bucket rate limiter
+// (config.Groww.RequestPerSecond with bursts of config.Groww.Burst), which backs off
+// when the server responds with 429 Too Many Requests.
+//
+// In record and replay mode (config.HTTP.Mode) responses are recorded to or
+// replayed from config.HTTP.FixturesDir, see StartFixtureSession.
 func InitGrowwTradingClient() {
+	growwLimiter = newRateLimiter(config.Groww.RequestPerSecond, config.Groww.Burst)
+
 	GrowwClient = resty.New()
 	GrowwClient.SetHeader("Authorization", "Bearer "+config.Groww.AccessToken)
 	GrowwClient.SetHeader("X-API-VERSION", config.Groww.XAPIVersion)
 	GrowwClient.SetHeader("Accept", "application/json")
 	GrowwClient.SetBaseURL(fmt.Sprintf("%v/%v", config.Groww.BaseURL, config.Groww.APIVersion))
+	useFixtures(GrowwClient, "groww", config.HTTP.Mode, config.HTTP.FixturesDir)
+
+	// Replayed responses do not reach Groww, so they are not rate limited
+	if config.HTTP.Mode == constants.HTTPModeReplay {
+		return
+	}
+
+	GrowwClient.OnBeforeRequest(func(_ *resty.Client, req *resty.Request) error {
+		return growwLimiter.Wait(req.Context())
+	})
+
+	GrowwClient.OnAfterResponse(func(_ *resty.Client, resp *resty.Response) error {
+		switch {
+		case resp.StatusCode() == http.StatusTooManyRequests:
+			growwLimiter.throttle(parseRetryAfter(resp.Header().Get("Retry-After")))
+		case !resp.IsError():
+			growwLimiter.relax()
+		}
+		return nil
+	})
 }
@@ -3,44 +3,43 @@ package api
 import (
 	"archive/zip"
 	"bytes"
+	"context"
+	"eeye/src/calendar"
 	"eeye/src/constants"
 	"eeye/src/models"
 	"eeye/src/utils"
 	"fmt"
-	"log"
+	"log/slog"
 	"time"
 
 	"github.com/gocarina/gocsv"
 )
 
-// Downloads the zip file in memory
-// Extracts the CSV file (only file in zip) and unmarshals it into a slice of NSEStockData
-func getBhavcopyData(zipFileName string) ([]models.NSEStockData, error) {
-	empty := utils.EmptySlice[models.NSEStockData]()
+// BhavcopyFileName returns the name of the NSE CM bhavcopy zip file of the given day
+func BhavcopyFileName(day time.Time) string {
+	// Format: BhavCopy_NSE_CM_0_0_0_YYYYMMDD_F_0000.csv.zip
+	return fmt.Sprintf("BhavCopy_NSE_CM_0_0_0_%s_F_0000.csv.zip", day.Format("20060102"))
+}
 
-	// download zip file
-	log.Printf("getBhavcopyData: Trying to fetch %v", zipFileName)
-	resp, err := NseClient.
-		R().
-		Get(zipFileName)
-	if err != nil {
-		return empty, fmt.Errorf("getBhavcopyData: %w", err)
-	}
+// ParseBhavcopy extracts the CSV file (only file in zip) of a bhavcopy zip archive
+// and unmarshals it into a slice of NSEStockData
+func ParseBhavcopy(data []byte) ([]models.NSEStockData, error) {
+	empty := utils.EmptySlice[models.NSEStockData]()
 
 	// Get a reader for zip file
-	r, err := zip.NewReader(bytes.NewReader(resp.Body()), int64(len(resp.Body())))
+	r, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
 	if err != nil {
-		return empty, fmt.Errorf("getBhavcopyData: %w", err)
+		return empty, fmt.Errorf("ParseBhavcopy: %w", err)
 	}
 
 	// Get the first (and only) file in the zip
 	if len(r.File) == 0 {
-		return empty, fmt.Errorf("getBhavcopyData: zip file is empty")
+		return empty, fmt.Errorf("ParseBhavcopy: zip file is empty")
 	}
 
 	rc, err := r.File[0].Open()
 	if err != nil {
-		return empty, fmt.Errorf("getBhavcopyData: %w", err)
+		return empty, fmt.Errorf("ParseBhavcopy: %w", err)
 	}
 	defer func() {
 		_ = rc.Close()
@@ -48,41 +47,72 @@ func getBhavcopyData(zipFileName string) ([]models.NSEStockData, error) {
 
 	stocks := make([]models.NSEStockData, 0, constants.NumOfStocks)
 	if err := gocsv.Unmarshal(rc, &stocks); err != nil {
+		return empty, fmt.Errorf("ParseBhavcopy: %w", err)
+	}
+
+	return stocks, nil
+}
+
+// Downloads the zip file in memory and parses it
+func getBhavcopyData(ctx context.Context, zipFileName string) ([]models.NSEStockData, error) {
+	empty := utils.EmptySlice[models.NSEStockData]()
+
+	// download zip file
+	slog.Debug("fetching bhavcopy", "file", zipFileName)
+	resp, err := NseClient.
+		R().
+		SetContext(ctx).
+		Get(zipFileName)
+	if err != nil {
+		return empty, fmt.Errorf("getBhavcopyData: %w", err)
+	}
+
+	if resp.IsError() {
+		return empty, fmt.Errorf("getBhavcopyData: %w", newHTTPError(resp.StatusCode(), resp.Header()))
+	}
+
+	stocks, err := ParseBhavcopy(resp.Body())
+	if err != nil {
 		return empty, fmt.Errorf("getBhavcopyData: %w", err)
 	}
 
 	return stocks, nil
 }
 
+// DownloadBhavcopy downloads the NSE CM bhavcopy of the given trading day
+func DownloadBhavcopy(ctx context.Context, day time.Time) ([]models.NSEStockData, error) {
+	return getBhavcopyData(ctx, BhavcopyFileName(day))
+}
+
 // DownloadLatestBhavcopy attempts to download the latest NSE Bhavcopy zip file
-// and extract the bhavcopy data CSV. It tries up to 10 previous days
-// if the latest file is not available (e.g., weekends, holidays).
-func DownloadLatestBhavcopy() ([]models.NSEStockData, string, error) {
+// and extract the bhavcopy data CSV. It starts from the last completed trading
+// session and walks back through previous trading days (skipping weekends and
+// holidays) if the file is not published yet.
+func DownloadLatestBhavcopy(ctx context.Context) ([]models.NSEStockData, string, error) {
 	const (
-		Probes = 10
+		Probes = 5
 	)
 
 	var (
-		now   = time.Now().Local()
+		day   = calendar.NSE.LastSession(utils.Now())
 		i     = 0
 		empty = utils.EmptySlice[models.NSEStockData]()
 	)
 	for i < Probes {
-		var (
-			// Format: BhavCopy_NSE_CM_0_0_0_YYYYMMDD_F_0000.csv.zip
-			dateStr        = now.Format("20060102")
-			zipFileName    = fmt.Sprintf("BhavCopy_NSE_CM_0_0_0_%s_F_0000.csv.zip", dateStr)
-			lastTradingDay = now.Format("2006-01-02")
-		)
-
-		stocks, err := getBhavcopyData(zipFileName)
+		lastTradingDay := day.Format("2006-01-02")
+
+		stocks, err := DownloadBhavcopy(ctx, day)
+		if ctx.Err() != nil {
+			return empty, "", ctx.Err()
+		}
+
 		if err == nil && len(stocks) > 0 {
-			log.Printf("DownloadLatestBhavcopy: Last trading day is %v", lastTradingDay)
+			slog.Info("found latest bhavcopy", "last_trading_day", lastTradingDay)
 			return stocks, lastTradingDay, nil
 		}
 
-		log.Printf("DownloadLatestBhavcopy: %v\n", err)
-		now = now.AddDate(0, 0, -1)
+		slog.Debug("bhavcopy not available", "err", err)
+		day = calendar.NSE.PrevTradingDay(day)
 		i++
 	}
 
@@ -0,0 +1,155 @@
+package api
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// rateLimiter is a token bucket shared by all requests of a client. Tokens refill
+// at the current rate up to the burst size; every request consumes one token and
+// waits if none is available. On a 429 response the rate is halved and requests are
+// paused for the Retry-After period, then the rate recovers gradually on successes.
+type rateLimiter struct {
+	mu sync.Mutex
+
+	// rate is the current refill rate in tokens per second
+	rate float64
+
+	// maxRate is the configured refill rate the limiter recovers to
+	maxRate float64
+
+	// minRate is the floor of the adaptive slow-down
+	minRate float64
+
+	// burst is the capacity of the bucket
+	burst float64
+
+	// tokens is the number of available tokens; negative values are
+	// reservations of requests already waiting
+	tokens float64
+
+	// last is the time tokens were last refilled
+	last time.Time
+
+	// pausedUntil holds back all requests after a 429 response
+	pausedUntil time.Time
+
+	// now returns the current time, replaced in tests
+	now func() time.Time
+}
+
+// newRateLimiter creates a full token bucket allowing rps requests per second
+// with bursts of up to burst requests
+func newRateLimiter(rps int, burst int) *rateLimiter {
+	const (
+		// MinRateDivisor bounds the slow-down to 1/8th of the configured rate
+		MinRateDivisor = 8
+	)
+
+	return &rateLimiter{
+		rate:    float64(rps),
+		maxRate: float64(rps),
+		minRate: float64(rps) / MinRateDivisor,
+		burst:   float64(burst),
+		tokens:  float64(burst),
+		last:    time.Now(),
+		now:     time.Now,
+	}
+}
+
+// refill adds the tokens accumulated since the last refill. Must hold mu.
+func (l *rateLimiter) refill(now time.Time) {
+	if !now.After(l.last) {
+		return
+	}
+
+	l.tokens = min(l.burst, l.tokens+now.Sub(l.last).Seconds()*l.rate)
+	l.last = now
+}
+
+// reserve takes a token and returns how long the caller has to wait before using it.
+// During a pause tokens only start to refill when it ends, so the requests reserved
+// meanwhile are released one by one at the current rate after the pause instead of
+// all at once.
+func (l *rateLimiter) reserve() time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	l.refill(now)
+	l.tokens--
+
+	// last is the end of the pause while paused
+	var wait time.Duration
+	if l.last.After(now) {
+		wait = l.last.Sub(now)
+	}
+	if l.tokens < 0 {
+		wait += time.Duration(-l.tokens / l.rate * float64(time.Second))
+	}
+
+	return wait
+}
+
+// Wait blocks until the request is allowed to proceed or ctx is done
+func (l *rateLimiter) Wait(ctx context.Context) error {
+	wait := l.reserve()
+	if wait <= 0 {
+		return nil
+	}
+
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// throttle slows the limiter down after a 429 response. All requests are paused
+// for retryAfter (one second if the server did not say) and the rate is halved.
+func (l *rateLimiter) throttle(retryAfter time.Duration) {
+	if retryAfter <= 0 {
+		retryAfter = time.Second
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	var (
+		now   = l.now()
+		until = now.Add(retryAfter)
+	)
+
+	l.refill(now)
+	l.rate = max(l.minRate, l.rate/2)
+	l.tokens = min(l.tokens, 0)
+	if until.After(l.pausedUntil) {
+		l.pausedUntil = until
+		// no tokens accumulate while paused
+		l.last = until
+	}
+
+	slog.Warn("rate limited by server, slowing down", "pause", retryAfter, "rps", l.rate)
+}
+
+// relax moves the rate back towards the configured rate after a successful response
+func (l *rateLimiter) relax() {
+	const (
+		// RecoverySteps is the number of successful responses to fully recover
+		RecoverySteps = 10
+	)
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.rate < l.maxRate {
+		l.refill(l.now())
+		l.rate = min(l.maxRate, l.rate+l.maxRate/RecoverySteps)
+	}
+}
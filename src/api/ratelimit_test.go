@@ -0,0 +1,166 @@
+package api
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+// clock is a fake time source for the limiter
+type clock struct {
+	t time.Time
+}
+
+func (c *clock) now() time.Time {
+	return c.t
+}
+
+// testLimiter returns a full limiter driven by the returned clock
+func testLimiter(rps int, burst int) (*rateLimiter, *clock) {
+	c := &clock{t: time.Date(2025, 3, 20, 10, 0, 0, 0, time.UTC)}
+	l := newRateLimiter(rps, burst)
+	l.now = c.now
+	l.last = c.t
+	return l, c
+}
+
+func TestRateLimiter(t *testing.T) {
+	tests := []struct {
+		name string
+		rps  int
+		// burst is the bucket capacity
+		burst int
+		// drain is the number of requests reserved first
+		drain int
+		// throttles is the number of 429 responses received after draining
+		throttles int
+		// retryAfter is the Retry-After of the 429 responses
+		retryAfter time.Duration
+		// advance is the time elapsed before the checked reservations
+		advance time.Duration
+		// want holds the waits of the checked reservations
+		want     []time.Duration
+		wantRate float64
+	}{
+		{
+			name:     "burst then rate",
+			rps:      2,
+			burst:    3,
+			want:     []time.Duration{0, 0, 0, 500 * time.Millisecond, time.Second},
+			wantRate: 2,
+		},
+		{
+			name:     "partial refill",
+			rps:      2,
+			burst:    2,
+			drain:    2,
+			advance:  500 * time.Millisecond,
+			want:     []time.Duration{0, 500 * time.Millisecond},
+			wantRate: 2,
+		},
+		{
+			name:     "refill capped at the burst",
+			rps:      2,
+			burst:    2,
+			drain:    2,
+			advance:  10 * time.Second,
+			want:     []time.Duration{0, 0, 500 * time.Millisecond},
+			wantRate: 2,
+		},
+		{
+			name:       "requests reserved during a pause are spread after it",
+			rps:        4,
+			burst:      4,
+			drain:      4,
+			throttles:  1,
+			retryAfter: 2 * time.Second,
+			want:       []time.Duration{2500 * time.Millisecond, 3 * time.Second, 3500 * time.Millisecond},
+			wantRate:   2,
+		},
+		{
+			name:       "pause drops the tokens left",
+			rps:        4,
+			burst:      4,
+			drain:      1,
+			throttles:  1,
+			retryAfter: 2 * time.Second,
+			advance:    500 * time.Millisecond,
+			want:       []time.Duration{2 * time.Second, 2500 * time.Millisecond},
+			wantRate:   2,
+		},
+		{
+			name:      "one second pause without Retry-After",
+			rps:       4,
+			burst:     4,
+			drain:     4,
+			throttles: 1,
+			want:      []time.Duration{1500 * time.Millisecond},
+			wantRate:  2,
+		},
+		{
+			name:       "refill resumes after the pause",
+			rps:        4,
+			burst:      4,
+			drain:      4,
+			throttles:  1,
+			retryAfter: 2 * time.Second,
+			advance:    3 * time.Second,
+			want:       []time.Duration{0, 0, 500 * time.Millisecond},
+			wantRate:   2,
+		},
+		{
+			name:       "rate floor",
+			rps:        8,
+			burst:      8,
+			throttles:  5,
+			retryAfter: time.Second,
+			advance:    time.Second,
+			want:       []time.Duration{time.Second},
+			wantRate:   1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l, c := testLimiter(tt.rps, tt.burst)
+
+			for range tt.drain {
+				l.reserve()
+			}
+			for range tt.throttles {
+				l.throttle(tt.retryAfter)
+			}
+			c.t = c.t.Add(tt.advance)
+
+			got := make([]time.Duration, 0, len(tt.want))
+			for range tt.want {
+				got = append(got, l.reserve())
+			}
+
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("reserve() waits = %v, want %v", got, tt.want)
+			}
+			if l.rate != tt.wantRate {
+				t.Errorf("rate = %v, want %v", l.rate, tt.wantRate)
+			}
+		})
+	}
+}
+
+func TestRateLimiterRelax(t *testing.T) {
+	l, _ := testLimiter(10, 10)
+
+	l.throttle(time.Second)
+	l.throttle(time.Second)
+	if l.rate != 2.5 {
+		t.Fatalf("rate = %v after two 429 responses, want 2.5", l.rate)
+	}
+
+	want := []float64{3.5, 4.5, 5.5, 6.5, 7.5, 8.5, 9.5, 10, 10}
+	for i, rate := range want {
+		l.relax()
+		if l.rate != rate {
+			t.Errorf("rate = %v after %d successes, want %v", l.rate, i+1, rate)
+		}
+	}
+}
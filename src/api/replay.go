@@ -0,0 +1,198 @@
+package api
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"eeye/src/constants"
+	"eeye/src/utils"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"log/slog"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/go-resty/resty/v2"
+)
+
+// sessionFile stores the time at which fixtures were recorded
+const sessionFile = "session.json"
+
+// unsafeChars matches characters which are not kept in fixture file names
+var unsafeChars = regexp.MustCompile(`[^A-Za-z0-9._-]+`)
+
+// fixture is a recorded HTTP response
+type fixture struct {
+	Method     string      `json:"method"`
+	URL        string      `json:"url"`
+	StatusCode int         `json:"status_code"`
+	Header     http.Header `json:"header"`
+	Body       []byte      `json:"body"`
+}
+
+// session describes a recording
+type session struct {
+	RecordedAt time.Time `json:"recorded_at"`
+}
+
+// fixtureTransport records responses to, or replays responses from, a fixtures directory.
+// Requests are identified by method and URL; headers (e.g. the access token) are ignored.
+type fixtureTransport struct {
+	dir    string
+	mode   string
+	next   http.RoundTripper
+	client string
+}
+
+// fixturePath returns the file of the fixture for a request. The name keeps the
+// readable part of the URL path and a hash of the full URL to stay unique.
+func (t *fixtureTransport) fixturePath(req *http.Request) string {
+	var (
+		key  = req.Method + " " + req.URL.String()
+		sum  = sha256.Sum256([]byte(key))
+		name = strings.Trim(unsafeChars.ReplaceAllString(filepath.Base(req.URL.Path), "_"), "_")
+	)
+
+	return filepath.Join(t.dir, t.client, fmt.Sprintf("%s-%s.json", name, hex.EncodeToString(sum[:6])))
+}
+
+//revive:disable-next-line exported
+func (t *fixtureTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	path := t.fixturePath(req)
+	if t.mode == constants.HTTPModeReplay {
+		return t.replay(req, path)
+	}
+
+	resp, err := t.next.RoundTrip(req)
+	if err != nil {
+		return resp, err
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	_ = resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+
+	// Rate limited responses say nothing about the data and are not worth replaying
+	if resp.StatusCode != http.StatusTooManyRequests {
+		if err := writeFixture(path, &fixture{
+			Method:     req.Method,
+			URL:        req.URL.String(),
+			StatusCode: resp.StatusCode,
+			Header:     resp.Header,
+			Body:       body,
+		}); err != nil {
+			slog.Warn("failed to record fixture", "url", req.URL.String(), "err", err)
+		}
+	}
+
+	return resp, nil
+}
+
+// replay answers a request from its fixture. A request without a fixture is
+// answered with 404 Not Found, as if the data did not exist.
+func (t *fixtureTransport) replay(req *http.Request, path string) (*http.Response, error) {
+	f := fixture{StatusCode: http.StatusNotFound, Header: http.Header{}}
+
+	data, err := os.ReadFile(path)
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+		slog.Warn("no fixture", "method", req.Method, "url", req.URL.String())
+	case err != nil:
+		return nil, fmt.Errorf("failed to read fixture %v: %w", path, err)
+	default:
+		if err := json.Unmarshal(data, &f); err != nil {
+			return nil, fmt.Errorf("invalid fixture %v: %w", path, err)
+		}
+	}
+
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", f.StatusCode, http.StatusText(f.StatusCode)),
+		StatusCode:    f.StatusCode,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        f.Header,
+		Body:          io.NopCloser(bytes.NewReader(f.Body)),
+		ContentLength: int64(len(f.Body)),
+		Request:       req,
+	}, nil
+}
+
+// writeFixture stores a fixture as JSON, creating its directory if needed
+func writeFixture(path string, f *fixture) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+
+	data, err := json.MarshalIndent(f, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(path, data, 0o644)
+}
+
+// useFixtures installs the record/replay transport on a client according to mode.
+// Live mode leaves the client untouched.
+func useFixtures(client *resty.Client, name string, mode string, dir string) {
+	if mode != constants.HTTPModeRecord && mode != constants.HTTPModeReplay {
+		return
+	}
+
+	next := client.GetClient().Transport
+	if next == nil {
+		next = http.DefaultTransport
+	}
+
+	client.SetTransport(&fixtureTransport{dir: dir, mode: mode, next: next, client: name})
+}
+
+// StartFixtureSession prepares the fixtures directory for the given HTTP mode.
+// Recording stores the current time next to the fixtures; replaying freezes the
+// application clock (utils.Now) at the recorded time so that the pipeline asks
+// for exactly the data that was recorded.
+func StartFixtureSession(mode string, dir string) error {
+	path := filepath.Join(dir, sessionFile)
+
+	switch mode {
+	case constants.HTTPModeRecord:
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return fmt.Errorf("failed to create fixtures directory: %w", err)
+		}
+
+		data, err := json.MarshalIndent(session{RecordedAt: utils.Now()}, "", "  ")
+		if err != nil {
+			return err
+		}
+
+		if err := os.WriteFile(path, data, 0o644); err != nil {
+			return fmt.Errorf("failed to write %v: %w", path, err)
+		}
+		slog.Info("recording HTTP fixtures", "dir", dir)
+	case constants.HTTPModeReplay:
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("failed to read %v: %w", path, err)
+		}
+
+		s := session{}
+		if err := json.Unmarshal(data, &s); err != nil {
+			return fmt.Errorf("invalid %v: %w", path, err)
+		}
+
+		utils.FreezeTime(s.RecordedAt)
+		slog.Info("replaying HTTP fixtures", "dir", dir, "recorded_at", s.RecordedAt)
+	}
+
+	return nil
+}
@@ -0,0 +1,93 @@
+package api
+
+import (
+	"context"
+	"eeye/src/constants"
+	"eeye/src/utils"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-resty/resty/v2"
+)
+
+// fixtureClient returns a client of the server whose responses are recorded to or
+// replayed from dir
+func fixtureClient(baseURL string, mode string, dir string) *resty.Client {
+	client := resty.New().SetBaseURL(baseURL)
+	useFixtures(client, "nse", mode, dir)
+	return client
+}
+
+func TestFixtureRoundTrip(t *testing.T) {
+	var (
+		dir    = t.TempDir()
+		calls  = 0
+		server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			calls++
+			w.Header().Set("X-Day", r.URL.Query().Get("day"))
+			_, _ = w.Write([]byte(`{"symbol":"TCS"}`))
+		}))
+	)
+
+	resp, err := fixtureClient(server.URL, constants.HTTPModeRecord, dir).R().Get("/quote?day=2025-03-20")
+	if err != nil {
+		t.Fatalf("recording error = %v", err)
+	}
+	if resp.StatusCode() != http.StatusOK || string(resp.Body()) != `{"symbol":"TCS"}` {
+		t.Fatalf("recorded response = %d %q, want the server response", resp.StatusCode(), resp.Body())
+	}
+
+	// Replaying does not reach the server anymore
+	server.Close()
+
+	replay := fixtureClient(server.URL, constants.HTTPModeReplay, dir)
+	resp, err = replay.R().Get("/quote?day=2025-03-20")
+	if err != nil {
+		t.Fatalf("replaying error = %v", err)
+	}
+	if resp.StatusCode() != http.StatusOK || string(resp.Body()) != `{"symbol":"TCS"}` || resp.Header().Get("X-Day") != "2025-03-20" {
+		t.Errorf("replayed response = %d %q %v, want the recorded response", resp.StatusCode(), resp.Body(), resp.Header())
+	}
+	if calls != 1 {
+		t.Errorf("server called %d times, want only while recording", calls)
+	}
+
+	// A request without a fixture fails like a missing file on the server
+	saved := NseClient
+	defer func() { NseClient = saved }()
+	NseClient = replay
+
+	_, err = getBhavcopyData(context.Background(), "/BhavCopy_NSE_CM_0_0_0_20250321_F_0000.csv.zip")
+	var httpErr *HTTPError
+	if !errors.As(err, &httpErr) || httpErr.StatusCode != http.StatusNotFound {
+		t.Errorf("getBhavcopyData() error = %v, want a 404 for the missing fixture", err)
+	}
+}
+
+func TestFixtureSession(t *testing.T) {
+	var (
+		dir        = t.TempDir()
+		recordedAt = time.Date(2025, time.March, 20, 18, 30, 0, 0, time.UTC)
+	)
+	defer utils.FreezeTime(time.Time{})
+
+	if err := StartFixtureSession(constants.HTTPModeReplay, dir); err == nil {
+		t.Error("StartFixtureSession(replay) error = nil, want an error without a recording")
+	}
+
+	utils.FreezeTime(recordedAt)
+	if err := StartFixtureSession(constants.HTTPModeRecord, dir); err != nil {
+		t.Fatalf("StartFixtureSession(record) error = %v", err)
+	}
+
+	utils.FreezeTime(time.Time{})
+	if err := StartFixtureSession(constants.HTTPModeReplay, dir); err != nil {
+		t.Fatalf("StartFixtureSession(replay) error = %v", err)
+	}
+	if now := utils.Now(); !now.Equal(recordedAt) {
+		t.Errorf("Now() = %v after replaying, want the recording time %v", now, recordedAt)
+	}
+}
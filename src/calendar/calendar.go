@@ -0,0 +1,231 @@
+// Package calendar provides the NSE trading calendar.
+// It knows about weekends, exchange holidays and special sessions
+// (e.g. Muhurat trading or budget day sessions on a Saturday) and offers
+// session-aware date arithmetic in trading days.
+package calendar
+
+import (
+	"eeye/src/config"
+	"eeye/src/constants"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log"
+	"log/slog"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/gocarina/gocsv"
+)
+
+// dateFmt is the layout of dates in the holiday file and calendar keys
+const dateFmt = "2006-01-02"
+
+// Entry types supported in the holiday file
+const (
+	// holidayEntry marks a weekday on which the exchange is closed
+	holidayEntry = "holiday"
+
+	// specialEntry marks a day with a trading session which would otherwise be closed
+	specialEntry = "special"
+)
+
+// entry is a single row of the holiday file
+type entry struct {
+	// Date of the holiday or special session (YYYY-MM-DD)
+	Date string `csv:"date"`
+
+	// Type is either "holiday" or "special"
+	Type string `csv:"type"`
+
+	// Description is a human readable reason, e.g. "Diwali Laxmi Pujan"
+	Description string `csv:"description"`
+}
+
+// Calendar answers trading day questions for a single exchange.
+// Days are evaluated in the calendar's location.
+type Calendar struct {
+	loc      *time.Location
+	holidays map[string]string
+	special  map[string]string
+}
+
+// NSE is the calendar used across the application. Until Load is called it
+// only knows about weekends.
+var NSE = newCalendar(time.Local, nil)
+
+// newCalendar creates a calendar from the given holiday file entries
+func newCalendar(loc *time.Location, entries []entry) *Calendar {
+	c := &Calendar{
+		loc:      loc,
+		holidays: make(map[string]string),
+		special:  make(map[string]string),
+	}
+
+	for i := range entries {
+		e := &entries[i]
+		switch strings.TrimSpace(e.Type) {
+		case specialEntry:
+			c.special[strings.TrimSpace(e.Date)] = strings.TrimSpace(e.Description)
+		default:
+			c.holidays[strings.TrimSpace(e.Date)] = strings.TrimSpace(e.Description)
+		}
+	}
+
+	return c
+}
+
+// Parse reads holiday file entries (CSV with date,type,description columns)
+func Parse(data []byte, loc *time.Location) (*Calendar, error) {
+	entries := make([]entry, 0)
+	if err := gocsv.UnmarshalBytes(data, &entries); err != nil {
+		return nil, fmt.Errorf("invalid holiday file: %w", err)
+	}
+
+	for i := range entries {
+		e := &entries[i]
+		if _, err := time.ParseInLocation(dateFmt, strings.TrimSpace(e.Date), loc); err != nil {
+			return nil, fmt.Errorf("invalid date %q in holiday file: %w", e.Date, err)
+		}
+
+		switch strings.TrimSpace(e.Type) {
+		case holidayEntry, specialEntry:
+		default:
+			return nil, fmt.Errorf("invalid type %q for %v in holiday file", e.Type, e.Date)
+		}
+	}
+
+	return newCalendar(loc, entries), nil
+}
+
+// Load initializes NSE from the holiday file configured in config.Calendar.
+// A missing file is not fatal: the calendar then only skips weekends.
+func Load() {
+	loc, err := time.LoadLocation(config.DB.Tz)
+	if err != nil {
+		log.Fatalf("unable to load location: %v\n", err)
+	}
+
+	data, err := os.ReadFile(config.Calendar.HolidaysFile)
+	if errors.Is(err, fs.ErrNotExist) {
+		slog.Warn("holiday file not found, only weekends will be treated as holidays", "path", config.Calendar.HolidaysFile)
+		NSE = newCalendar(loc, nil)
+		return
+	}
+
+	if err != nil {
+		log.Fatalf("unable to read holiday file: %v\n", err)
+	}
+
+	c, err := Parse(data, loc)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	NSE = c
+	slog.Info("loaded trading calendar", "holidays", len(c.holidays), "special_sessions", len(c.special))
+}
+
+// Location returns the timezone in which the calendar evaluates days
+func (c *Calendar) Location() *time.Location {
+	return c.loc
+}
+
+// Day truncates t to the start of its day in the calendar's location
+func (c *Calendar) Day(t time.Time) time.Time {
+	t = t.In(c.loc)
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, c.loc)
+}
+
+// IsTradingDay reports whether the exchange holds a session on the day of t
+func (c *Calendar) IsTradingDay(t time.Time) bool {
+	key := t.In(c.loc).Format(dateFmt)
+	if _, ok := c.special[key]; ok {
+		return true
+	}
+
+	if _, ok := c.holidays[key]; ok {
+		return false
+	}
+
+	weekday := t.In(c.loc).Weekday()
+	return weekday != time.Saturday && weekday != time.Sunday
+}
+
+// Holiday returns the description of the holiday on the day of t, if any
+func (c *Calendar) Holiday(t time.Time) (string, bool) {
+	description, ok := c.holidays[t.In(c.loc).Format(dateFmt)]
+	return description, ok
+}
+
+// NextTradingDay returns the first trading day strictly after the day of t
+func (c *Calendar) NextTradingDay(t time.Time) time.Time {
+	day := c.Day(t).AddDate(0, 0, 1)
+	for !c.IsTradingDay(day) {
+		day = day.AddDate(0, 0, 1)
+	}
+	return day
+}
+
+// PrevTradingDay returns the last trading day strictly before the day of t
+func (c *Calendar) PrevTradingDay(t time.Time) time.Time {
+	day := c.Day(t).AddDate(0, 0, -1)
+	for !c.IsTradingDay(day) {
+		day = day.AddDate(0, 0, -1)
+	}
+	return day
+}
+
+// LastTradingDay returns the day of t if it is a trading day,
+// otherwise the previous trading day
+func (c *Calendar) LastTradingDay(t time.Time) time.Time {
+	if c.IsTradingDay(t) {
+		return c.Day(t)
+	}
+	return c.PrevTradingDay(t)
+}
+
+// LastSession returns the most recent trading day whose session has closed at t.
+// During market hours of a trading day this is the previous trading day.
+func (c *Calendar) LastSession(t time.Time) time.Time {
+	var (
+		day      = c.Day(t)
+		closesAt = day.Add(constants.MarketCloseHour*time.Hour + constants.MarketCloseMinute*time.Minute)
+	)
+
+	if c.IsTradingDay(day) && !t.Before(closesAt) {
+		return day
+	}
+
+	return c.PrevTradingDay(day)
+}
+
+// AddTradingDays moves n trading days forward (or backward if n is negative)
+// from the day of t. Zero returns the day of t unchanged.
+func (c *Calendar) AddTradingDays(t time.Time, n int) time.Time {
+	day := c.Day(t)
+	for ; n > 0; n-- {
+		day = c.NextTradingDay(day)
+	}
+	for ; n < 0; n++ {
+		day = c.PrevTradingDay(day)
+	}
+	return day
+}
+
+// TradingDaysBetween returns all trading days in the inclusive range [from, to]
+func (c *Calendar) TradingDaysBetween(from time.Time, to time.Time) []time.Time {
+	var (
+		days = make([]time.Time, 0)
+		last = c.Day(to)
+	)
+
+	for day := c.Day(from); !day.After(last); day = day.AddDate(0, 0, 1) {
+		if c.IsTradingDay(day) {
+			days = append(days, day)
+		}
+	}
+
+	return days
+}
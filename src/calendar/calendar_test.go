@@ -0,0 +1,184 @@
+package calendar
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+// ist is the location of the test calendar
+var ist = time.FixedZone("IST", 5*3600+1800)
+
+const holidays = `date,type,description
+2024-03-25,holiday,Holi
+2024-03-29,holiday,Good Friday
+2024-03-02,special,Special session
+`
+
+func testCalendar(t *testing.T) *Calendar {
+	t.Helper()
+
+	c, err := Parse([]byte(holidays), ist)
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	return c
+}
+
+// at returns the time on the given day (2024-03-dd) in IST
+func at(dd int, hour int, minute int) time.Time {
+	return time.Date(2024, time.March, dd, hour, minute, 0, 0, ist)
+}
+
+// day returns the start of the given day (2024-03-dd) in IST
+func day(dd int) time.Time {
+	return at(dd, 0, 0)
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+	}{
+		{name: "invalid date", data: "date,type,description\n2024-02-30,holiday,Nope\n"},
+		{name: "invalid type", data: "date,type,description\n2024-03-25,closed,Holi\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := Parse([]byte(tt.data), ist); err == nil {
+				t.Error("Parse() error = nil, want an error")
+			}
+		})
+	}
+}
+
+func TestIsTradingDay(t *testing.T) {
+	c := testCalendar(t)
+
+	tests := []struct {
+		name string
+		t    time.Time
+		want bool
+	}{
+		{name: "weekday", t: day(22), want: true},
+		{name: "saturday", t: day(23), want: false},
+		{name: "sunday", t: day(24), want: false},
+		{name: "holiday", t: day(25), want: false},
+		{name: "special session on a saturday", t: day(2), want: true},
+		{name: "evaluated in the calendar location", t: time.Date(2024, time.March, 22, 19, 0, 0, 0, time.UTC), want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := c.IsTradingDay(tt.t); got != tt.want {
+				t.Errorf("IsTradingDay(%v) = %v, want %v", tt.t, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLastSession(t *testing.T) {
+	c := testCalendar(t)
+
+	tests := []struct {
+		name string
+		t    time.Time
+		want time.Time
+	}{
+		{name: "before the close", t: at(22, 15, 29), want: day(21)},
+		{name: "at the close", t: at(22, 15, 30), want: day(22)},
+		{name: "evening", t: at(22, 18, 30), want: day(22)},
+		{name: "weekend", t: at(24, 12, 0), want: day(22)},
+		{name: "holiday", t: at(25, 18, 0), want: day(22)},
+		{name: "morning after a holiday", t: at(26, 9, 15), want: day(22)},
+		{name: "special session", t: at(2, 16, 0), want: day(2)},
+		{name: "utc time after the close", t: time.Date(2024, time.March, 26, 10, 0, 0, 0, time.UTC), want: day(26)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := c.LastSession(tt.t); !got.Equal(tt.want) {
+				t.Errorf("LastSession(%v) = %v, want %v", tt.t, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPrevAndNextTradingDay(t *testing.T) {
+	c := testCalendar(t)
+
+	tests := []struct {
+		name     string
+		t        time.Time
+		wantPrev time.Time
+		wantNext time.Time
+	}{
+		{name: "midweek", t: at(20, 12, 0), wantPrev: day(19), wantNext: day(21)},
+		{name: "friday before a holiday", t: day(22), wantPrev: day(21), wantNext: day(26)},
+		{name: "holiday", t: day(25), wantPrev: day(22), wantNext: day(26)},
+		{name: "around a special session", t: day(1), wantPrev: day(29).AddDate(0, -1, 0), wantNext: day(2)},
+		{name: "long weekend", t: day(28), wantPrev: day(27), wantNext: time.Date(2024, time.April, 1, 0, 0, 0, 0, ist)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := c.PrevTradingDay(tt.t); !got.Equal(tt.wantPrev) {
+				t.Errorf("PrevTradingDay(%v) = %v, want %v", tt.t, got, tt.wantPrev)
+			}
+			if got := c.NextTradingDay(tt.t); !got.Equal(tt.wantNext) {
+				t.Errorf("NextTradingDay(%v) = %v, want %v", tt.t, got, tt.wantNext)
+			}
+		})
+	}
+}
+
+func TestAddTradingDays(t *testing.T) {
+	c := testCalendar(t)
+
+	tests := []struct {
+		name string
+		t    time.Time
+		n    int
+		want time.Time
+	}{
+		{name: "zero", t: at(23, 10, 0), n: 0, want: day(23)},
+		{name: "forward over a weekend and holiday", t: day(22), n: 1, want: day(26)},
+		{name: "forward several days", t: day(21), n: 4, want: day(28)},
+		{name: "backward over a holiday", t: day(26), n: -1, want: day(22)},
+		{name: "backward from a weekend", t: day(24), n: -2, want: day(21)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := c.AddTradingDays(tt.t, tt.n); !got.Equal(tt.want) {
+				t.Errorf("AddTradingDays(%v, %d) = %v, want %v", tt.t, tt.n, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTradingDaysBetween(t *testing.T) {
+	c := testCalendar(t)
+
+	tests := []struct {
+		name string
+		from time.Time
+		to   time.Time
+		want []time.Time
+	}{
+		{name: "week with a holiday", from: day(22), to: day(29), want: []time.Time{day(22), day(26), day(27), day(28)}},
+		{name: "special session", from: day(1), to: day(4), want: []time.Time{day(1), day(2), day(4)}},
+		{name: "inclusive bounds within the day", from: at(21, 18, 0), to: at(21, 9, 0), want: []time.Time{day(21)}},
+		{name: "weekend only", from: day(23), to: day(24), want: []time.Time{}},
+		{name: "reversed range", from: day(22), to: day(21), want: []time.Time{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := c.TradingDaysBetween(tt.from, tt.to); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("TradingDaysBetween(%v, %v) = %v, want %v", tt.from, tt.to, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,54 @@
+package cli
+
+import (
+	"context"
+	"eeye/src/db"
+	"eeye/src/quality"
+	"eeye/src/utils"
+	"flag"
+	"log/slog"
+	"os"
+)
+
+// qualityCommand prints the data quality issues detected during ingestion
+var qualityCommand = &command{
+	name:    "quality",
+	summary: "Print the data quality issues of ingested candles",
+	setup: func(fs *flag.FlagSet) runFunc {
+		days := fs.Int("days", 7, "Number of days covered by the report")
+
+		return func(ctx context.Context, _ []string) error {
+			since := utils.Now().AddDate(0, 0, -*days)
+			return quality.Report(ctx, os.Stdout, since)
+		}
+	},
+}
+
+// migrateCommand applies, reverts or lists the schema migrations
+var migrateCommand = &command{
+	name:    "migrate",
+	args:    "up|down|status",
+	summary: "Apply pending migrations, revert the latest ones or list them",
+	minArgs: 1,
+	maxArgs: 1,
+	setup: func(fs *flag.FlagSet) runFunc {
+		steps := fs.Int("steps", 1, "Number of migrations reverted by down")
+
+		return func(ctx context.Context, args []string) error {
+			switch args[0] {
+			case "up":
+				count, err := db.MigrateUp(ctx)
+				slog.Info("migrations applied", "count", count)
+				return err
+			case "down":
+				count, err := db.MigrateDown(ctx, *steps)
+				slog.Info("migrations reverted", "count", count)
+				return err
+			case "status":
+				return db.MigrationStatus(ctx, os.Stdout)
+			default:
+				return errUsage("unknown migrate command: %v", args[0])
+			}
+		}
+	},
+}
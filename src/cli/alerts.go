@@ -0,0 +1,178 @@
+package cli
+
+import (
+	"context"
+	"eeye/src/alerts"
+	"eeye/src/calendar"
+	"eeye/src/db"
+	"eeye/src/models"
+	"eeye/src/utils"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// alertsCommand creates, lists and deletes price alerts
+var alertsCommand = &command{
+	name:    "alerts",
+	args:    "list | add SYMBOL CONDITION [LEVEL|PERIOD] above|below | delete ID",
+	summary: "Create, list and delete price alerts, evaluated after every ingestion",
+	minArgs: 1,
+	maxArgs: 5,
+	setup: func(fs *flag.FlagSet) runFunc {
+		var (
+			all     = fs.Bool("all", false, "Also list the triggered and expired alerts")
+			expires = fs.String("expires", "", "Last trading day the added alert is evaluated on, as YYYY-MM-DD (default never)")
+		)
+
+		return func(ctx context.Context, args []string) error {
+			switch args[0] {
+			case "list":
+				if len(args) != 1 {
+					return errUsage("alerts list takes no arguments")
+				}
+
+				stored, err := db.FetchAlerts(ctx)
+				if err != nil {
+					return err
+				}
+				return printAlerts(os.Stdout, stored, calendar.NSE.Day(utils.Now()), *all)
+
+			case "add":
+				alert, err := parseAlert(args[1:], *expires)
+				if err != nil {
+					return errUsage("%v", err)
+				}
+				if err := alerts.Create(ctx, alert); err != nil {
+					return err
+				}
+
+				_, err = fmt.Printf("created alert #%d: %v %v %v\n", alert.ID, alert.Symbol, alert.Direction, alert.Spec())
+				return err
+
+			case "delete":
+				if len(args) != 2 {
+					return errUsage("alerts delete takes the ID of the alert")
+				}
+				id, err := strconv.ParseInt(args[1], 10, 64)
+				if err != nil {
+					return errUsage("invalid alert ID %q", args[1])
+				}
+
+				deleted, err := db.DeleteAlert(ctx, id)
+				if err != nil {
+					return err
+				}
+				if !deleted {
+					return fmt.Errorf("alert #%d not found", id)
+				}
+
+				_, err = fmt.Printf("deleted alert #%d\n", id)
+				return err
+
+			default:
+				return errUsage("unknown alerts command: %v", args[0])
+			}
+		}
+	},
+}
+
+// parseAlert returns the alert described by the arguments of alerts add: the symbol, the
+// condition, the price level or EMA period (for the price and ema conditions) and the direction
+func parseAlert(args []string, expires string) (*models.Alert, error) {
+	if len(args) < 3 || len(args) > 4 {
+		return nil, errors.New("alerts add takes SYMBOL CONDITION [LEVEL|PERIOD] above|below")
+	}
+
+	alert := &models.Alert{
+		Symbol:    args[0],
+		Condition: strings.ToLower(args[1]),
+		Direction: args[len(args)-1],
+	}
+
+	if len(args) == 4 {
+		switch alert.Condition {
+		case models.AlertPrice:
+			level, err := strconv.ParseFloat(args[2], 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid price level %q", args[2])
+			}
+			alert.Level = level
+		case models.AlertEMA:
+			period, err := strconv.Atoi(args[2])
+			if err != nil {
+				return nil, fmt.Errorf("invalid EMA period %q", args[2])
+			}
+			alert.Period = period
+		default:
+			return nil, fmt.Errorf("the %v condition takes no level", alert.Condition)
+		}
+	} else if alert.Condition == models.AlertPrice || alert.Condition == models.AlertEMA {
+		return nil, fmt.Errorf("the %v condition needs a level", alert.Condition)
+	}
+
+	if expires != "" {
+		day, err := time.Parse("2006-01-02", expires)
+		if err != nil {
+			return nil, fmt.Errorf("invalid expiry %q, expected YYYY-MM-DD", expires)
+		}
+		alert.ExpiresOn = &day
+	}
+
+	return alert, nil
+}
+
+// printAlerts prints the active alerts, or all alerts with their status if all is set
+func printAlerts(w io.Writer, stored []models.Alert, today time.Time, all bool) error {
+	listed := make([]models.Alert, 0, len(stored))
+	for i := range stored {
+		if all || stored[i].Status(today) == models.AlertActive {
+			listed = append(listed, stored[i])
+		}
+	}
+
+	if len(listed) == 0 {
+		_, err := fmt.Fprintln(w, "no alerts")
+		return err
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintln(tw, "ID\tSYMBOL\tCONDITION\tDIRECTION\tEXPIRES\tSTATUS\tTRIGGERED")
+	for i := range listed {
+		alert := &listed[i]
+
+		expiresOn := "-"
+		if alert.ExpiresOn != nil {
+			expiresOn = alert.ExpiresOn.Format("2006-01-02")
+		}
+		triggered := "-"
+		if alert.TriggeredOn != nil {
+			triggered = fmt.Sprintf(
+				"%v, close %v at %v",
+				alert.TriggeredOn.Format("2006-01-02"),
+				alert.TriggeredClose,
+				alert.TriggeredLevel,
+			)
+		}
+
+		_, _ = fmt.Fprintf(
+			tw,
+			"%d\t%v\t%v\t%v\t%v\t%v\t%v\n",
+			alert.ID,
+			alert.Symbol,
+			alert.Spec(),
+			alert.Direction,
+			expiresOn,
+			alert.Status(today),
+			triggered,
+		)
+	}
+
+	return tw.Flush()
+}
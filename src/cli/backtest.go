@@ -0,0 +1,125 @@
+package cli
+
+import (
+	"context"
+	"eeye/src/calendar"
+	"eeye/src/models"
+	"eeye/src/strategy"
+	"eeye/src/utils"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"text/tabwriter"
+	"time"
+)
+
+// backtestCommand replays strategies over past sessions and reports the returns of their signals
+var backtestCommand = &command{
+	name:    "backtest",
+	summary: "Replay strategies over past sessions and report the returns of their signals",
+	setup: func(fs *flag.FlagSet) runFunc {
+		var (
+			names     = fs.String("strategies", "", "Comma separated strategy names (default all)")
+			selection = addStockFlags(fs)
+			from      = fs.String("from", "", "First session to look for signals, YYYY-MM-DD (default one year ago)")
+			to        = fs.String("to", "", "Last session to look for signals, YYYY-MM-DD (default today)")
+			hold      = fs.Int("hold", 5, "Number of sessions a selected stock is held")
+			signals   = fs.Bool("signals", false, "Also print every signal")
+		)
+
+		return func(ctx context.Context, _ []string) error {
+			now := utils.Now()
+			opts := strategy.BacktestOptions{From: now.AddDate(-1, 0, 0), To: now, Hold: *hold}
+
+			if *hold < 1 {
+				return errUsage("--hold must be at least 1")
+			}
+			if err := parseDay(*from, &opts.From); err != nil {
+				return errUsage("invalid --from: %v", err)
+			}
+			if err := parseDay(*to, &opts.To); err != nil {
+				return errUsage("invalid --to: %v", err)
+			}
+			if opts.To.Before(opts.From) {
+				return errUsage("--to is before --from")
+			}
+
+			// unknown strategy names are reported before any candle is read
+			if _, err := strategy.Select(splitList(*names)); err != nil {
+				return errUsage("%v", err)
+			}
+
+			stocks, err := selection.stocks(ctx)
+			if err != nil {
+				return err
+			}
+
+			results, err := strategy.Backtest(ctx, stocks, splitList(*names), opts)
+			if err != nil {
+				return err
+			}
+			return printBacktestResults(os.Stdout, results, opts, *signals)
+		}
+	},
+}
+
+// parseDay parses a YYYY-MM-DD flag value in the exchange time zone into day,
+// leaving day unchanged if the value is empty
+func parseDay(value string, day *time.Time) error {
+	if value == "" {
+		return nil
+	}
+
+	t, err := time.ParseInLocation("2006-01-02", value, calendar.NSE.Location())
+	if err != nil {
+		return err
+	}
+	*day = t
+	return nil
+}
+
+// printBacktestResults prints the win rate and average return of every strategy,
+// followed by the signals if requested
+func printBacktestResults(w io.Writer, results []models.BacktestResult, opts strategy.BacktestOptions, signals bool) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+
+	_, _ = fmt.Fprintf(
+		tw,
+		"Backtest from %v to %v, holding %d sessions\n\n",
+		opts.From.Format("2006-01-02"),
+		opts.To.Format("2006-01-02"),
+		opts.Hold,
+	)
+	_, _ = fmt.Fprintln(tw, "STRATEGY\tSIGNALS\tWIN RATE\tAVG RETURN")
+	for i := range results {
+		_, _ = fmt.Fprintf(
+			tw,
+			"%v\t%d\t%.2f%%\t%.2f%%\n",
+			results[i].Strategy,
+			len(results[i].Signals),
+			results[i].WinRate(),
+			results[i].AverageReturn(),
+		)
+	}
+
+	if signals {
+		_, _ = fmt.Fprintln(tw, "\nDATE\tSTRATEGY\tSYMBOL\tENTRY\tEXIT\tRETURN")
+		for i := range results {
+			for _, signal := range results[i].Signals {
+				_, _ = fmt.Fprintf(
+					tw,
+					"%v\t%v\t%v\t%v\t%v\t%.2f%%\n",
+					signal.Date.Format("2006-01-02"),
+					signal.Strategy,
+					signal.Symbol,
+					utils.Round2(signal.Entry),
+					utils.Round2(signal.Exit),
+					signal.Return(),
+				)
+			}
+		}
+	}
+
+	return tw.Flush()
+}
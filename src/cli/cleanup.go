@@ -0,0 +1,67 @@
+package cli
+
+import (
+	"context"
+	"eeye/src/dataflow"
+	"eeye/src/models"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"text/tabwriter"
+)
+
+// cleanupCommand archives the candles of stocks which are no longer listed
+var cleanupCommand = &command{
+	name:    "cleanup",
+	summary: "Archive and delete the candles of de-listed stocks",
+	setup: func(fs *flag.FlagSet) runFunc {
+		dryRun := fs.Bool("dry-run", false, "Only list the de-listed stocks, without archiving them")
+
+		return func(ctx context.Context, _ []string) error {
+			delisted, err := dataflow.FindDelistedStocks(ctx)
+			if err != nil {
+				return err
+			}
+			if err := printDelisted(os.Stdout, delisted); err != nil {
+				return err
+			}
+
+			if *dryRun || len(delisted) == 0 {
+				return nil
+			}
+
+			rows, err := dataflow.ArchiveDelistedStocks(ctx, delisted)
+			if err != nil {
+				return err
+			}
+
+			_, err = fmt.Printf("\narchived %d candles of %d stocks\n", rows, len(delisted))
+			return err
+		}
+	},
+}
+
+// printDelisted prints the de-listed stocks with their stored candles
+func printDelisted(w io.Writer, delisted []models.DelistedStock) error {
+	if len(delisted) == 0 {
+		_, err := fmt.Fprintln(w, "no de-listed stocks")
+		return err
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintf(tw, "De-listed stocks: %d\n\n", len(delisted))
+	_, _ = fmt.Fprintln(tw, "SYMBOL\tCANDLES\tFIRST\tLAST")
+	for i := range delisted {
+		_, _ = fmt.Fprintf(
+			tw,
+			"%v\t%d\t%v\t%v\n",
+			delisted[i].Symbol,
+			delisted[i].Range.Count,
+			delisted[i].Range.First.Format("2006-01-02"),
+			delisted[i].Range.Last.Format("2006-01-02"),
+		)
+	}
+
+	return tw.Flush()
+}
@@ -0,0 +1,299 @@
+// Package cli implements the eeye command line: a set of subcommands (run, ingest,
+// screen, backtest, serve, cleanup, inspect, alerts, quality, migrate and config) sharing
+// the environment setup and a few common flags.
+package cli
+
+import (
+	"context"
+	"eeye/src/api"
+	"eeye/src/calendar"
+	"eeye/src/config"
+	"eeye/src/db"
+	"eeye/src/handlers"
+	"eeye/src/metrics"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"log/slog"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+)
+
+// Exit codes of Main
+const (
+	exitOK        = 0
+	exitFailure   = 1
+	exitUsage     = 2
+	exitInterrupt = 130
+)
+
+// defaultCommand runs when no subcommand is given, so that `eeye` keeps ingesting and screening
+const defaultCommand = "run"
+
+// runFunc executes a command with its positional arguments once the environment is set up
+type runFunc func(ctx context.Context, args []string) error
+
+// command is a subcommand of the CLI
+type command struct {
+	// name selects the command, e.g. eeye screen
+	name string
+
+	// args documents the positional arguments in the usage line
+	args string
+
+	// summary is the one line description listed by eeye help
+	summary string
+
+	// minArgs and maxArgs bound the number of positional arguments
+	minArgs int
+	maxArgs int
+
+	// configOnly commands only need the configuration: they run without connecting
+	// to the database and APIs, and even if the configuration is invalid
+	configOnly bool
+
+	// setup registers the command flags and returns the function running the command
+	setup func(fs *flag.FlagSet) runFunc
+}
+
+// commands lists the subcommands in the order they are documented
+var commands = []*command{
+	runCommand,
+	ingestCommand,
+	screenCommand,
+	backtestCommand,
+	serveCommand,
+	cleanupCommand,
+	inspectCommand,
+	alertsCommand,
+	qualityCommand,
+	migrateCommand,
+	configCommand,
+}
+
+// usageError is returned by commands for invalid arguments, so that Main exits
+// with the usage exit code and prints the command usage
+type usageError struct {
+	msg string
+}
+
+func (e *usageError) Error() string {
+	return e.msg
+}
+
+// errUsage returns a usageError with a formatted message
+func errUsage(format string, args ...any) error {
+	return &usageError{msg: fmt.Sprintf(format, args...)}
+}
+
+// commonFlags are accepted by every command and override the environment configuration
+type commonFlags struct {
+	fs               *flag.FlagSet
+	configFile       *string
+	profile          *string
+	verbose          *bool
+	logLevel         *string
+	metricsAddr      *string
+	strategyWorkers  *int
+	ingestionWorkers *int
+}
+
+// addCommonFlags registers the common flags on fs
+func addCommonFlags(fs *flag.FlagSet) *commonFlags {
+	return &commonFlags{
+		fs:               fs,
+		configFile:       fs.String("config", "", "YAML config file (overrides EEYE_CONFIG, default eeye.yaml if it exists)"),
+		profile:          fs.String("profile", "", "Configuration profile, e.g. dev, prod or offline (overrides EEYE_PROFILE)"),
+		verbose:          fs.Bool("verbose", false, "Also print logs to stdout"),
+		logLevel:         fs.String("log-level", "", "Minimum log level: debug, info, warn or error (overrides EEYE_LOG_LEVEL)"),
+		metricsAddr:      fs.String("metrics-addr", "", "Serve Prometheus metrics on this address (overrides EEYE_METRICS_ADDR)"),
+		strategyWorkers:  fs.Int("strategy-workers", 0, "Number of strategy workers (overrides EEYE_STRATEGY_WORKERS)"),
+		ingestionWorkers: fs.Int("ingestion-workers", 0, "Number of ingestion workers (overrides EEYE_INGESTION_WORKERS)"),
+	}
+}
+
+// options returns the configuration sources selected by the flags; the flags which were
+// set override the settings of every other source, even when set to empty
+// (e.g. --metrics-addr "" disables the metrics server of the config file)
+func (c *commonFlags) options() config.Options {
+	overrides := make(map[string]string)
+	c.fs.Visit(func(f *flag.Flag) {
+		switch f.Name {
+		case "log-level":
+			overrides["EEYE_LOG_LEVEL"] = *c.logLevel
+		case "metrics-addr":
+			overrides["EEYE_METRICS_ADDR"] = *c.metricsAddr
+		case "strategy-workers":
+			overrides["EEYE_STRATEGY_WORKERS"] = strconv.Itoa(*c.strategyWorkers)
+		case "ingestion-workers":
+			overrides["EEYE_INGESTION_WORKERS"] = strconv.Itoa(*c.ingestionWorkers)
+		}
+	})
+
+	return config.Options{File: *c.configFile, Profile: *c.profile, Overrides: overrides}
+}
+
+// lookupCommand returns the command with the given name, or nil
+func lookupCommand(name string) *command {
+	for _, cmd := range commands {
+		if cmd.name == name {
+			return cmd
+		}
+	}
+	return nil
+}
+
+// parseArgs parses the flags of fs which may be interleaved with the positional
+// arguments (e.g. eeye inspect TCS --days 60) and returns the positional arguments.
+// Flag parsing stops at "--": all arguments after it are positional, even if they
+// start with "-".
+func parseArgs(fs *flag.FlagSet, args []string) ([]string, error) {
+	positional := make([]string, 0)
+	for {
+		if err := fs.Parse(args); err != nil {
+			return nil, err
+		}
+
+		rest := fs.Args()
+		// fs.Parse consumes the "--" it stops at
+		if parsed := len(args) - len(rest); parsed > 0 && args[parsed-1] == "--" {
+			return append(positional, rest...), nil
+		}
+
+		if len(rest) == 0 {
+			return positional, nil
+		}
+		positional = append(positional, rest[0])
+		args = rest[1:]
+	}
+}
+
+// printUsage lists the commands on w
+func printUsage(w io.Writer) {
+	_, _ = fmt.Fprintln(w, "Usage: eeye <command> [flags] [args]")
+	_, _ = fmt.Fprintln(w, "\nCommands:")
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	for _, cmd := range commands {
+		_, _ = fmt.Fprintf(tw, "  %v\t%v\n", cmd.name, cmd.summary)
+	}
+	_ = tw.Flush()
+
+	_, _ = fmt.Fprintf(w, "\nWithout a command, eeye runs %q. Run eeye <command> -h for the flags of a command.\n", defaultCommand)
+}
+
+// commandUsage prints the usage line and flags of cmd
+func commandUsage(fs *flag.FlagSet, cmd *command) func() {
+	return func() {
+		w := fs.Output()
+		_, _ = fmt.Fprintf(w, "Usage: eeye %v [flags] %v\n\n%v\n\nFlags:\n", cmd.name, cmd.args, cmd.summary)
+		fs.PrintDefaults()
+	}
+}
+
+// setupEnvironment connects the logger, calendar, API clients and database of the loaded
+// configuration. It returns the root context, cancelled on SIGINT/SIGTERM, and a function
+// releasing everything.
+func setupEnvironment(verbose bool) (context.Context, func()) {
+	applog := handlers.GetAppLog(verbose)
+	calendar.Load()
+	if err := api.StartFixtureSession(config.HTTP.Mode, config.HTTP.FixturesDir); err != nil {
+		log.Fatal(err)
+	}
+	api.InitGrowwTradingClient()
+	api.InitNseClient()
+	db.Connect()
+
+	// Cancelled on SIGINT/SIGTERM: aborts in-flight requests and queries and drains the workers
+	ctx, stop := handlers.GetInterruptContext()
+
+	if config.Metrics.Addr != "" {
+		go metrics.Serve(ctx, config.Metrics.Addr)
+	}
+
+	return ctx, func() {
+		stop()
+		db.Disconnect()
+		applog.Close()
+	}
+}
+
+// Main runs the command selected by args (without the program name) and returns
+// the exit code of the process
+func Main(args []string) int {
+	name := defaultCommand
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		name, args = args[0], args[1:]
+	}
+
+	if name == "help" {
+		printUsage(os.Stdout)
+		return exitOK
+	}
+
+	cmd := lookupCommand(name)
+	if cmd == nil {
+		_, _ = fmt.Fprintf(os.Stderr, "unknown command %q\n\n", name)
+		printUsage(os.Stderr)
+		return exitUsage
+	}
+
+	fs := flag.NewFlagSet("eeye "+cmd.name, flag.ContinueOnError)
+	fs.Usage = commandUsage(fs, cmd)
+	common := addCommonFlags(fs)
+	run := cmd.setup(fs)
+
+	positional, err := parseArgs(fs, args)
+	switch {
+	case errors.Is(err, flag.ErrHelp):
+		return exitOK
+	case err != nil:
+		// the flag package has already printed the error and usage
+		return exitUsage
+	case len(positional) < cmd.minArgs || len(positional) > cmd.maxArgs:
+		_, _ = fmt.Fprintf(os.Stderr, "invalid arguments %q\n\n", positional)
+		fs.Usage()
+		return exitUsage
+	}
+
+	loadErr := config.Load(common.options())
+	if loadErr != nil && !cmd.configOnly {
+		_, _ = fmt.Fprintln(os.Stderr, loadErr)
+		return exitFailure
+	}
+
+	ctx := context.Background()
+	if !cmd.configOnly {
+		var teardown func()
+		ctx, teardown = setupEnvironment(*common.verbose)
+		defer teardown()
+	}
+
+	err = run(ctx, positional)
+	if err == nil && loadErr != nil {
+		// config-only commands also run with an invalid configuration, to help finding the culprit
+		_, _ = fmt.Fprintln(os.Stderr, loadErr)
+		return exitFailure
+	}
+
+	var usageErr *usageError
+	switch {
+	case err == nil:
+		return exitOK
+	case ctx.Err() != nil:
+		slog.Info("shutting down gracefully, signal caught", "command", cmd.name)
+		return exitInterrupt
+	case errors.As(err, &usageErr):
+		_, _ = fmt.Fprintf(os.Stderr, "%v\n\n", err)
+		fs.Usage()
+		return exitUsage
+	default:
+		slog.Error("command failed", "command", cmd.name, "err", err)
+		_, _ = fmt.Fprintf(os.Stderr, "eeye %v: %v\n", cmd.name, err)
+		return exitFailure
+	}
+}
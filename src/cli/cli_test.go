@@ -0,0 +1,241 @@
+package cli
+
+import (
+	"bytes"
+	"eeye/src/models"
+	"eeye/src/strategy"
+	"flag"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseArgs(t *testing.T) {
+	tests := []struct {
+		name       string
+		args       []string
+		wantDays   int
+		wantStore  bool
+		wantParsed []string
+	}{
+		{name: "flags first", args: []string{"--days", "60", "TCS"}, wantDays: 60, wantParsed: []string{"TCS"}},
+		{name: "flags after arguments", args: []string{"TCS", "--days", "60"}, wantDays: 60, wantParsed: []string{"TCS"}},
+		{name: "interleaved", args: []string{"up", "--storage", "down", "--days=3"}, wantDays: 3, wantStore: true, wantParsed: []string{"up", "down"}},
+		{name: "no arguments", args: []string{}, wantDays: 20, wantParsed: []string{}},
+		{name: "arguments after --", args: []string{"add", "--days", "5", "--", "-1", "--storage"}, wantDays: 5, wantParsed: []string{"add", "-1", "--storage"}},
+		{name: "-- first", args: []string{"--", "--days", "60"}, wantDays: 20, wantParsed: []string{"--days", "60"}},
+		{name: "-- after an argument", args: []string{"TCS", "--", "-"}, wantDays: 20, wantParsed: []string{"TCS", "-"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fs := flag.NewFlagSet("test", flag.ContinueOnError)
+			days := fs.Int("days", 20, "")
+			storage := fs.Bool("storage", false, "")
+
+			got, err := parseArgs(fs, tt.args)
+			if err != nil {
+				t.Fatalf("parseArgs() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.wantParsed) || *days != tt.wantDays || *storage != tt.wantStore {
+				t.Errorf(
+					"parseArgs() = %v, days = %v, storage = %v, want %v, %v, %v",
+					got, *days, *storage, tt.wantParsed, tt.wantDays, tt.wantStore,
+				)
+			}
+		})
+	}
+}
+
+func TestParseArgsUnknownFlag(t *testing.T) {
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+
+	if _, err := parseArgs(fs, []string{"TCS", "--unknown"}); err == nil {
+		t.Error("parseArgs() error = nil, want an error for an unknown flag")
+	}
+}
+
+func TestStockFlagsSelected(t *testing.T) {
+	universe := filepath.Join(t.TempDir(), "universe.txt")
+	content := "# banks\nhdfcbank\n\nICICIBANK  # private\nTCS\n"
+	if err := os.WriteFile(universe, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		symbols  string
+		universe string
+		want     []string
+	}{
+		{name: "nothing selected", want: nil},
+		{name: "symbols", symbols: "tcs, INFY,,", want: []string{"TCS", "INFY"}},
+		{name: "universe", universe: universe, want: []string{"HDFCBANK", "ICICIBANK", "TCS"}},
+		{name: "symbols and universe are combined", symbols: "TCS,INFY", universe: universe, want: []string{"TCS", "INFY", "HDFCBANK", "ICICIBANK"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &stockFlags{symbols: &tt.symbols, universe: &tt.universe}
+
+			got, err := s.selected()
+			if err != nil {
+				t.Fatalf("selected() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("selected() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFilterStocks(t *testing.T) {
+	stored := []models.Stock{{Symbol: "INFY"}, {Symbol: "TCS"}, {Symbol: "WIPRO"}}
+
+	got, err := filterStocks(stored, []string{"WIPRO", "INFY"})
+	if err != nil {
+		t.Fatalf("filterStocks() error = %v", err)
+	}
+	if want := []models.Stock{{Symbol: "WIPRO"}, {Symbol: "INFY"}}; !reflect.DeepEqual(got, want) {
+		t.Errorf("filterStocks() = %v, want %v", got, want)
+	}
+
+	if _, err := filterStocks(stored, []string{"TCS", "TYPO"}); err == nil {
+		t.Error("filterStocks() error = nil, want an error for a symbol without candles")
+	}
+}
+
+func TestPrintScreenResults(t *testing.T) {
+	strategies, err := strategy.Select([]string{"Bullish Swing"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	result := &models.StrategyResult{
+		Strategy: strategies[0],
+		Stocks:   []*models.Stock{{Symbol: "TCS"}, {Symbol: "INFY"}},
+	}
+	compared := &models.StrategyResult{
+		Strategy: strategies[0],
+		Stocks:   result.Stocks,
+		Diff:     &models.SignalDiff{New: []string{"INFY"}, Continuing: []string{"TCS"}, Dropped: []string{}},
+	}
+
+	tests := []struct {
+		name    string
+		result  *models.StrategyResult
+		newOnly bool
+		want    []string
+	}{
+		{name: "plain", result: result, want: []string{"STRATEGY", "STOCKS", "SYMBOLS", "Bullish Swing", "INFY, TCS"}},
+		{name: "compared", result: compared, want: []string{"NEW", "CONTINUING", "DROPPED", "INFY", "TCS", "-"}},
+		{name: "new only", result: compared, newOnly: true, want: []string{"NEW", "SYMBOLS", "Bullish Swing", "INFY"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := printScreenResults(&buf, []*models.StrategyResult{tt.result}, tt.newOnly); err != nil {
+				t.Fatalf("printScreenResults() error = %v", err)
+			}
+
+			for _, want := range tt.want {
+				if !strings.Contains(buf.String(), want) {
+					t.Errorf("printScreenResults() = %q, want it to contain %q", buf.String(), want)
+				}
+			}
+			if tt.newOnly && strings.Contains(buf.String(), "TCS") {
+				t.Errorf("printScreenResults() = %q, want only the new signals", buf.String())
+			}
+		})
+	}
+}
+
+func TestParseAlert(t *testing.T) {
+	expires := time.Date(2024, 3, 31, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name    string
+		args    []string
+		expires string
+		want    *models.Alert
+		wantErr bool
+	}{
+		{
+			name: "price",
+			args: []string{"TCS", "price", "4200.5", "above"},
+			want: &models.Alert{Symbol: "TCS", Condition: models.AlertPrice, Level: 4200.5, Direction: models.CrossAbove},
+		},
+		{
+			name:    "ema with expiry",
+			args:    []string{"TCS", "EMA", "50", "below"},
+			expires: "2024-03-31",
+			want:    &models.Alert{Symbol: "TCS", Condition: models.AlertEMA, Period: 50, Direction: models.CrossBelow, ExpiresOn: &expires},
+		},
+		{
+			name: "support",
+			args: []string{"TCS", "support", "below"},
+			want: &models.Alert{Symbol: "TCS", Condition: models.AlertSupport, Direction: models.CrossBelow},
+		},
+		{name: "missing level", args: []string{"TCS", "price", "above"}, wantErr: true},
+		{name: "level of a support", args: []string{"TCS", "support", "95", "below"}, wantErr: true},
+		{name: "invalid period", args: []string{"TCS", "ema", "fifty", "below"}, wantErr: true},
+		{name: "invalid expiry", args: []string{"TCS", "support", "below"}, expires: "31-03-2024", wantErr: true},
+		{name: "missing direction", args: []string{"TCS", "support"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseAlert(tt.args, tt.expires)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseAlert() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseAlert() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPrintAlerts(t *testing.T) {
+	var (
+		today     = time.Date(2024, 3, 15, 0, 0, 0, 0, time.UTC)
+		yesterday = today.AddDate(0, 0, -1)
+		stored    = []models.Alert{
+			{ID: 1, Symbol: "TCS", Condition: models.AlertPrice, Level: 4200, Direction: models.CrossAbove},
+			{ID: 2, Symbol: "TCS", Condition: models.AlertEMA, Period: 50, Direction: models.CrossBelow, ExpiresOn: &yesterday},
+			{
+				ID:             3,
+				Symbol:         "INFY",
+				Condition:      models.AlertSupport,
+				Direction:      models.CrossBelow,
+				TriggeredOn:    &yesterday,
+				TriggeredLevel: 1450.2,
+				TriggeredClose: 1432,
+			},
+		}
+	)
+
+	var buf bytes.Buffer
+	if err := printAlerts(&buf, stored, today, false); err != nil {
+		t.Fatalf("printAlerts() error = %v", err)
+	}
+	if got := buf.String(); !strings.Contains(got, "price 4200") || strings.Contains(got, "EMA(50)") || strings.Contains(got, "INFY") {
+		t.Errorf("printAlerts() = %q, want the active alerts only", got)
+	}
+
+	buf.Reset()
+	if err := printAlerts(&buf, stored, today, true); err != nil {
+		t.Fatalf("printAlerts() error = %v", err)
+	}
+	for _, want := range []string{"EMA(50)", "expired", "triggered", "2024-03-14, close 1432 at 1450.2"} {
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("printAlerts() = %q, want it to contain %q", buf.String(), want)
+		}
+	}
+}
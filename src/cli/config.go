@@ -0,0 +1,52 @@
+package cli
+
+import (
+	"context"
+	"eeye/src/config"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"text/tabwriter"
+)
+
+// configCommand prints the effective configuration
+var configCommand = &command{
+	name:       "config",
+	args:       "print",
+	summary:    "Print the effective configuration with the source of every value, secrets redacted",
+	minArgs:    1,
+	maxArgs:    1,
+	configOnly: true,
+	setup: func(_ *flag.FlagSet) runFunc {
+		return func(_ context.Context, args []string) error {
+			if args[0] != "print" {
+				return errUsage("unknown config command: %v", args[0])
+			}
+			return printConfig(os.Stdout, config.Settings())
+		}
+	},
+}
+
+// printConfig prints the config file and profile in use, followed by every setting
+func printConfig(w io.Writer, settings []config.Setting) error {
+	var (
+		file    = config.Loaded.File
+		profile = config.Loaded.Profile
+	)
+	if file == "" {
+		file = "-"
+	}
+	if profile == "" {
+		profile = "-"
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintf(tw, "Config file: %v\nProfile: %v\n\n", file, profile)
+	_, _ = fmt.Fprintln(tw, "KEY\tENV\tVALUE\tSOURCE")
+	for _, s := range settings {
+		_, _ = fmt.Fprintf(tw, "%v\t%v\t%v\t%v\n", s.Key, s.Env, s.Redacted(), s.Source)
+	}
+
+	return tw.Flush()
+}
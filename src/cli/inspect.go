@@ -0,0 +1,157 @@
+package cli
+
+import (
+	"context"
+	"eeye/src/calendar"
+	"eeye/src/db"
+	"eeye/src/models"
+	"eeye/src/steps"
+	"eeye/src/utils"
+	"flag"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"strings"
+	"text/tabwriter"
+)
+
+// inspectCommand prints the latest candles and indicators of a stock, the storage
+// statistics of the stock_prices hypertable or the latest listing changes
+var inspectCommand = &command{
+	name:    "inspect",
+	args:    "[SYMBOL]",
+	summary: "Print the latest candles and indicators of a stock, storage statistics or listing changes",
+	maxArgs: 1,
+	setup: func(fs *flag.FlagSet) runFunc {
+		var (
+			days     = fs.Int("days", 20, "Number of latest sessions printed for SYMBOL or --listings")
+			storage  = fs.Bool("storage", false, "Print the size, compression ratio and policies of the stock_prices hypertable")
+			chunks   = fs.Bool("chunks", false, "Print the size and compression ratio of every stock_prices chunk")
+			listings = fs.Bool("listings", false, "Print the renames, series changes and suspensions of the latest sessions")
+		)
+
+		return func(ctx context.Context, args []string) error {
+			if *days < 1 {
+				return errUsage("--days must be at least 1")
+			}
+
+			switch {
+			case *storage && len(args) == 0 && !*chunks && !*listings:
+				return db.StorageReport(ctx, os.Stdout)
+			case *chunks && len(args) == 0 && !*storage && !*listings:
+				return db.ChunkReport(ctx, os.Stdout)
+			case *listings && len(args) == 0 && !*storage && !*chunks:
+				since := calendar.NSE.AddTradingDays(calendar.NSE.LastSession(utils.Now()), 1-*days)
+				events, err := db.FetchListingEvents(ctx, since)
+				if err != nil {
+					return err
+				}
+				return printListingEvents(os.Stdout, events)
+			case len(args) == 1 && !*storage && !*chunks && !*listings:
+				return inspectStock(ctx, os.Stdout, strings.ToUpper(args[0]), *days)
+			default:
+				return errUsage("expected either a SYMBOL, --storage, --chunks or --listings")
+			}
+		}
+	},
+}
+
+// inspectStock prints the latest candles of a stock with the indicators used by the strategies.
+// Indicators are computed over the whole stored history, so that the printed values match
+// the ones seen by the strategies.
+func inspectStock(ctx context.Context, w io.Writer, symbol string, days int) error {
+	stock := models.Stock{
+		Symbol:   symbol,
+		Exchange: "NSE",
+		Segment:  "CASH",
+		Name:     symbol,
+	}
+	candles, err := db.FetchAllCandles(ctx, &stock)
+	if err != nil {
+		return fmt.Errorf("failed to fetch candles: %w", err)
+	}
+	if len(candles) == 0 {
+		return fmt.Errorf("no candles stored for %v", symbol)
+	}
+
+	var (
+		total         = len(candles)
+		missing       = math.NaN()
+		rsi           = utils.PadLeft(steps.ComputeRsi(candles, 14), total, missing)
+		ema20         = utils.PadLeft(steps.ComputeEma(candles, 20), total, missing)
+		ema50         = utils.PadLeft(steps.ComputeEma(candles, 50), total, missing)
+		ema200        = utils.PadLeft(steps.ComputeEma(candles, 200), total, missing)
+		volumeMA      = utils.PadLeft(steps.ComputeVolumeMA(candles, 20), total, missing)
+		_, lbbs, ubbs = steps.ComputeBollingerBands(candles, 20, 2)
+		lbb           = utils.PadLeft(lbbs, total, missing)
+		ubb           = utils.PadLeft(ubbs, total, missing)
+		tw            = tabwriter.NewWriter(w, 0, 0, 2, ' ', tabwriter.AlignRight)
+	)
+
+	_, _ = fmt.Fprintf(w, "%v: %d stored candles, latest %d sessions\n\n", symbol, total, min(days, total))
+	_, _ = fmt.Fprintln(tw, "DATE\tOPEN\tHIGH\tLOW\tCLOSE\tVOLUME\tDELIV%\tRSI14\tEMA20\tEMA50\tEMA200\tBB LOWER\tBB UPPER\tVOL MA20\t")
+	for i := max(total-days, 0); i < total; i++ {
+		candle := &candles[i]
+
+		delivery := "-"
+		if candle.DeliveryPct > 0 {
+			delivery = formatIndicator(candle.DeliveryPct)
+		}
+
+		_, _ = fmt.Fprintf(
+			tw,
+			"%v\t%v\t%v\t%v\t%v\t%d\t%v\t%v\t%v\t%v\t%v\t%v\t%v\t%v\t\n",
+			candle.Timestamp.Format("2006-01-02"),
+			utils.Round2(candle.Open),
+			utils.Round2(candle.High),
+			utils.Round2(candle.Low),
+			utils.Round2(candle.Close),
+			candle.Volume,
+			delivery,
+			formatIndicator(rsi[i]),
+			formatIndicator(ema20[i]),
+			formatIndicator(ema50[i]),
+			formatIndicator(ema200[i]),
+			formatIndicator(lbb[i]),
+			formatIndicator(ubb[i]),
+			formatIndicator(volumeMA[i]),
+		)
+	}
+
+	return tw.Flush()
+}
+
+// printListingEvents prints the listing events, most recent first
+func printListingEvents(w io.Writer, events []models.ListingEvent) error {
+	if len(events) == 0 {
+		_, err := fmt.Fprintln(w, "no listing changes")
+		return err
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintln(tw, "DATE\tSYMBOL\tISIN\tEVENT\tOLD\tNEW")
+	for i := range events {
+		_, _ = fmt.Fprintf(
+			tw,
+			"%v\t%v\t%v\t%v\t%v\t%v\n",
+			events[i].Day.Format("2006-01-02"),
+			events[i].Symbol,
+			events[i].ISIN,
+			events[i].Event,
+			events[i].Old,
+			events[i].New,
+		)
+	}
+
+	return tw.Flush()
+}
+
+// formatIndicator rounds an indicator value, or returns "-" for sessions without
+// enough history to compute it
+func formatIndicator(v float64) string {
+	if math.IsNaN(v) {
+		return "-"
+	}
+	return fmt.Sprint(utils.Round2(v))
+}
@@ -0,0 +1,86 @@
+package cli
+
+import (
+	"context"
+	"eeye/src/alerts"
+	"eeye/src/dataflow"
+	"eeye/src/db"
+	"eeye/src/metrics"
+	"eeye/src/models"
+	"eeye/src/notify"
+	"eeye/src/strategy"
+	"errors"
+	"flag"
+	"log/slog"
+)
+
+// runCommand ingests the latest data and screens all stocks with all strategies
+var runCommand = &command{
+	name:    "run",
+	summary: "Ingest the latest data and screen all stocks with all strategies (default)",
+	setup: func(fs *flag.FlagSet) runFunc {
+		cleanup := fs.Bool("cleanup", false, "Archive de-listed stocks after a successful run")
+
+		return func(ctx context.Context, _ []string) error {
+			if err := <-strategy.Analyze(ctx); err != nil {
+				return err
+			}
+			if !*cleanup {
+				return nil
+			}
+
+			// A run which could not sync the database skips the cleanup instead of failing
+			delisted, err := dataflow.FindDelistedStocks(ctx)
+			if errors.Is(err, db.ErrNotSynced) {
+				slog.Warn("skipping cleanup of delisted stocks", "err", err)
+				return nil
+			}
+			if err != nil {
+				return err
+			}
+
+			_, err = dataflow.ArchiveDelistedStocks(ctx, delisted)
+			return err
+		}
+	},
+}
+
+// ingestCommand syncs the candles of all listed stocks without screening them, and
+// evaluates the price alerts against the ingested candles
+var ingestCommand = &command{
+	name:    "ingest",
+	summary: "Sync the candles of all listed stocks without screening",
+	setup: func(fs *flag.FlagSet) runFunc {
+		bhavcopyDir := fs.String("bhavcopy-dir", "", "Load the candles of all NSE bhavcopy zip files in the directory instead")
+
+		return func(ctx context.Context, _ []string) error {
+			// Invalid sinks are reported before the long ingestion
+			dispatcher, err := notify.New(strategy.Names())
+			if err != nil {
+				return err
+			}
+
+			run := metrics.StartRun()
+			defer func() {
+				slog.Info("run summary", "metrics", run)
+			}()
+
+			if *bhavcopyDir != "" {
+				err = dataflow.LoadBhavcopyDir(ctx, *bhavcopyDir)
+			} else {
+				var stocks []models.Stock
+				if stocks, err = dataflow.GetStocks(ctx); err == nil {
+					slog.Info("ingestion completed", "stocks", len(stocks))
+				}
+			}
+			if err != nil {
+				return err
+			}
+
+			if err := alerts.Evaluate(ctx, dispatcher); err != nil {
+				slog.Error("failed to evaluate alerts", "err", err)
+			}
+			return nil
+		}
+	},
+}
@@ -0,0 +1,143 @@
+package cli
+
+import (
+	"context"
+	"eeye/src/dataflow"
+	"eeye/src/metrics"
+	"eeye/src/models"
+	"eeye/src/notify"
+	"eeye/src/strategy"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+)
+
+// screenCommand runs selected strategies on the stored candles of selected stocks
+var screenCommand = &command{
+	name:    "screen",
+	summary: "Screen stored stocks with selected strategies, without ingesting",
+	setup: func(fs *flag.FlagSet) runFunc {
+		var (
+			names     = fs.String("strategies", "", "Comma separated strategy names (default all), e.g. \"Bullish Swing\"")
+			notifyRun = fs.Bool("notify", false, "Also send the signals to the configured notification sinks")
+			diff      = fs.Bool("diff", false, "Label the signals as new, continuing or dropped since the previous stored run")
+			newOnly   = fs.Bool("new-only", false, "Only print the signals which are new since the previous stored run (implies --diff)")
+			selection = addStockFlags(fs)
+		)
+
+		return func(ctx context.Context, _ []string) error {
+			strategies, err := strategy.Select(splitList(*names))
+			if err != nil {
+				return errUsage("%v", err)
+			}
+
+			var dispatcher *notify.Dispatcher
+			if *notifyRun {
+				if dispatcher, err = notify.New(strategy.Names()); err != nil {
+					return err
+				}
+				if !dispatcher.Enabled() {
+					return errUsage("--notify needs a notification sink, e.g. EEYE_WEBHOOK_URL")
+				}
+			}
+
+			stocks, err := selection.stocks(ctx)
+			if err != nil {
+				return err
+			}
+
+			run := metrics.StartRun()
+			defer func() {
+				slog.Info("run summary", "metrics", run)
+			}()
+
+			results, err := strategy.Screen(ctx, stocks, strategies)
+			if err != nil {
+				// The stocks screened before the cancellation are still worth printing
+				if printErr := printScreenResults(os.Stdout, results, false); printErr != nil {
+					slog.Warn("failed to print partial results", "err", printErr)
+				}
+				return err
+			}
+
+			if *diff || *newOnly {
+				day, err := dataflow.LatestSession(ctx)
+				if err != nil {
+					return err
+				}
+				if err := strategy.CompareSignals(ctx, day, results, stocks); err != nil {
+					return err
+				}
+			}
+			if err := printScreenResults(os.Stdout, results, *newOnly); err != nil {
+				return err
+			}
+
+			if dispatcher == nil {
+				return nil
+			}
+			return dispatcher.Send(ctx, results)
+		}
+	},
+}
+
+// listOrDash joins the symbols, or returns "-" if there are none
+func listOrDash(symbols []string) string {
+	if len(symbols) == 0 {
+		return "-"
+	}
+	return strings.Join(symbols, ", ")
+}
+
+// printScreenResults prints the stocks selected by every strategy. Compared results
+// are split into new, continuing and dropped signals, or only the new ones with newOnly.
+func printScreenResults(w io.Writer, results []*models.StrategyResult, newOnly bool) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	compared := len(results) > 0 && results[0].Diff != nil
+
+	switch {
+	case compared && newOnly:
+		_, _ = fmt.Fprintln(tw, "STRATEGY\tNEW\tSYMBOLS")
+	case compared:
+		_, _ = fmt.Fprintln(tw, "STRATEGY\tSTOCKS\tNEW\tCONTINUING\tDROPPED")
+	default:
+		_, _ = fmt.Fprintln(tw, "STRATEGY\tSTOCKS\tSYMBOLS")
+	}
+
+	for _, result := range results {
+		name := result.Strategy.Name()
+		if compared {
+			diff := result.Diff
+			if newOnly {
+				_, _ = fmt.Fprintf(tw, "%v\t%d\t%v\n", name, len(diff.New), listOrDash(diff.New))
+			} else {
+				_, _ = fmt.Fprintf(
+					tw,
+					"%v\t%d\t%v\t%v\t%v\n",
+					name,
+					len(result.Stocks),
+					listOrDash(diff.New),
+					listOrDash(diff.Continuing),
+					listOrDash(diff.Dropped),
+				)
+			}
+			continue
+		}
+
+		symbols := make([]string, 0, len(result.Stocks))
+		for i := range result.Stocks {
+			symbols = append(symbols, result.Stocks[i].Symbol)
+		}
+		// workers finish stocks in any order
+		sort.Strings(symbols)
+
+		_, _ = fmt.Fprintf(tw, "%v\t%d\t%v\n", name, len(symbols), listOrDash(symbols))
+	}
+
+	return tw.Flush()
+}
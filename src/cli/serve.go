@@ -0,0 +1,28 @@
+package cli
+
+import (
+	"context"
+	"eeye/src/mcp"
+	"eeye/src/scheduler"
+	"flag"
+)
+
+// serveCommand runs the MCP server, optionally together with the post-market scheduler
+var serveCommand = &command{
+	name:    "serve",
+	summary: "Serve MCP, optionally with scheduled post-market runs",
+	setup: func(fs *flag.FlagSet) runFunc {
+		schedule := fs.Bool("schedule", false, "Also run ingestion and screening on EEYE_SCHEDULE")
+
+		return func(ctx context.Context, _ []string) error {
+			if !*schedule {
+				mcp.Init(ctx)
+				return nil
+			}
+
+			// MCP server keeps serving queries while the scheduler runs in the foreground
+			go mcp.Init(ctx)
+			return scheduler.Run(ctx)
+		}
+	},
+}
@@ -0,0 +1,137 @@
+package cli
+
+import (
+	"bufio"
+	"context"
+	"eeye/src/db"
+	"eeye/src/models"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// stockFlags select the stocks a command works on: --symbols and --universe are
+// combined, and all stored stocks are selected when neither is set
+type stockFlags struct {
+	symbols  *string
+	universe *string
+}
+
+// addStockFlags registers the stock selection flags on fs
+func addStockFlags(fs *flag.FlagSet) *stockFlags {
+	return &stockFlags{
+		symbols:  fs.String("symbols", "", "Comma separated symbols, e.g. TCS,INFY (default all stored stocks)"),
+		universe: fs.String("universe", "", "File with one symbol per line; blank lines and # comments are ignored"),
+	}
+}
+
+// splitList splits a comma separated flag value, dropping empty items
+func splitList(value string) []string {
+	items := make([]string, 0)
+	for item := range strings.SplitSeq(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// readUniverse reads the symbols of a universe file
+func readUniverse(path string) ([]string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open universe: %w", err)
+	}
+	defer func() { _ = file.Close() }()
+
+	symbols := make([]string, 0)
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line, _, _ := strings.Cut(scanner.Text(), "#")
+		if line = strings.TrimSpace(line); line != "" {
+			symbols = append(symbols, line)
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read universe: %w", err)
+	}
+	return symbols, nil
+}
+
+// selected returns the upper-cased, deduplicated symbols given by the flags,
+// or nil if no stocks were selected
+func (s *stockFlags) selected() ([]string, error) {
+	symbols := splitList(*s.symbols)
+	if *s.universe != "" {
+		universe, err := readUniverse(*s.universe)
+		if err != nil {
+			return nil, err
+		}
+		symbols = append(symbols, universe...)
+	}
+
+	var (
+		seen = make(map[string]struct{}, len(symbols))
+		res  = make([]string, 0, len(symbols))
+	)
+	for _, symbol := range symbols {
+		symbol = strings.ToUpper(symbol)
+		if _, ok := seen[symbol]; ok {
+			continue
+		}
+		seen[symbol] = struct{}{}
+		res = append(res, symbol)
+	}
+
+	if len(res) == 0 {
+		return nil, nil
+	}
+	return res, nil
+}
+
+// filterStocks keeps the stored stocks with the given symbols, in the order of symbols.
+// Symbols without stored candles are an error, since they were most likely mistyped.
+func filterStocks(stored []models.Stock, symbols []string) ([]models.Stock, error) {
+	bySymbol := make(map[string]models.Stock, len(stored))
+	for i := range stored {
+		bySymbol[stored[i].Symbol] = stored[i]
+	}
+
+	var (
+		res     = make([]models.Stock, 0, len(symbols))
+		unknown = make([]string, 0)
+	)
+	for _, symbol := range symbols {
+		stock, ok := bySymbol[symbol]
+		if !ok {
+			unknown = append(unknown, symbol)
+			continue
+		}
+		res = append(res, stock)
+	}
+
+	if len(unknown) > 0 {
+		return nil, fmt.Errorf("no candles stored for %v", strings.Join(unknown, ", "))
+	}
+	return res, nil
+}
+
+// stocks returns the selected stocks among the stocks stored in the database
+func (s *stockFlags) stocks(ctx context.Context) ([]models.Stock, error) {
+	symbols, err := s.selected()
+	if err != nil {
+		return nil, err
+	}
+
+	stored, err := db.FetchAllStocks(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch stocks: %w", err)
+	}
+
+	if symbols == nil {
+		return stored, nil
+	}
+	return filterStocks(stored, symbols)
+}
@@ -4,13 +4,16 @@
 package config
 
 import (
+	"cmp"
 	"eeye/src/constants"
 	"eeye/src/utils"
-	"log"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/url"
 	"os"
 	"strconv"
-
-	"github.com/joho/godotenv"
+	"time"
 )
 
 // Groww holds configuration for the trading API connection.
@@ -30,7 +33,137 @@ var Groww = struct {
 
 	// RequestPerSecond defines the maximum API requests per second
 	RequestPerSecond int
-}{RequestPerSecond: constants.MinRequestPerSecond}
+
+	// Burst is the number of requests that may be sent back to back
+	Burst int
+
+	// MaxRetries is the number of retries of a request failing with a transient error
+	MaxRetries int
+
+	// RetryBaseDelay is the wait before the first retry, doubled on every attempt
+	RetryBaseDelay time.Duration
+
+	// RetryMaxDelay caps the exponential backoff between retries
+	RetryMaxDelay time.Duration
+
+	// MaxRangeDays is the longest range of days requested in a single historical data call
+	MaxRangeDays int
+}{
+	BaseURL:          constants.DefaultGrowwBaseURL,
+	APIVersion:       constants.DefaultGrowwAPIVersion,
+	XAPIVersion:      constants.DefaultGrowwXAPIVersion,
+	RequestPerSecond: constants.MinRequestPerSecond,
+	Burst:            constants.MinRequestPerSecond,
+	MaxRetries:       constants.DefaultMaxRetries,
+	RetryBaseDelay:   constants.DefaultRetryBaseDelay,
+	RetryMaxDelay:    constants.DefaultRetryMaxDelay,
+	MaxRangeDays:     constants.DefaultMaxRangeDays,
+}
+
+// Ingestion holds the configuration of the candle ingestion
+var Ingestion = struct {
+	// Source is the provider of daily candles ("groww" or "bhavcopy")
+	Source string
+
+	// HistoryDays is the number of days of history kept for every stock.
+	// Raising it (e.g. to 3650 for backtests) extends existing stocks on the next run.
+	HistoryDays int
+
+	// DeliveryDays is the number of recent days for which missing delivery data is
+	// fetched after ingestion (0 disables it)
+	DeliveryDays int
+}{
+	Source:       constants.CandleSourceGroww,
+	HistoryDays:  constants.LookBackDays,
+	DeliveryDays: constants.DefaultDeliveryDays,
+}
+
+// Analysis holds the configuration of the analysis phase
+var Analysis = struct {
+	// BatchSize is the number of stocks whose candles are loaded by a single query.
+	// At most one batch waits in memory ahead of the strategy workers.
+	BatchSize int
+
+	// Trim loads only the latest candles needed by the active strategies instead of the full history
+	Trim bool
+
+	// CacheDir is the directory of the persistent candle cache (empty disables it)
+	CacheDir string
+
+	// CacheRefresh syncs the candle cache with the database before analysis.
+	// Disabling it serves cached stocks from disk only, e.g. while iterating on a strategy.
+	CacheRefresh bool
+}{
+	BatchSize:    constants.DefaultAnalysisBatchSize,
+	CacheRefresh: true,
+}
+
+// Workers holds the sizes of the worker pools and of the channels between them
+var Workers = struct {
+	// StrategyWorkers is the number of goroutines analyzing stocks concurrently
+	StrategyWorkers int
+
+	// StrategyQueueSize is the buffer size of the channel feeding stocks to the strategy workers
+	StrategyQueueSize int
+
+	// StrategySinkSize is the buffer size of each strategy's output channel
+	StrategySinkSize int
+
+	// IngestionWorkers is the number of goroutines ingesting stocks concurrently.
+	// Requests are rate limited, so more workers mainly help with slow responses.
+	IngestionWorkers int
+
+	// IngestionQueueSize is the buffer size of the channel feeding stocks to the ingestion workers
+	IngestionQueueSize int
+}{
+	StrategyWorkers:    constants.DefaultStrategyWorkers,
+	StrategyQueueSize:  constants.DefaultStrategyQueueSize,
+	StrategySinkSize:   constants.DefaultStrategySinkSize,
+	IngestionWorkers:   constants.DefaultIngestionWorkers,
+	IngestionQueueSize: constants.DefaultIngestionQueueSize,
+}
+
+// Log holds the configuration of the application logs
+var Log = struct {
+	// Level is the minimum level of the logged records (debug, info, warn or error)
+	Level slog.Level
+
+	// Format is the format of the log records ("text" or "json")
+	Format string
+
+	// Dir is the directory where every run writes its own log file
+	Dir string
+
+	// MaxFiles is the number of run log files kept (0 keeps all)
+	MaxFiles int
+}{
+	Level:    slog.LevelInfo,
+	Format:   constants.LogFormatText,
+	Dir:      constants.DefaultLogDir,
+	MaxFiles: constants.DefaultLogMaxFiles,
+}
+
+// Metrics holds the configuration of the pipeline metrics
+var Metrics = struct {
+	// Addr is the address /metrics is served on in the Prometheus text format (empty disables it)
+	Addr string
+}{}
+
+// Storage holds the TimescaleDB policies of the stock_prices hypertable
+var Storage = struct {
+	// CompressAfterDays is the age of chunks (in days) after which they are compressed (0 disables compression)
+	CompressAfterDays int
+
+	// RetentionDays is the age of chunks (in days) after which they are dropped (0 keeps all data)
+	RetentionDays int
+}{CompressAfterDays: constants.DefaultCompressAfterDays}
+
+// Cleanup holds the safeguards of the delisted stock cleanup
+var Cleanup = struct {
+	// DelistAfterSessions is the number of consecutive trading sessions without a candle
+	// after which a stock missing from the bhavcopy is considered delisted
+	DelistAfterSessions int
+}{DelistAfterSessions: constants.DefaultDelistAfterSessions}
 
 // DB holds the PostgreSQL database connection configuration.
 var DB = struct {
@@ -51,13 +184,36 @@ var DB = struct {
 
 	// Tz is the timezone for database connections
 	Tz string
-}{}
+}{
+	Host: constants.DefaultDBHost,
+	Port: constants.DefaultDBPort,
+	Name: constants.DefaultDBName,
+	Tz:   constants.DefaultTz,
+}
 
 // NSE holds configuration for NSE Bhavcopy downloads
 var NSE = struct {
 	// BaseURL is the base URL for NSE Bhavcopy downloads
 	BaseURL string
-}{}
+
+	// DeliveryBaseURL is the base URL for NSE security-wise delivery file downloads
+	DeliveryBaseURL string
+}{
+	BaseURL:         constants.DefaultBhavcopyBaseURL,
+	DeliveryBaseURL: constants.DefaultDeliveryBaseURL,
+}
+
+// HTTP holds the record/replay configuration of the HTTP clients
+var HTTP = struct {
+	// Mode is "live", "record" or "replay"
+	Mode string
+
+	// FixturesDir is the directory where responses are recorded to and replayed from
+	FixturesDir string
+}{
+	Mode:        constants.HTTPModeLive,
+	FixturesDir: constants.DefaultFixturesDir,
+}
 
 // MCP holds the MCP server configuration
 var MCP = struct {
@@ -66,42 +222,536 @@ var MCP = struct {
 
 	// Port is the MCP server port
 	Port string
+}{
+	Host: constants.DefaultMCPHost,
+	Port: constants.DefaultMCPPort,
+}
+
+// Calendar holds the configuration of the NSE trading calendar
+var Calendar = struct {
+	// HolidaysFile is the path of the CSV file listing holidays and special sessions
+	HolidaysFile string
+}{HolidaysFile: constants.DefaultHolidaysFile}
+
+// Daemon holds the configuration for the long-running scheduler mode
+var Daemon = struct {
+	// Schedule is a cron expression (minute hour day-of-month month day-of-week)
+	// evaluated in the EEYE_TZ timezone
+	Schedule string
+
+	// RetryInterval is the wait between attempts when the bhavcopy is not published yet
+	RetryInterval time.Duration
+
+	// MaxRetries is the number of attempts before giving up on a session
+	MaxRetries int
+}{
+	Schedule:      constants.DefaultDaemonSchedule,
+	RetryInterval: constants.DefaultDaemonRetryInterval,
+	MaxRetries:    constants.DefaultDaemonMaxRetries,
+}
+
+// Notify holds the settings shared by the notification sinks. The sinks are notified
+// of the signals of every run; a sink is disabled until its address is set.
+var Notify = struct {
+	// Template is the path of a text/template file rendering the notifications
+	// (empty uses the built-in template)
+	Template string
+
+	// Timeout bounds the delivery of a notification to a single sink
+	Timeout time.Duration
+
+	// NewOnly only notifies the signals which are new since the previous run
+	NewOnly bool
+}{Timeout: constants.DefaultNotifyTimeout}
+
+// Webhook holds the generic HTTP webhook sink, which receives the signals as JSON
+var Webhook = struct {
+	// URL is the endpoint the notifications are POSTed to (empty disables the sink)
+	URL string
+
+	// Strategies is a comma separated list of the strategies notified (empty for all)
+	Strategies string
 }{}
 
-// Load reads configuration from environment variables and initializes
-// the application's configuration structures. It will panic if required
-// environment variables are missing or invalid.
-func Load() {
-	err := godotenv.Load()
+// SMTP holds the email sink
+var SMTP = struct {
+	// Host is the SMTP server (empty disables the sink)
+	Host string
+
+	// Port is the SMTP server port; STARTTLS is used whenever the server offers it
+	Port string
+
+	// User and Password authenticate with the server (no authentication without a user)
+	User     string
+	Password string
+
+	// From is the sender address
+	From string
+
+	// To is a comma separated list of recipient addresses
+	To string
+
+	// Strategies is a comma separated list of the strategies notified (empty for all)
+	Strategies string
+}{Port: constants.DefaultSMTPPort}
+
+// Telegram holds the chat bot sink
+var Telegram = struct {
+	// Token is the bot token (empty disables the sink)
+	Token string
+
+	// ChatID is the chat the bot posts to
+	ChatID string
+
+	// BaseURL is the root URL of the bot API
+	BaseURL string
+
+	// Strategies is a comma separated list of the strategies notified (empty for all)
+	Strategies string
+}{BaseURL: constants.DefaultTelegramBaseURL}
+
+// Options select the configuration sources of Load
+type Options struct {
+	// File is the YAML config file. It defaults to EEYE_CONFIG, or eeye.yaml if it exists.
+	File string
+
+	// Profile is the profile applied on top of the config file. It defaults to
+	// EEYE_PROFILE, or the profile selected in the config file.
+	Profile string
+
+	// Overrides are values set by command line flags, keyed by environment variable
+	Overrides map[string]string
+}
+
+// Loaded describes the sources of the loaded configuration
+var Loaded = struct {
+	// File is the config file read, if any
+	File string
+
+	// Profile is the applied profile, if any
+	Profile string
+
+	// sources maps the environment variable of every setting which is not a default to its source
+	sources map[string]string
+}{sources: make(map[string]string)}
+
+// Load initializes the application's configuration structures from, in increasing order
+// of precedence: the defaults, the optional .env file, the YAML config file, the selected
+// profile, environment variables and the overrides of command line flags. Invalid values
+// and missing required settings are all reported in the returned error.
+func Load(opts Options) error {
+	dotEnv, err := readDotEnv()
 	if err != nil {
-		log.Fatalf("error loading .env file")
+		return err
 	}
 
-	Groww.AccessToken = os.Getenv("GROWW_ACCESS_TOKEN")
-	Groww.BaseURL = os.Getenv("GROWW_BASE_URL")
-	Groww.APIVersion = os.Getenv("GROWW_API_VERSION")
-	Groww.XAPIVersion = os.Getenv("GROWW_X_API_VERSION")
+	if opts.File == "" {
+		opts.File = cmp.Or(os.Getenv("EEYE_CONFIG"), dotEnv["EEYE_CONFIG"])
+	}
+	if opts.File == "" {
+		if _, err := os.Stat(constants.DefaultConfigFile); err == nil {
+			opts.File = constants.DefaultConfigFile
+		}
+	}
+	if opts.Profile == "" {
+		opts.Profile = cmp.Or(os.Getenv("EEYE_PROFILE"), dotEnv["EEYE_PROFILE"])
+	}
 
-	DB.Host = os.Getenv("EEYE_DB_HOST")
-	DB.Port = os.Getenv("EEYE_DB_PORT")
-	DB.User = os.Getenv("EEYE_DB_USER")
-	DB.Password = os.Getenv("EEYE_DB_PASSWORD")
-	DB.Name = os.Getenv("EEYE_DB_NAME")
-	DB.Tz = os.Getenv("EEYE_TZ")
+	var file *configFile
+	if opts.File != "" {
+		if file, err = readConfigFile(opts.File); err != nil {
+			return err
+		}
+	}
 
-	requestPerSecond, err := strconv.Atoi(os.Getenv("GROWW_RPS"))
-	if err == nil {
-		Groww.RequestPerSecond = utils.Clamp[int, int](
-			requestPerSecond,
-			constants.MinRequestPerSecond,
-			constants.MaxRequestPerSecond,
-		)
-	} else {
-		log.Println("invalid GROWW_RPS defaulting to", constants.MaxRequestPerSecond)
+	l, profile, err := newLoader(opts, dotEnv, file, environ())
+	if err != nil {
+		return err
 	}
+	Loaded.File = opts.File
+	Loaded.Profile = profile
+	Loaded.sources = l.sources
 
-	NSE.BaseURL = os.Getenv("NSE_BHAVCOPY_BASE_URL")
+	l.load()
+	l.validate()
+	if len(l.errs) > 0 {
+		return fmt.Errorf("invalid configuration: %w", errors.Join(l.errs...))
+	}
+	return nil
+}
+
+// str sets dst to the value of a setting, if it is set. A setting set to empty clears
+// dst, e.g. a profile disabling the metrics server of the config file.
+func (l *loader) str(env string, dst *string) {
+	if v, ok := l.lookup(env); ok {
+		*dst = v
+	}
+}
 
-	MCP.Host = os.Getenv("MCP_HOST")
-	MCP.Port = os.Getenv("MCP_PORT")
+// load parses the settings into the configuration structures
+func (l *loader) load() {
+	l.str("GROWW_ACCESS_TOKEN", &Groww.AccessToken)
+	l.str("GROWW_BASE_URL", &Groww.BaseURL)
+	l.str("GROWW_API_VERSION", &Groww.APIVersion)
+	l.str("GROWW_X_API_VERSION", &Groww.XAPIVersion)
+
+	l.str("EEYE_DB_HOST", &DB.Host)
+	l.str("EEYE_DB_PORT", &DB.Port)
+	l.str("EEYE_DB_USER", &DB.User)
+	l.str("EEYE_DB_PASSWORD", &DB.Password)
+	l.str("EEYE_DB_NAME", &DB.Name)
+	l.str("EEYE_TZ", &DB.Tz)
+
+	if v := l.get("GROWW_RPS"); v != "" {
+		requestPerSecond, err := strconv.Atoi(v)
+		if err == nil {
+			Groww.RequestPerSecond = utils.Clamp[int, int](
+				requestPerSecond,
+				constants.MinRequestPerSecond,
+				constants.MaxRequestPerSecond,
+			)
+		} else {
+			l.invalid("GROWW_RPS")
+		}
+	}
+
+	// Burst defaults to one second worth of requests
+	Groww.Burst = Groww.RequestPerSecond
+	if v := l.get("GROWW_BURST"); v != "" {
+		burst, err := strconv.Atoi(v)
+		if err == nil && burst > 0 {
+			Groww.Burst = burst
+		} else {
+			l.invalid("GROWW_BURST")
+		}
+	}
+
+	if v := l.get("GROWW_MAX_RETRIES"); v != "" {
+		maxRetries, err := strconv.Atoi(v)
+		if err == nil && maxRetries >= 0 {
+			Groww.MaxRetries = maxRetries
+		} else {
+			l.invalid("GROWW_MAX_RETRIES")
+		}
+	}
+
+	if v := l.get("GROWW_RETRY_BASE_DELAY"); v != "" {
+		delay, err := time.ParseDuration(v)
+		if err == nil && delay > 0 {
+			Groww.RetryBaseDelay = delay
+		} else {
+			l.invalid("GROWW_RETRY_BASE_DELAY")
+		}
+	}
+
+	if v := l.get("GROWW_RETRY_MAX_DELAY"); v != "" {
+		delay, err := time.ParseDuration(v)
+		if err == nil && delay > 0 {
+			Groww.RetryMaxDelay = delay
+		} else {
+			l.invalid("GROWW_RETRY_MAX_DELAY")
+		}
+	}
+
+	if v := l.get("GROWW_MAX_RANGE_DAYS"); v != "" {
+		maxRangeDays, err := strconv.Atoi(v)
+		if err == nil && maxRangeDays > 0 {
+			Groww.MaxRangeDays = maxRangeDays
+		} else {
+			l.invalid("GROWW_MAX_RANGE_DAYS")
+		}
+	}
+
+	if v := l.get("EEYE_HISTORY_DAYS"); v != "" {
+		historyDays, err := strconv.Atoi(v)
+		if err == nil && historyDays > 0 {
+			Ingestion.HistoryDays = historyDays
+		} else {
+			l.invalid("EEYE_HISTORY_DAYS")
+		}
+	}
+
+	switch source := l.get("EEYE_CANDLE_SOURCE"); source {
+	case "":
+	case constants.CandleSourceGroww, constants.CandleSourceBhavcopy:
+		Ingestion.Source = source
+	default:
+		l.invalid("EEYE_CANDLE_SOURCE")
+	}
+
+	if v := l.get("EEYE_DELIVERY_DAYS"); v != "" {
+		deliveryDays, err := strconv.Atoi(v)
+		if err == nil && deliveryDays >= 0 {
+			Ingestion.DeliveryDays = deliveryDays
+		} else {
+			l.invalid("EEYE_DELIVERY_DAYS")
+		}
+	}
+
+	if v := l.get("EEYE_ANALYSIS_BATCH_SIZE"); v != "" {
+		batchSize, err := strconv.Atoi(v)
+		if err == nil && batchSize > 0 {
+			Analysis.BatchSize = batchSize
+		} else {
+			l.invalid("EEYE_ANALYSIS_BATCH_SIZE")
+		}
+	}
+
+	if v := l.get("EEYE_ANALYSIS_TRIM"); v != "" {
+		trim, err := strconv.ParseBool(v)
+		if err == nil {
+			Analysis.Trim = trim
+		} else {
+			l.invalid("EEYE_ANALYSIS_TRIM")
+		}
+	}
+
+	l.str("EEYE_CANDLE_CACHE_DIR", &Analysis.CacheDir)
+
+	if v := l.get("EEYE_CANDLE_CACHE_REFRESH"); v != "" {
+		refresh, err := strconv.ParseBool(v)
+		if err == nil {
+			Analysis.CacheRefresh = refresh
+		} else {
+			l.invalid("EEYE_CANDLE_CACHE_REFRESH")
+		}
+	}
+
+	if v := l.get("EEYE_STRATEGY_WORKERS"); v != "" {
+		workers, err := strconv.Atoi(v)
+		if err == nil && workers > 0 {
+			Workers.StrategyWorkers = workers
+		} else {
+			l.invalid("EEYE_STRATEGY_WORKERS")
+		}
+	}
+
+	if v := l.get("EEYE_STRATEGY_QUEUE_SIZE"); v != "" {
+		size, err := strconv.Atoi(v)
+		if err == nil && size >= 0 {
+			Workers.StrategyQueueSize = size
+		} else {
+			l.invalid("EEYE_STRATEGY_QUEUE_SIZE")
+		}
+	}
+
+	if v := l.get("EEYE_STRATEGY_SINK_SIZE"); v != "" {
+		size, err := strconv.Atoi(v)
+		if err == nil && size > 0 {
+			Workers.StrategySinkSize = size
+		} else {
+			l.invalid("EEYE_STRATEGY_SINK_SIZE")
+		}
+	}
+
+	if v := l.get("EEYE_INGESTION_WORKERS"); v != "" {
+		workers, err := strconv.Atoi(v)
+		if err == nil && workers > 0 {
+			Workers.IngestionWorkers = workers
+		} else {
+			l.invalid("EEYE_INGESTION_WORKERS")
+		}
+	}
+
+	if v := l.get("EEYE_INGESTION_QUEUE_SIZE"); v != "" {
+		size, err := strconv.Atoi(v)
+		if err == nil && size >= 0 {
+			Workers.IngestionQueueSize = size
+		} else {
+			l.invalid("EEYE_INGESTION_QUEUE_SIZE")
+		}
+	}
+
+	l.str("EEYE_METRICS_ADDR", &Metrics.Addr)
+
+	if v := l.get("EEYE_LOG_LEVEL"); v != "" {
+		if err := Log.Level.UnmarshalText([]byte(v)); err != nil {
+			l.invalid("EEYE_LOG_LEVEL")
+		}
+	}
+
+	switch format := l.get("EEYE_LOG_FORMAT"); format {
+	case "":
+	case constants.LogFormatText, constants.LogFormatJSON:
+		Log.Format = format
+	default:
+		l.invalid("EEYE_LOG_FORMAT")
+	}
+
+	if logDir := l.get("EEYE_LOG_DIR"); logDir != "" {
+		Log.Dir = logDir
+	}
+
+	if v := l.get("EEYE_LOG_MAX_FILES"); v != "" {
+		maxFiles, err := strconv.Atoi(v)
+		if err == nil && maxFiles >= 0 {
+			Log.MaxFiles = maxFiles
+		} else {
+			l.invalid("EEYE_LOG_MAX_FILES")
+		}
+	}
+
+	if v := l.get("EEYE_COMPRESS_AFTER_DAYS"); v != "" {
+		compressAfterDays, err := strconv.Atoi(v)
+		if err == nil && compressAfterDays >= 0 {
+			Storage.CompressAfterDays = compressAfterDays
+		} else {
+			l.invalid("EEYE_COMPRESS_AFTER_DAYS")
+		}
+	}
+
+	if v := l.get("EEYE_RETENTION_DAYS"); v != "" {
+		retentionDays, err := strconv.Atoi(v)
+		switch {
+		case err != nil || retentionDays < 0:
+			l.invalid("EEYE_RETENTION_DAYS")
+		case retentionDays > 0 && retentionDays <= Ingestion.HistoryDays:
+			// Dropped chunks would be backfilled again on every run
+			l.fail("EEYE_RETENTION_DAYS (%v) must exceed EEYE_HISTORY_DAYS (%v)", retentionDays, Ingestion.HistoryDays)
+		default:
+			Storage.RetentionDays = retentionDays
+		}
+	}
+
+	if v := l.get("EEYE_DELIST_AFTER_SESSIONS"); v != "" {
+		sessions, err := strconv.Atoi(v)
+		if err == nil && sessions > 0 {
+			Cleanup.DelistAfterSessions = sessions
+		} else {
+			l.invalid("EEYE_DELIST_AFTER_SESSIONS")
+		}
+	}
+
+	l.str("NSE_BHAVCOPY_BASE_URL", &NSE.BaseURL)
+	l.str("NSE_DELIVERY_BASE_URL", &NSE.DeliveryBaseURL)
+
+	switch mode := l.get("EEYE_HTTP_MODE"); mode {
+	case "":
+	case constants.HTTPModeLive, constants.HTTPModeRecord, constants.HTTPModeReplay:
+		HTTP.Mode = mode
+	default:
+		l.invalid("EEYE_HTTP_MODE")
+	}
+
+	if fixturesDir := l.get("EEYE_FIXTURES_DIR"); fixturesDir != "" {
+		HTTP.FixturesDir = fixturesDir
+	}
+
+	l.str("MCP_HOST", &MCP.Host)
+	l.str("MCP_PORT", &MCP.Port)
+
+	if holidaysFile := l.get("EEYE_HOLIDAYS_FILE"); holidaysFile != "" {
+		Calendar.HolidaysFile = holidaysFile
+	}
+
+	if schedule := l.get("EEYE_SCHEDULE"); schedule != "" {
+		Daemon.Schedule = schedule
+	}
+
+	if v := l.get("EEYE_SCHEDULE_RETRY_INTERVAL"); v != "" {
+		retryInterval, err := time.ParseDuration(v)
+		if err == nil && retryInterval > 0 {
+			Daemon.RetryInterval = retryInterval
+		} else {
+			l.invalid("EEYE_SCHEDULE_RETRY_INTERVAL")
+		}
+	}
+
+	if v := l.get("EEYE_SCHEDULE_MAX_RETRIES"); v != "" {
+		maxRetries, err := strconv.Atoi(v)
+		if err == nil && maxRetries >= 0 {
+			Daemon.MaxRetries = maxRetries
+		} else {
+			l.invalid("EEYE_SCHEDULE_MAX_RETRIES")
+		}
+	}
+
+	l.str("EEYE_NOTIFY_TEMPLATE", &Notify.Template)
+
+	if v := l.get("EEYE_NOTIFY_TIMEOUT"); v != "" {
+		timeout, err := time.ParseDuration(v)
+		if err == nil && timeout > 0 {
+			Notify.Timeout = timeout
+		} else {
+			l.invalid("EEYE_NOTIFY_TIMEOUT")
+		}
+	}
+
+	if v := l.get("EEYE_NOTIFY_NEW_ONLY"); v != "" {
+		newOnly, err := strconv.ParseBool(v)
+		if err == nil {
+			Notify.NewOnly = newOnly
+		} else {
+			l.invalid("EEYE_NOTIFY_NEW_ONLY")
+		}
+	}
+
+	l.str("EEYE_WEBHOOK_URL", &Webhook.URL)
+	l.str("EEYE_WEBHOOK_STRATEGIES", &Webhook.Strategies)
+
+	l.str("EEYE_SMTP_HOST", &SMTP.Host)
+	l.str("EEYE_SMTP_PORT", &SMTP.Port)
+	l.str("EEYE_SMTP_USER", &SMTP.User)
+	l.str("EEYE_SMTP_PASSWORD", &SMTP.Password)
+	l.str("EEYE_SMTP_FROM", &SMTP.From)
+	l.str("EEYE_SMTP_TO", &SMTP.To)
+	l.str("EEYE_SMTP_STRATEGIES", &SMTP.Strategies)
+
+	l.str("TELEGRAM_BOT_TOKEN", &Telegram.Token)
+	l.str("TELEGRAM_CHAT_ID", &Telegram.ChatID)
+	l.str("TELEGRAM_BASE_URL", &Telegram.BaseURL)
+	l.str("TELEGRAM_STRATEGIES", &Telegram.Strategies)
+}
+
+// validate checks the required settings and the settings depending on each other
+func (l *loader) validate() {
+	if _, err := time.LoadLocation(DB.Tz); err != nil {
+		l.fail("invalid EEYE_TZ %q: %v", DB.Tz, err)
+	}
+
+	required := []struct{ env, value string }{
+		{"EEYE_DB_HOST", DB.Host},
+		{"EEYE_DB_USER", DB.User},
+		{"EEYE_DB_NAME", DB.Name},
+		{"EEYE_TZ", DB.Tz},
+	}
+	for _, r := range required {
+		if r.value == "" {
+			l.fail("%v is required", r.env)
+		}
+	}
+
+	ports := []struct{ env, value string }{
+		{"EEYE_DB_PORT", DB.Port},
+		{"MCP_PORT", MCP.Port},
+		{"EEYE_SMTP_PORT", SMTP.Port},
+	}
+	for _, p := range ports {
+		if port, err := strconv.Atoi(p.value); err != nil || port < 1 || port > 65535 {
+			l.invalid(p.env)
+		}
+	}
+
+	// Candles are only requested from Groww by the groww source, and never while replaying fixtures
+	if Groww.AccessToken == "" && Ingestion.Source == constants.CandleSourceGroww && HTTP.Mode != constants.HTTPModeReplay {
+		l.fail("GROWW_ACCESS_TOKEN is required by EEYE_CANDLE_SOURCE=%v", constants.CandleSourceGroww)
+	}
+
+	if Groww.RetryMaxDelay < Groww.RetryBaseDelay {
+		l.fail("GROWW_RETRY_MAX_DELAY (%v) is below GROWW_RETRY_BASE_DELAY (%v)", Groww.RetryMaxDelay, Groww.RetryBaseDelay)
+	}
+
+	if Webhook.URL != "" {
+		if u, err := url.Parse(Webhook.URL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			l.invalid("EEYE_WEBHOOK_URL")
+		}
+	}
+
+	// A sink is enabled by its address, the other settings it needs are then required
+	if SMTP.Host != "" && (SMTP.From == "" || SMTP.To == "") {
+		l.fail("EEYE_SMTP_FROM and EEYE_SMTP_TO are required by EEYE_SMTP_HOST")
+	}
+	if Telegram.Token != "" && Telegram.ChatID == "" {
+		l.fail("TELEGRAM_CHAT_ID is required by TELEGRAM_BOT_TOKEN")
+	}
 }
@@ -0,0 +1,257 @@
+package config
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/goccy/go-yaml"
+	"github.com/joho/godotenv"
+)
+
+// layer holds the values of settings (keyed by environment variable) set by one source.
+// A setting present with an empty value is set to empty, which clears the value of the
+// lower layers; an absent setting is left to them.
+type layer struct {
+	// name describes the source, e.g. eeye.yaml or profile dev
+	name string
+
+	values map[string]string
+}
+
+// loader looks settings up in layers of increasing precedence and collects every
+// invalid value instead of stopping at the first one
+type loader struct {
+	layers  []layer
+	sources map[string]string
+	errs    []error
+}
+
+// lookup returns the value of a setting from the layer with the highest precedence
+// which sets it, possibly to empty, and whether any layer sets it
+func (l *loader) lookup(env string) (string, bool) {
+	for i := len(l.layers) - 1; i >= 0; i-- {
+		if v, ok := l.layers[i].values[env]; ok {
+			l.sources[env] = l.layers[i].name
+			return v, true
+		}
+	}
+	return "", false
+}
+
+// get returns the value of a setting from the layer with the highest precedence
+// which sets it, or an empty string
+func (l *loader) get(env string) string {
+	v, _ := l.lookup(env)
+	return v
+}
+
+// invalid records the value of a setting as invalid
+func (l *loader) invalid(env string) {
+	value := l.get(env)
+	l.errs = append(l.errs, fmt.Errorf("invalid %v %q (set by %v)", env, value, cmp.Or(l.sources[env], "default")))
+}
+
+// fail records a configuration error
+func (l *loader) fail(format string, args ...any) {
+	l.errs = append(l.errs, fmt.Errorf(format, args...))
+}
+
+// envKeys maps the dotted config file keys to the environment variables of the settings
+func envKeys() map[string]string {
+	res := make(map[string]string)
+	for _, s := range settings() {
+		res[s.Key] = s.Env
+	}
+	return res
+}
+
+// fmtValue formats a setting value the way it is written in the environment
+func fmtValue(v any) string {
+	if v == nil {
+		return ""
+	}
+	return fmt.Sprint(v)
+}
+
+// flatten converts the nested sections of a config file to the values of environment
+// variables; unknown keys and sections holding a list or a section for a setting are errors
+func flatten(node map[string]any, prefix string, keys map[string]string, out map[string]string) []error {
+	var (
+		errs  = make([]error, 0)
+		names = make([]string, 0, len(node))
+	)
+	for name := range node {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		key := prefix + name
+		switch value := node[name].(type) {
+		case map[string]any:
+			errs = append(errs, flatten(value, key+".", keys, out)...)
+		case []any:
+			errs = append(errs, fmt.Errorf("%v must be a single value, not a list", key))
+		default:
+			env, ok := keys[key]
+			if !ok {
+				errs = append(errs, fmt.Errorf("unknown key %v", key))
+				continue
+			}
+			out[env] = fmtValue(value)
+		}
+	}
+	return errs
+}
+
+// configFile is the content of a config file: the settings, the profile selected by
+// default and the profiles, all as nested sections of settings
+type configFile struct {
+	settings map[string]string
+	profile  string
+	profiles map[string]map[string]string
+}
+
+// readConfigFile reads a YAML config file
+func readConfigFile(path string) (*configFile, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	root := make(map[string]any)
+	if err := yaml.Unmarshal(data, &root); err != nil {
+		return nil, fmt.Errorf("failed to parse config file %v: %w", path, err)
+	}
+
+	var (
+		keys = envKeys()
+		file = &configFile{
+			settings: make(map[string]string),
+			profiles: make(map[string]map[string]string),
+		}
+		errs = make([]error, 0)
+	)
+
+	if profile, ok := root["profile"]; ok {
+		name, isString := profile.(string)
+		if !isString {
+			errs = append(errs, errors.New("profile must be a profile name"))
+		}
+		file.profile = name
+		delete(root, "profile")
+	}
+
+	if section, ok := root["profiles"]; ok {
+		named, isMap := section.(map[string]any)
+		if !isMap {
+			errs = append(errs, errors.New("profiles must map profile names to settings"))
+		}
+		for name, node := range named {
+			settings, isMap := node.(map[string]any)
+			if !isMap {
+				errs = append(errs, fmt.Errorf("profile %v must hold settings", name))
+				continue
+			}
+
+			file.profiles[name] = make(map[string]string)
+			for _, err := range flatten(settings, "", keys, file.profiles[name]) {
+				errs = append(errs, fmt.Errorf("profile %v: %w", name, err))
+			}
+		}
+		delete(root, "profiles")
+	}
+
+	errs = append(errs, flatten(root, "", keys, file.settings)...)
+	if len(errs) > 0 {
+		return nil, fmt.Errorf("invalid config file %v: %w", path, errors.Join(errs...))
+	}
+	return file, nil
+}
+
+// readDotEnv returns the settings of the .env file, which is optional
+func readDotEnv() (map[string]string, error) {
+	values, err := godotenv.Read()
+	if errors.Is(err, fs.ErrNotExist) {
+		return make(map[string]string), nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read .env: %w", err)
+	}
+	return values, nil
+}
+
+// environ returns the settings set in the environment, including those set to empty
+func environ() map[string]string {
+	values := make(map[string]string)
+	for _, s := range settings() {
+		if v, ok := os.LookupEnv(s.Env); ok {
+			values[s.Env] = v
+		}
+	}
+	return values
+}
+
+// profileNames returns the names of the built-in profiles and of the profiles of a config file
+func profileNames(file *configFile) []string {
+	names := make([]string, 0, len(profiles))
+	for name := range profiles {
+		names = append(names, name)
+	}
+	if file != nil {
+		for name := range file.profiles {
+			if _, ok := profiles[name]; !ok {
+				names = append(names, name)
+			}
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// newLoader stacks the sources of the settings, from the lowest to the highest precedence:
+// .env, the config file, the built-in profile, the profile of the config file, the
+// environment and the flags
+func newLoader(opts Options, dotEnv map[string]string, file *configFile, env map[string]string) (*loader, string, error) {
+	l := &loader{sources: make(map[string]string)}
+	l.layers = append(l.layers, layer{name: ".env", values: dotEnv})
+
+	profile := opts.Profile
+	if file != nil {
+		l.layers = append(l.layers, layer{name: opts.File, values: file.settings})
+		if profile == "" {
+			profile = file.profile
+		}
+	}
+
+	if profile != "" {
+		builtin, isBuiltin := profiles[profile]
+		var custom map[string]string
+		if file != nil {
+			custom = file.profiles[profile]
+		}
+
+		if !isBuiltin && custom == nil {
+			return nil, "", fmt.Errorf(
+				"unknown profile %q, available: %v",
+				profile,
+				strings.Join(profileNames(file), ", "),
+			)
+		}
+		if isBuiltin {
+			l.layers = append(l.layers, layer{name: "profile " + profile, values: builtin})
+		}
+		if custom != nil {
+			l.layers = append(l.layers, layer{name: fmt.Sprintf("profile %v (%v)", profile, opts.File), values: custom})
+		}
+	}
+
+	l.layers = append(l.layers, layer{name: "environment", values: env})
+	l.layers = append(l.layers, layer{name: "flag", values: opts.Overrides})
+	return l, profile, nil
+}
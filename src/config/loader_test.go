@@ -0,0 +1,208 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func writeConfigFile(t *testing.T, content string) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "eeye.yaml")
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestReadConfigFile(t *testing.T) {
+	path := writeConfigFile(t, `
+profile: dev
+groww:
+  rps: 3
+  retry_base_delay: 2s
+analysis:
+  trim: true
+daemon:
+  schedule: "30 18 * * 1-5"
+profiles:
+  nightly:
+    log:
+      level: warn
+`)
+
+	file, err := readConfigFile(path)
+	if err != nil {
+		t.Fatalf("readConfigFile() error = %v", err)
+	}
+
+	wantSettings := map[string]string{
+		"GROWW_RPS":              "3",
+		"GROWW_RETRY_BASE_DELAY": "2s",
+		"EEYE_ANALYSIS_TRIM":     "true",
+		"EEYE_SCHEDULE":          "30 18 * * 1-5",
+	}
+	if !reflect.DeepEqual(file.settings, wantSettings) {
+		t.Errorf("settings = %v, want %v", file.settings, wantSettings)
+	}
+	if file.profile != "dev" {
+		t.Errorf("profile = %v, want dev", file.profile)
+	}
+	if want := map[string]string{"EEYE_LOG_LEVEL": "warn"}; !reflect.DeepEqual(file.profiles["nightly"], want) {
+		t.Errorf("profiles[nightly] = %v, want %v", file.profiles["nightly"], want)
+	}
+}
+
+func TestReadConfigFileErrors(t *testing.T) {
+	path := writeConfigFile(t, `
+groww:
+  rsp: 3
+db:
+  host: [a, b]
+profiles:
+  dev:
+    log:
+      lvl: debug
+`)
+
+	_, err := readConfigFile(path)
+	if err == nil {
+		t.Fatal("readConfigFile() error = nil, want errors")
+	}
+
+	for _, want := range []string{"unknown key groww.rsp", "db.host must be a single value", "profile dev: unknown key log.lvl"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("readConfigFile() error = %v, want it to contain %q", err, want)
+		}
+	}
+}
+
+func TestNewLoader(t *testing.T) {
+	var (
+		dotEnv = map[string]string{"GROWW_RPS": "1", "EEYE_LOG_LEVEL": "info", "EEYE_DB_USER": "admin"}
+		file   = &configFile{
+			settings: map[string]string{"GROWW_RPS": "2", "EEYE_LOG_FORMAT": "text", "EEYE_HTTP_MODE": "live"},
+			profile:  "offline",
+			profiles: map[string]map[string]string{
+				"offline": {"EEYE_FIXTURES_DIR": "testdata"},
+			},
+		}
+		env  = map[string]string{"EEYE_LOG_LEVEL": "warn"}
+		opts = Options{File: "eeye.yaml", Overrides: map[string]string{"EEYE_LOG_LEVEL": "debug"}}
+	)
+
+	l, profile, err := newLoader(opts, dotEnv, file, env)
+	if err != nil {
+		t.Fatalf("newLoader() error = %v", err)
+	}
+	if profile != "offline" {
+		t.Errorf("profile = %v, want the default profile of the file", profile)
+	}
+
+	tests := []struct {
+		env        string
+		wantValue  string
+		wantSource string
+	}{
+		{env: "EEYE_DB_USER", wantValue: "admin", wantSource: ".env"},
+		{env: "GROWW_RPS", wantValue: "2", wantSource: "eeye.yaml"},
+		{env: "EEYE_HTTP_MODE", wantValue: "replay", wantSource: "profile offline"},
+		{env: "EEYE_FIXTURES_DIR", wantValue: "testdata", wantSource: "profile offline (eeye.yaml)"},
+		{env: "EEYE_LOG_LEVEL", wantValue: "debug", wantSource: "flag"},
+		{env: "EEYE_LOG_DIR", wantValue: "", wantSource: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.env, func(t *testing.T) {
+			if got := l.get(tt.env); got != tt.wantValue || l.sources[tt.env] != tt.wantSource {
+				t.Errorf("get() = %v from %v, want %v from %v", got, l.sources[tt.env], tt.wantValue, tt.wantSource)
+			}
+		})
+	}
+}
+
+func TestLoaderEmptyValues(t *testing.T) {
+	var (
+		dotEnv = map[string]string{"EEYE_CANDLE_CACHE_DIR": ".cache", "EEYE_LOG_DIR": ""}
+		file   = &configFile{
+			settings: map[string]string{"EEYE_METRICS_ADDR": ":9090", "EEYE_WEBHOOK_URL": "http://localhost"},
+			profiles: map[string]map[string]string{
+				"nightly": {"EEYE_METRICS_ADDR": ""},
+			},
+		}
+		env  = map[string]string{"EEYE_CANDLE_CACHE_DIR": ""}
+		opts = Options{File: "eeye.yaml", Profile: "nightly"}
+	)
+
+	l, _, err := newLoader(opts, dotEnv, file, env)
+	if err != nil {
+		t.Fatalf("newLoader() error = %v", err)
+	}
+
+	tests := []struct {
+		env        string
+		current    string
+		want       string
+		wantSource string
+	}{
+		{env: "EEYE_METRICS_ADDR", current: ":9090", want: "", wantSource: "profile nightly (eeye.yaml)"},
+		{env: "EEYE_CANDLE_CACHE_DIR", current: ".cache", want: "", wantSource: "environment"},
+		{env: "EEYE_WEBHOOK_URL", current: "", want: "http://localhost", wantSource: "eeye.yaml"},
+		{env: "EEYE_LOG_DIR", current: "logs", want: "", wantSource: ".env"},
+		{env: "EEYE_SMTP_HOST", current: "default", want: "default", wantSource: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.env, func(t *testing.T) {
+			got := tt.current
+			l.str(tt.env, &got)
+			if got != tt.want || l.sources[tt.env] != tt.wantSource {
+				t.Errorf("str() = %q from %v, want %q from %v", got, l.sources[tt.env], tt.want, tt.wantSource)
+			}
+		})
+	}
+}
+
+func TestNewLoaderUnknownProfile(t *testing.T) {
+	file := &configFile{profiles: map[string]map[string]string{"nightly": {}}}
+
+	_, _, err := newLoader(Options{Profile: "staging"}, nil, file, nil)
+	if err == nil || !strings.Contains(err.Error(), "available: dev, nightly, offline, prod") {
+		t.Errorf("newLoader() error = %v, want the available profiles", err)
+	}
+}
+
+func TestSettingRedacted(t *testing.T) {
+	tests := []struct {
+		setting Setting
+		want    string
+	}{
+		{setting: Setting{Value: "token", Secret: true}, want: "<redacted>"},
+		{setting: Setting{Value: "", Secret: true}, want: ""},
+		{setting: Setting{Value: 12}, want: "12"},
+	}
+
+	for _, tt := range tests {
+		if got := tt.setting.Redacted(); got != tt.want {
+			t.Errorf("Redacted() = %v, want %v", got, tt.want)
+		}
+	}
+}
+
+func TestSettingsAreUnique(t *testing.T) {
+	var (
+		keys = make(map[string]struct{})
+		envs = make(map[string]struct{})
+	)
+	for _, s := range settings() {
+		if _, ok := keys[s.Key]; ok {
+			t.Errorf("duplicated key %v", s.Key)
+		}
+		if _, ok := envs[s.Env]; ok {
+			t.Errorf("duplicated variable %v", s.Env)
+		}
+		keys[s.Key] = struct{}{}
+		envs[s.Env] = struct{}{}
+	}
+}
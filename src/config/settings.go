@@ -0,0 +1,145 @@
+package config
+
+// Setting is a configuration value together with its config file key and environment variable
+type Setting struct {
+	// Key is the dotted key in the config file, e.g. groww.rps
+	Key string
+
+	// Env is the environment variable overriding the value, e.g. GROWW_RPS
+	Env string
+
+	// Value is the effective value
+	Value any
+
+	// Secret values are redacted when printed
+	Secret bool
+
+	// Source is where the value was set: default, .env, the config file, a profile,
+	// the environment or a flag
+	Source string
+}
+
+// settings lists every setting with its current value, in the order of the config file.
+// Its keys and variables are also the only ones accepted in config files and profiles.
+func settings() []Setting {
+	return []Setting{
+		{Key: "groww.access_token", Env: "GROWW_ACCESS_TOKEN", Value: Groww.AccessToken, Secret: true},
+		{Key: "groww.base_url", Env: "GROWW_BASE_URL", Value: Groww.BaseURL},
+		{Key: "groww.api_version", Env: "GROWW_API_VERSION", Value: Groww.APIVersion},
+		{Key: "groww.x_api_version", Env: "GROWW_X_API_VERSION", Value: Groww.XAPIVersion},
+		{Key: "groww.rps", Env: "GROWW_RPS", Value: Groww.RequestPerSecond},
+		{Key: "groww.burst", Env: "GROWW_BURST", Value: Groww.Burst},
+		{Key: "groww.max_retries", Env: "GROWW_MAX_RETRIES", Value: Groww.MaxRetries},
+		{Key: "groww.retry_base_delay", Env: "GROWW_RETRY_BASE_DELAY", Value: Groww.RetryBaseDelay},
+		{Key: "groww.retry_max_delay", Env: "GROWW_RETRY_MAX_DELAY", Value: Groww.RetryMaxDelay},
+		{Key: "groww.max_range_days", Env: "GROWW_MAX_RANGE_DAYS", Value: Groww.MaxRangeDays},
+
+		{Key: "ingestion.source", Env: "EEYE_CANDLE_SOURCE", Value: Ingestion.Source},
+		{Key: "ingestion.history_days", Env: "EEYE_HISTORY_DAYS", Value: Ingestion.HistoryDays},
+		{Key: "ingestion.delivery_days", Env: "EEYE_DELIVERY_DAYS", Value: Ingestion.DeliveryDays},
+
+		{Key: "analysis.batch_size", Env: "EEYE_ANALYSIS_BATCH_SIZE", Value: Analysis.BatchSize},
+		{Key: "analysis.trim", Env: "EEYE_ANALYSIS_TRIM", Value: Analysis.Trim},
+		{Key: "analysis.cache_dir", Env: "EEYE_CANDLE_CACHE_DIR", Value: Analysis.CacheDir},
+		{Key: "analysis.cache_refresh", Env: "EEYE_CANDLE_CACHE_REFRESH", Value: Analysis.CacheRefresh},
+
+		{Key: "workers.strategy_workers", Env: "EEYE_STRATEGY_WORKERS", Value: Workers.StrategyWorkers},
+		{Key: "workers.strategy_queue_size", Env: "EEYE_STRATEGY_QUEUE_SIZE", Value: Workers.StrategyQueueSize},
+		{Key: "workers.strategy_sink_size", Env: "EEYE_STRATEGY_SINK_SIZE", Value: Workers.StrategySinkSize},
+		{Key: "workers.ingestion_workers", Env: "EEYE_INGESTION_WORKERS", Value: Workers.IngestionWorkers},
+		{Key: "workers.ingestion_queue_size", Env: "EEYE_INGESTION_QUEUE_SIZE", Value: Workers.IngestionQueueSize},
+
+		{Key: "metrics.addr", Env: "EEYE_METRICS_ADDR", Value: Metrics.Addr},
+
+		{Key: "log.level", Env: "EEYE_LOG_LEVEL", Value: Log.Level},
+		{Key: "log.format", Env: "EEYE_LOG_FORMAT", Value: Log.Format},
+		{Key: "log.dir", Env: "EEYE_LOG_DIR", Value: Log.Dir},
+		{Key: "log.max_files", Env: "EEYE_LOG_MAX_FILES", Value: Log.MaxFiles},
+
+		{Key: "db.host", Env: "EEYE_DB_HOST", Value: DB.Host},
+		{Key: "db.port", Env: "EEYE_DB_PORT", Value: DB.Port},
+		{Key: "db.user", Env: "EEYE_DB_USER", Value: DB.User},
+		{Key: "db.password", Env: "EEYE_DB_PASSWORD", Value: DB.Password, Secret: true},
+		{Key: "db.name", Env: "EEYE_DB_NAME", Value: DB.Name},
+		{Key: "db.tz", Env: "EEYE_TZ", Value: DB.Tz},
+
+		{Key: "storage.compress_after_days", Env: "EEYE_COMPRESS_AFTER_DAYS", Value: Storage.CompressAfterDays},
+		{Key: "storage.retention_days", Env: "EEYE_RETENTION_DAYS", Value: Storage.RetentionDays},
+
+		{Key: "cleanup.delist_after_sessions", Env: "EEYE_DELIST_AFTER_SESSIONS", Value: Cleanup.DelistAfterSessions},
+
+		{Key: "nse.base_url", Env: "NSE_BHAVCOPY_BASE_URL", Value: NSE.BaseURL},
+		{Key: "nse.delivery_base_url", Env: "NSE_DELIVERY_BASE_URL", Value: NSE.DeliveryBaseURL},
+
+		{Key: "http.mode", Env: "EEYE_HTTP_MODE", Value: HTTP.Mode},
+		{Key: "http.fixtures_dir", Env: "EEYE_FIXTURES_DIR", Value: HTTP.FixturesDir},
+
+		{Key: "mcp.host", Env: "MCP_HOST", Value: MCP.Host},
+		{Key: "mcp.port", Env: "MCP_PORT", Value: MCP.Port},
+
+		{Key: "calendar.holidays_file", Env: "EEYE_HOLIDAYS_FILE", Value: Calendar.HolidaysFile},
+
+		{Key: "daemon.schedule", Env: "EEYE_SCHEDULE", Value: Daemon.Schedule},
+		{Key: "daemon.retry_interval", Env: "EEYE_SCHEDULE_RETRY_INTERVAL", Value: Daemon.RetryInterval},
+		{Key: "daemon.max_retries", Env: "EEYE_SCHEDULE_MAX_RETRIES", Value: Daemon.MaxRetries},
+
+		{Key: "notify.template", Env: "EEYE_NOTIFY_TEMPLATE", Value: Notify.Template},
+		{Key: "notify.timeout", Env: "EEYE_NOTIFY_TIMEOUT", Value: Notify.Timeout},
+		{Key: "notify.new_only", Env: "EEYE_NOTIFY_NEW_ONLY", Value: Notify.NewOnly},
+		{Key: "notify.webhook.url", Env: "EEYE_WEBHOOK_URL", Value: Webhook.URL, Secret: true},
+		{Key: "notify.webhook.strategies", Env: "EEYE_WEBHOOK_STRATEGIES", Value: Webhook.Strategies},
+		{Key: "notify.smtp.host", Env: "EEYE_SMTP_HOST", Value: SMTP.Host},
+		{Key: "notify.smtp.port", Env: "EEYE_SMTP_PORT", Value: SMTP.Port},
+		{Key: "notify.smtp.user", Env: "EEYE_SMTP_USER", Value: SMTP.User},
+		{Key: "notify.smtp.password", Env: "EEYE_SMTP_PASSWORD", Value: SMTP.Password, Secret: true},
+		{Key: "notify.smtp.from", Env: "EEYE_SMTP_FROM", Value: SMTP.From},
+		{Key: "notify.smtp.to", Env: "EEYE_SMTP_TO", Value: SMTP.To},
+		{Key: "notify.smtp.strategies", Env: "EEYE_SMTP_STRATEGIES", Value: SMTP.Strategies},
+		{Key: "notify.telegram.token", Env: "TELEGRAM_BOT_TOKEN", Value: Telegram.Token, Secret: true},
+		{Key: "notify.telegram.chat_id", Env: "TELEGRAM_CHAT_ID", Value: Telegram.ChatID},
+		{Key: "notify.telegram.base_url", Env: "TELEGRAM_BASE_URL", Value: Telegram.BaseURL},
+		{Key: "notify.telegram.strategies", Env: "TELEGRAM_STRATEGIES", Value: Telegram.Strategies},
+	}
+}
+
+// Settings returns every setting with its effective value and source, in the order of the config file
+func Settings() []Setting {
+	res := settings()
+	for i := range res {
+		res[i].Source = "default"
+		if source, ok := Loaded.sources[res[i].Env]; ok {
+			res[i].Source = source
+		}
+	}
+	return res
+}
+
+// Redacted returns the value of the setting as printed, with secrets masked
+func (s Setting) Redacted() string {
+	value := fmtValue(s.Value)
+	if s.Secret && value != "" {
+		return "<redacted>"
+	}
+	return value
+}
+
+// profiles are the built-in profiles, as values of environment variables. Config files
+// may override them or define new ones under profiles.
+var profiles = map[string]map[string]string{
+	// Verbose logs and a local candle cache while developing strategies
+	"dev": {
+		"EEYE_LOG_LEVEL":        "debug",
+		"EEYE_CANDLE_CACHE_DIR": ".cache/candles",
+	},
+
+	// JSON logs for log shippers and a longer log history
+	"prod": {
+		"EEYE_LOG_FORMAT":    "json",
+		"EEYE_LOG_MAX_FILES": "30",
+	},
+
+	// Answer NSE and Groww requests from the recorded fixtures, without network access
+	"offline": {
+		"EEYE_HTTP_MODE": "replay",
+	},
+}
@@ -3,22 +3,109 @@
 // and other immutable values used throughout the application.
 package constants
 
+import "time"
+
 const (
-	// NumOfStrategyWorkers defines the maximum number of concurrent strategy workers allowed
-	NumOfStrategyWorkers = 12
+	// DefaultStrategyWorkers defines the default number of concurrent strategy workers
+	DefaultStrategyWorkers = 12
 
-	// StrategyWorkerInputBufferSize defines the buffer size for each strategy worker's input channel
-	StrategyWorkerInputBufferSize = 100
+	// DefaultStrategyQueueSize defines the default buffer size of the strategy workers' input channel
+	DefaultStrategyQueueSize = 100
 
 	// StrategyWorkerOutputBufferSize defines the buffer size for each strategy worker's output channel
 	StrategyWorkerOutputBufferSize = 500
 
-	// NumOfIngestionWorkers defines the number of concurrent ingestion workers
-	NumOfIngestionWorkers = 4
+	// DefaultIngestionWorkers defines the default number of concurrent ingestion workers
+	DefaultIngestionWorkers = 4
+
+	// DefaultIngestionQueueSize defines the default buffer size of the ingestion workers' input channel
+	DefaultIngestionQueueSize = 20
+
+	// DefaultStrategySinkSize defines the default buffer size of each strategy's output channel
+	// (consumed by the aggregator)
+	DefaultStrategySinkSize = 20
 
-	// IngestionBufferSize defines the buffer size for the ingestion worker's input channel
-	IngestionBufferSize = 20
+	// DefaultAnalysisBatchSize defines the number of stocks whose candles are loaded by a single query
+	DefaultAnalysisBatchSize = 100
 
-	// AggregatorBufferSize defines the buffer size for the aggregator's input channel
-	AggregatorBufferSize = 20
+	// MinCandleCacheRefreshDays is the minimum number of recent days re-read when refreshing the
+	// candle cache, so that candles corrected by the provider are picked up
+	MinCandleCacheRefreshDays = 7
 )
+
+const (
+	// DefaultDaemonSchedule runs the daemon at 18:30 every day, after NSE publishes the bhavcopy.
+	// Days without a session are skipped using the trading calendar.
+	DefaultDaemonSchedule = "30 18 * * *"
+
+	// DefaultDaemonRetryInterval is the wait between bhavcopy availability checks
+	DefaultDaemonRetryInterval = 15 * time.Minute
+
+	// DefaultDaemonMaxRetries is the number of bhavcopy availability checks per session
+	DefaultDaemonMaxRetries = 12
+)
+
+const (
+	// DefaultDelistAfterSessions is the number of consecutive sessions a stock has to be missing
+	// from the database (and the bhavcopy) before the cleanup deletes it
+	DefaultDelistAfterSessions = 5
+
+	// DefaultCompressAfterDays is the default age (in days) after which stock_prices chunks are compressed.
+	// It is kept above the delivery sync window so that recent candles stay uncompressed while they are updated.
+	DefaultCompressAfterDays = 120
+)
+
+const (
+	// LogFormatText writes log records as key=value pairs
+	LogFormatText = "text"
+
+	// LogFormatJSON writes log records as JSON objects, one per line
+	LogFormatJSON = "json"
+
+	// DefaultLogDir is the directory the per-run log files are written to
+	DefaultLogDir = "logs"
+
+	// DefaultLogMaxFiles is the number of run log files kept; older ones are deleted
+	DefaultLogMaxFiles = 10
+)
+
+const (
+	// DefaultConfigFile is the config file read when it exists and no other file is given
+	DefaultConfigFile = "eeye.yaml"
+
+	// DefaultGrowwBaseURL is the root URL of the Groww trading API
+	DefaultGrowwBaseURL = "https://api.groww.in"
+
+	// DefaultGrowwAPIVersion is the version of the Groww trading API
+	DefaultGrowwAPIVersion = "v1"
+
+	// DefaultGrowwXAPIVersion is the value of the X-API-VERSION header of Groww requests
+	DefaultGrowwXAPIVersion = "1.0"
+
+	// DefaultDBHost is the host of the TimescaleDB started by scripts/setup-db.sh
+	DefaultDBHost = "localhost"
+
+	// DefaultDBPort is the PostgreSQL port
+	DefaultDBPort = "5432"
+
+	// DefaultDBName is the name of the database
+	DefaultDBName = "eeye"
+
+	// DefaultTz is the timezone of NSE sessions and database connections
+	DefaultTz = "Asia/Kolkata"
+
+	// DefaultMCPHost is the host the MCP server listens on
+	DefaultMCPHost = "localhost"
+
+	// DefaultMCPPort is the port the MCP server listens on
+	DefaultMCPPort = "3000"
+
+	// DefaultSMTPPort is the submission port of SMTP servers (STARTTLS)
+	DefaultSMTPPort = "587"
+
+	// DefaultTelegramBaseURL is the root URL of the Telegram bot API
+	DefaultTelegramBaseURL = "https://api.telegram.org"
+)
+
+// DefaultNotifyTimeout bounds the delivery of a notification to a single sink
+const DefaultNotifyTimeout = 10 * time.Second
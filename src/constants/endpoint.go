@@ -1,5 +1,7 @@
 package constants
 
+import "time"
+
 const (
 	// HistoricalDataEndpoint is the API endpoint for retrieving historical candle data
 	HistoricalDataEndpoint = "/historical/candle/range"
@@ -31,6 +33,12 @@ const (
 const (
 	// LookBackDays defines the number of days to look back for historical data
 	LookBackDays = 1080 // Approximately 3 years of trading days
+
+	// BhavcopyBatchDays is the number of bhavcopies ingested together
+	BhavcopyBatchDays = 20
+
+	// DefaultMaxRangeDays is the default number of days requested from the trading API per call
+	DefaultMaxRangeDays = 180
 )
 
 const (
@@ -40,3 +48,38 @@ const (
 	// MaxRequestPerSecond defines the maximum API requests allowed per second
 	MaxRequestPerSecond = 4
 )
+
+const (
+	// DefaultMaxRetries is the default number of retries for transient API errors
+	DefaultMaxRetries = 4
+
+	// DefaultRetryBaseDelay is the default wait before the first retry
+	DefaultRetryBaseDelay = time.Second
+
+	// DefaultRetryMaxDelay is the default cap of the exponential backoff
+	DefaultRetryMaxDelay = 30 * time.Second
+)
+
+// Sources of daily candles
+const (
+	// CandleSourceGroww fetches the candles of every stock from the Groww API
+	CandleSourceGroww = "groww"
+
+	// CandleSourceBhavcopy builds the candles of all stocks from the daily NSE bhavcopy
+	CandleSourceBhavcopy = "bhavcopy"
+)
+
+// Modes of the HTTP clients
+const (
+	// HTTPModeLive sends every request to the real endpoints
+	HTTPModeLive = "live"
+
+	// HTTPModeRecord sends requests to the real endpoints and stores the responses as fixtures
+	HTTPModeRecord = "record"
+
+	// HTTPModeReplay answers requests from recorded fixtures without network access
+	HTTPModeReplay = "replay"
+
+	// DefaultFixturesDir is the default directory of recorded HTTP fixtures
+	DefaultFixturesDir = "fixtures"
+)
@@ -7,4 +7,17 @@ const (
 
 	// NumOfStocks defines the number of stocks on NSE
 	NumOfStocks = 2500
+
+	// BhavcopyFirstDay is the first trading day published in the UDiFF bhavcopy format (YYYY-MM-DD).
+	// Older bhavcopies use a different format and have to be fetched from another source.
+	BhavcopyFirstDay = "2024-07-08"
+
+	// DefaultBhavcopyBaseURL is the default base URL of the NSE bhavcopy downloads
+	DefaultBhavcopyBaseURL = "https://nsearchives.nseindia.com/content/cm"
+
+	// DefaultDeliveryBaseURL is the default base URL of the NSE security-wise delivery files
+	DefaultDeliveryBaseURL = "https://nsearchives.nseindia.com/products/content"
+
+	// DefaultDeliveryDays is the default number of days for which missing delivery data is fetched
+	DefaultDeliveryDays = 60
 )
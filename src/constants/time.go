@@ -6,3 +6,14 @@ const (
 	// parsing and formatting timestamps in API requests and database operations.
 	TimestampFmt = "2006-01-02 15:04:05"
 )
+
+const (
+	// MarketCloseHour is the hour (in EEYE_TZ) at which the NSE cash market closes
+	MarketCloseHour = 15
+
+	// MarketCloseMinute is the minute at which the NSE cash market closes
+	MarketCloseMinute = 30
+
+	// DefaultHolidaysFile is the default location of the NSE holiday calendar
+	DefaultHolidaysFile = "data/nse_holidays.csv"
+)
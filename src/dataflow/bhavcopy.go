@@ -0,0 +1,391 @@
+package dataflow
+
+import (
+	"context"
+	"eeye/src/api"
+	"eeye/src/calendar"
+	"eeye/src/config"
+	"eeye/src/constants"
+	"eeye/src/db"
+	"eeye/src/models"
+	"eeye/src/utils"
+	"fmt"
+	"log/slog"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// isListedEquity reports whether a bhavcopy row is an equity stock in the capital
+// market segment, traded in one of the listedSeries. ETFs are excluded by their ISIN
+// prefix (stocks start with INE, ETFs with INF).
+func isListedEquity(s *models.NSEStockData) bool {
+	_, listed := listedSeries[strings.TrimSpace(s.Series)]
+	return listed &&
+		strings.TrimSpace(s.Segment) == "CM" &&
+		strings.TrimSpace(s.InstrumentType) == "STK" &&
+		strings.HasPrefix(strings.TrimSpace(s.ISIN), "INE")
+}
+
+// bhavcopy holds the rows of the bhavcopy of a single trading day
+type bhavcopy struct {
+	day  time.Time
+	rows []models.NSEStockData
+}
+
+// adjustmentTolerance is the largest difference (1%) between the previous close published
+// in a bhavcopy and the stored close of the previous session which is not a price adjustment
+const adjustmentTolerance = 0.01
+
+// bhavcopyStock tracks a stock across the bhavcopies of a bulk ingestion
+type bhavcopyStock struct {
+	stock    models.Stock
+	candles  []models.Candle
+	previous *models.Candle
+	loaded   bool
+
+	// prevCloses holds the previous close published with each candle
+	prevCloses []float64
+
+	// lastSeries is the series of the row of the last candle
+	lastSeries string
+}
+
+// toCandle converts a bhavcopy row into the daily candle of its trading day
+func toCandle(s *models.NSEStockData) (models.Candle, error) {
+	day, err := time.ParseInLocation("2006-01-02", strings.TrimSpace(s.TradeDate), calendar.NSE.Location())
+	if err != nil {
+		return models.Candle{}, fmt.Errorf("invalid trade date %q for %v: %w", s.TradeDate, s.Symbol, err)
+	}
+
+	return models.Candle{
+		Symbol:    strings.TrimSpace(s.Symbol),
+		Open:      s.Open,
+		Close:     s.Close,
+		High:      s.High,
+		Low:       s.Low,
+		Timestamp: day,
+		Volume:    s.Volume,
+		Turnover:  s.Turnover,
+	}, nil
+}
+
+// detectCorporateActions returns the corporate actions revealed by the previous closes
+// published in the bhavcopies. NSE adjusts the previous close of a stock on the ex-date
+// of a split or bonus, so a published previous close which differs from the close of the
+// previous candle marks an ex-date. Nothing is detected without a previous candle.
+func detectCorporateActions(previous *models.Candle, candles []models.Candle, prevCloses []float64) []models.CorporateAction {
+	var (
+		actions   = make([]models.CorporateAction, 0)
+		lastClose = 0.0
+	)
+
+	if previous != nil {
+		lastClose = previous.Close
+	}
+
+	for i := range candles {
+		published := prevCloses[i]
+		if lastClose > 0 && published > 0 && math.Abs(published-lastClose)/lastClose > adjustmentTolerance {
+			actions = append(actions, models.CorporateAction{
+				Symbol: candles[i].Symbol,
+				ExDate: candles[i].Timestamp,
+				Action: fmt.Sprintf("previous close adjusted from %v to %v", lastClose, published),
+			})
+		}
+		lastClose = candles[i].Close
+	}
+
+	return actions
+}
+
+// collectBhavcopyCandles replaces the candles of stocks with those of all equity stocks
+// found in the given bhavcopies (ordered oldest first). Rows are collected under the current
+// symbol of their ISIN in symbols. A stock found in several series on the same day keeps
+// the candle of its EQ row, like its listing.
+func collectBhavcopyCandles(batch []bhavcopy, symbols map[string]string, stocks map[string]*bhavcopyStock) {
+	for i := range stocks {
+		stocks[i].candles = stocks[i].candles[:0]
+		stocks[i].prevCloses = stocks[i].prevCloses[:0]
+	}
+
+	for i := range batch {
+		for j := range batch[i].rows {
+			row := &batch[i].rows[j]
+			if !isListedEquity(row) {
+				continue
+			}
+
+			candle, err := toCandle(row)
+			if err != nil {
+				slog.Debug("skipping bhavcopy row", "err", err)
+				continue
+			}
+			if symbol, ok := symbols[strings.TrimSpace(row.ISIN)]; ok {
+				candle.Symbol = symbol
+			}
+
+			s, ok := stocks[candle.Symbol]
+			if !ok {
+				s = &bhavcopyStock{
+					stock: models.Stock{
+						Symbol:   candle.Symbol,
+						Name:     strings.TrimSpace(row.Name),
+						Exchange: "NSE",
+						Segment:  "CASH",
+					},
+				}
+				stocks[candle.Symbol] = s
+			}
+
+			// A stock moving between series may be listed in both on the same day
+			if n := len(s.candles); n > 0 && s.candles[n-1].Timestamp.Equal(candle.Timestamp) {
+				if !isPreferredSeries(s.lastSeries) {
+					s.candles[n-1], s.prevCloses[n-1], s.lastSeries = candle, row.PrevClose, row.Series
+				}
+				continue
+			}
+			s.candles = append(s.candles, candle)
+			s.prevCloses = append(s.prevCloses, row.PrevClose)
+			s.lastSeries = row.Series
+		}
+	}
+}
+
+// ingestBhavcopies stores the candles of all equity stocks found in the given
+// bhavcopies (ordered oldest first). Rows are stored under the current symbol of their
+// ISIN in symbols, so that the candles traded before a rename join the renamed stock.
+// The last accepted candle of every stock is kept in stocks so that consecutive
+// batches are validated against each other.
+func ingestBhavcopies(
+	ctx context.Context,
+	batch []bhavcopy,
+	symbols map[string]string,
+	stocks map[string]*bhavcopyStock,
+) []ingestionFailure {
+	collectBhavcopyCandles(batch, symbols, stocks)
+
+	jobs := make([]ingestionJob, 0, len(stocks))
+	for symbol := range stocks {
+		s := stocks[symbol]
+		if len(s.candles) == 0 {
+			continue
+		}
+
+		jobs = append(jobs, ingestionJob{
+			stock: &s.stock,
+			backfill: func(ctx context.Context, stock *models.Stock) error {
+				// The stored candle is only a valid predecessor if it is older than the batch
+				if !s.loaded {
+					latest, err := db.GetLastCandle(ctx, stock.Symbol)
+					if err != nil {
+						return fmt.Errorf("failed to fetch latest candle for %v: %w", stock.Symbol, err)
+					}
+
+					if latest.Close > 0 && latest.Timestamp.Before(s.candles[0].Timestamp) {
+						s.previous = &latest
+					}
+					s.loaded = true
+				}
+
+				// Recorded before validation, so that the price gaps of the ex-dates are exempted
+				actions := detectCorporateActions(s.previous, s.candles, s.prevCloses)
+				if err := db.SaveCorporateActions(ctx, actions); err != nil {
+					return fmt.Errorf("failed to save corporate actions for %v: %w", stock.Symbol, err)
+				}
+
+				last, err := ingestCandles(ctx, stock, s.previous, s.candles)
+				if err != nil {
+					return err
+				}
+
+				if last != nil {
+					s.previous = last
+				}
+				return nil
+			},
+		})
+	}
+
+	sort.Slice(jobs, func(i, j int) bool {
+		return jobs[i].stock.Symbol < jobs[j].stock.Symbol
+	})
+
+	from, to := batch[0].day.Format("2006-01-02"), batch[len(batch)-1].day.Format("2006-01-02")
+	return runIngestionJobs(ctx, jobs, fmt.Sprintf("Ingesting bhavcopies %v to %v...", from, to))
+}
+
+// ingestBhavcopyBatches ingests the bhavcopies in batches of constants.BhavcopyBatchDays
+// days, loading every bhavcopy of a batch with load. Candles are stored under the current
+// symbol of their ISIN in symbols. No further batch is started once ctx is done.
+func ingestBhavcopyBatches(
+	ctx context.Context,
+	days []time.Time,
+	symbols map[string]string,
+	load func(day time.Time) (bhavcopy, error),
+) error {
+	var (
+		stocks   = make(map[string]*bhavcopyStock)
+		failures = make([]ingestionFailure, 0)
+	)
+
+	for start := 0; start < len(days) && ctx.Err() == nil; start += constants.BhavcopyBatchDays {
+		end := min(start+constants.BhavcopyBatchDays, len(days))
+
+		batch := make([]bhavcopy, 0, end-start)
+		for _, day := range days[start:end] {
+			b, err := load(day)
+			if err != nil {
+				slog.Warn("skipping bhavcopy", "day", day.Format("2006-01-02"), "err", err)
+				continue
+			}
+			batch = append(batch, b)
+		}
+
+		if len(batch) > 0 {
+			failures = append(failures, ingestBhavcopies(ctx, batch, symbols, stocks)...)
+		}
+	}
+
+	logIngestionSummary(len(stocks), failures)
+	return ctx.Err()
+}
+
+// bhavcopyIngestor brings the database up to date from the daily NSE bhavcopies,
+// with one request per missing trading day instead of one per stock. The bhavcopy
+// of the last trading day has already been downloaded and is passed in latest, symbols
+// holds the current symbol of every listing by ISIN.
+// An empty database is filled from the start of the history window, but not before
+// constants.BhavcopyFirstDay, the first day published in the supported format.
+func bhavcopyIngestor(
+	ctx context.Context,
+	latest []models.NSEStockData,
+	lastTradingDay string,
+	symbols map[string]string,
+) error {
+	loc := calendar.NSE.Location()
+	lastDay, err := time.ParseInLocation("2006-01-02", lastTradingDay, loc)
+	if err != nil {
+		return fmt.Errorf("invalid last trading day %q: %w", lastTradingDay, err)
+	}
+
+	firstDay, err := time.ParseInLocation("2006-01-02", constants.BhavcopyFirstDay, loc)
+	if err != nil {
+		return fmt.Errorf("invalid first bhavcopy day %q: %w", constants.BhavcopyFirstDay, err)
+	}
+
+	_, last, err := db.GetStoredRange(ctx)
+	if err != nil {
+		return err
+	}
+
+	from := calendar.NSE.Day(utils.Now()).AddDate(0, 0, -config.Ingestion.HistoryDays)
+	if last != nil {
+		from = candleDay(*last).AddDate(0, 0, 1)
+	}
+
+	if from.Before(firstDay) {
+		slog.Warn(
+			"bhavcopies are not available for the whole history window",
+			"first_day", constants.BhavcopyFirstDay,
+			"older_history_source", constants.CandleSourceGroww,
+		)
+		from = firstDay
+	}
+
+	days := calendar.NSE.TradingDaysBetween(from, lastDay)
+	slog.Info("bhavcopies need ingestion", "days", len(days))
+
+	return ingestBhavcopyBatches(ctx, days, symbols, func(day time.Time) (bhavcopy, error) {
+		if day.Equal(lastDay) {
+			return bhavcopy{day: day, rows: latest}, nil
+		}
+
+		var rows []models.NSEStockData
+		err := withRetry(ctx, day.Format("2006-01-02"), func() error {
+			var err error
+			rows, err = api.DownloadBhavcopy(ctx, day)
+			return err
+		})
+		return bhavcopy{day: day, rows: rows}, err
+	})
+}
+
+// LoadBhavcopyDir ingests every bhavcopy zip file (as published by NSE) found in dir,
+// oldest trading day first. Files are matched by their content, not their name,
+// so renamed archives are fine.
+func LoadBhavcopyDir(ctx context.Context, dir string) error {
+	paths, err := filepath.Glob(filepath.Join(dir, "*.zip"))
+	if err != nil {
+		return fmt.Errorf("invalid bhavcopy directory %v: %w", dir, err)
+	}
+
+	// The trading day of a file is only known after parsing it
+	files := make(map[string]string)
+	days := make([]time.Time, 0, len(paths))
+	for _, path := range paths {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		b, err := readBhavcopyFile(path)
+		if err != nil {
+			slog.Warn("skipping bhavcopy file", "path", path, "err", err)
+			continue
+		}
+
+		key := b.day.Format("2006-01-02")
+		if _, ok := files[key]; ok {
+			slog.Warn("skipping bhavcopy file: another file holds the same day", "path", path, "day", key)
+			continue
+		}
+
+		files[key] = path
+		days = append(days, b.day)
+	}
+
+	if len(days) == 0 {
+		return fmt.Errorf("no bhavcopy found in %v", dir)
+	}
+
+	sort.Slice(days, func(i, j int) bool {
+		return days[i].Before(days[j])
+	})
+
+	listings, err := db.FetchListings(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to fetch listings: %w", err)
+	}
+
+	slog.Info("loading bhavcopies", "days", len(days), "dir", dir)
+	return ingestBhavcopyBatches(ctx, days, symbolsByISIN(listings), func(day time.Time) (bhavcopy, error) {
+		return readBhavcopyFile(files[day.Format("2006-01-02")])
+	})
+}
+
+// readBhavcopyFile parses a bhavcopy zip file from disk
+func readBhavcopyFile(path string) (bhavcopy, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return bhavcopy{}, err
+	}
+
+	rows, err := api.ParseBhavcopy(data)
+	if err != nil {
+		return bhavcopy{}, err
+	}
+
+	if len(rows) == 0 {
+		return bhavcopy{}, fmt.Errorf("bhavcopy is empty")
+	}
+
+	day, err := time.ParseInLocation("2006-01-02", strings.TrimSpace(rows[0].TradeDate), calendar.NSE.Location())
+	if err != nil {
+		return bhavcopy{}, fmt.Errorf("invalid trade date %q: %w", rows[0].TradeDate, err)
+	}
+
+	return bhavcopy{day: day, rows: rows}, nil
+}
@@ -0,0 +1,134 @@
+package dataflow
+
+import (
+	"eeye/src/models"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestDetectCorporateActions(t *testing.T) {
+	var (
+		day = func(d int) time.Time {
+			return time.Date(2025, 3, d, 0, 0, 0, 0, time.UTC)
+		}
+		candle = func(d int, closePrice float64) models.Candle {
+			return models.Candle{Symbol: "TCS", Timestamp: day(d), Close: closePrice}
+		}
+		stored = candle(3, 1000)
+	)
+
+	tests := []struct {
+		name       string
+		previous   *models.Candle
+		candles    []models.Candle
+		prevCloses []float64
+		want       []models.CorporateAction
+	}{
+		{
+			name:       "no adjustment",
+			previous:   &stored,
+			candles:    []models.Candle{candle(4, 1010), candle(5, 1020)},
+			prevCloses: []float64{1000, 1010},
+			want:       []models.CorporateAction{},
+		},
+		{
+			name:       "split adjusts the stored close",
+			previous:   &stored,
+			candles:    []models.Candle{candle(4, 505), candle(5, 510)},
+			prevCloses: []float64{500, 505},
+			want: []models.CorporateAction{
+				{Symbol: "TCS", ExDate: day(4), Action: "previous close adjusted from 1000 to 500"},
+			},
+		},
+		{
+			name:       "bonus within the batch",
+			previous:   &stored,
+			candles:    []models.Candle{candle(4, 1000), candle(5, 505)},
+			prevCloses: []float64{1000, 500},
+			want: []models.CorporateAction{
+				{Symbol: "TCS", ExDate: day(5), Action: "previous close adjusted from 1000 to 500"},
+			},
+		},
+		{
+			name:       "rounding within the tolerance",
+			previous:   &stored,
+			candles:    []models.Candle{candle(4, 1000)},
+			prevCloses: []float64{1005},
+			want:       []models.CorporateAction{},
+		},
+		{
+			name:       "no previous candle",
+			candles:    []models.Candle{candle(4, 505), candle(5, 510)},
+			prevCloses: []float64{1000, 505},
+			want:       []models.CorporateAction{},
+		},
+		{
+			name:       "previous close not published",
+			previous:   &stored,
+			candles:    []models.Candle{candle(4, 505)},
+			prevCloses: []float64{0},
+			want:       []models.CorporateAction{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := detectCorporateActions(tt.previous, tt.candles, tt.prevCloses)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("detectCorporateActions() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCollectBhavcopyCandles(t *testing.T) {
+	var (
+		day = time.Date(2025, 3, 20, 0, 0, 0, 0, time.UTC)
+		row = func(isin, symbol, series string, closePrice float64) models.NSEStockData {
+			return models.NSEStockData{
+				ISIN:           isin,
+				Symbol:         symbol,
+				Series:         series,
+				Segment:        "CM",
+				InstrumentType: "STK",
+				TradeDate:      "2025-03-20",
+				Open:           closePrice,
+				High:           closePrice,
+				Low:            closePrice,
+				Close:          closePrice,
+			}
+		}
+	)
+
+	batch := []bhavcopy{{
+		day: day,
+		rows: []models.NSEStockData{
+			row("INE000A01001", "TCS", "EQ", 100),
+			row("INE000A01001", "TCS", "BE", 101),
+			row("INE000A01002", "SUZLON", "BE", 200),
+			row("INE000A01002", "SUZLON", "EQ", 201),
+			row("INE000A01003", "ZOMATO", "BE", 300),
+			row("INE000A01003", "ZOMATO", "BZ", 301),
+			row("INF000A01004", "NIFTYBEES", "EQ", 400),
+		},
+	}}
+
+	stocks := make(map[string]*bhavcopyStock)
+	collectBhavcopyCandles(batch, map[string]string{"INE000A01003": "ETERNAL"}, stocks)
+
+	want := map[string]float64{"TCS": 100, "SUZLON": 201, "ETERNAL": 301}
+	if len(stocks) != len(want) {
+		t.Fatalf("collectBhavcopyCandles() collected %d stocks, want %d", len(stocks), len(want))
+	}
+	for symbol, closePrice := range want {
+		s, ok := stocks[symbol]
+		if !ok {
+			t.Errorf("collectBhavcopyCandles() did not collect %v", symbol)
+			continue
+		}
+		if len(s.candles) != 1 || s.candles[0].Close != closePrice || len(s.prevCloses) != 1 {
+			t.Errorf("collectBhavcopyCandles() %v candles = %+v, want one candle closing at %v", symbol, s.candles, closePrice)
+		}
+	}
+}
@@ -0,0 +1,125 @@
+package dataflow
+
+import (
+	"context"
+	"eeye/src/api"
+	"eeye/src/calendar"
+	"eeye/src/db"
+	"eeye/src/models"
+	"eeye/src/quality"
+	"eeye/src/utils"
+	"fmt"
+	"log/slog"
+	"time"
+)
+
+// dateRange is a half-open range of days [from, to)
+type dateRange struct {
+	from time.Time
+	to   time.Time
+}
+
+// splitRange splits [from, to) into consecutive ranges of at most maxDays days,
+// oldest first, so that every request stays within the provider's range cap.
+func splitRange(from time.Time, to time.Time, maxDays int) []dateRange {
+	ranges := make([]dateRange, 0)
+	for start := from; start.Before(to); {
+		end := start.AddDate(0, 0, maxDays)
+		if end.After(to) {
+			end = to
+		}
+
+		ranges = append(ranges, dateRange{from: start, to: end})
+		start = end
+	}
+
+	return ranges
+}
+
+// candleDay returns the trading day of a candle in the calendar's location.
+// Candles read from the database carry the wall clock of EEYE_TZ in UTC.
+func candleDay(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, calendar.NSE.Location())
+}
+
+// fetchRange fetches the candles of a stock for a single range and drops candles
+// of days already returned by a previous range (provider ranges may overlap at
+// the boundaries). seen is updated with the days of the returned candles.
+func fetchRange(ctx context.Context, stock *models.Stock, r dateRange, seen map[string]struct{}) ([]models.Candle, error) {
+	candles, err := api.GetCandles(
+		ctx,
+		stock,
+		utils.GetFormattedTimestamp(r.from),
+		utils.GetFormattedTimestamp(r.to),
+	)
+	if err != nil {
+		return candles, fmt.Errorf("failed to fetch candles for %v: %w", stock.Symbol, err)
+	}
+
+	return dropSeen(candles, seen), nil
+}
+
+// dropSeen returns the candles whose day is not in seen, keeping the first candle of
+// every day, and adds their days to seen
+func dropSeen(candles []models.Candle, seen map[string]struct{}) []models.Candle {
+	unique := make([]models.Candle, 0, len(candles))
+	for i := range candles {
+		key := candleDay(candles[i].Timestamp).Format("2006-01-02")
+		if _, ok := seen[key]; ok {
+			continue
+		}
+
+		seen[key] = struct{}{}
+		unique = append(unique, candles[i])
+	}
+
+	return unique
+}
+
+// ingestCandles validates a batch of candles against the previously stored candle
+// (nil if unknown), records the data quality issues and upserts the accepted candles.
+//
+// Returns:
+//   - The last accepted candle, or nil if no candle was accepted
+//   - Error if anything could not be stored
+func ingestCandles(
+	ctx context.Context,
+	stock *models.Stock,
+	previous *models.Candle,
+	newCandles []models.Candle,
+) (*models.Candle, error) {
+	if len(newCandles) == 0 {
+		return nil, nil
+	}
+
+	actions, err := db.FetchCorporateActions(ctx, stock.Symbol, newCandles[0].Timestamp.AddDate(0, 0, -1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch corporate actions for %v: %w", stock.Symbol, err)
+	}
+
+	// Quarantine bad candles so that strategies are not triggered by bad ticks
+	candles, issues := quality.Validate(previous, newCandles, actions)
+	if len(issues) > 0 {
+		slog.Warn(
+			"data quality issues",
+			"symbol", stock.Symbol,
+			"issues", len(issues),
+			"accepted", len(candles),
+			"candles", len(newCandles),
+		)
+	}
+
+	if err = db.SaveCandleIssues(ctx, issues); err != nil {
+		return nil, fmt.Errorf("failed to save data quality issues for %v: %w", stock.Symbol, err)
+	}
+
+	if err = db.BackfillCandles(ctx, stock, candles); err != nil {
+		return nil, fmt.Errorf("failed to ingest data for %v: %w", stock.Symbol, err)
+	}
+
+	if len(candles) == 0 {
+		return nil, nil
+	}
+
+	return &candles[len(candles)-1], nil
+}
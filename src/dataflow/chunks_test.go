@@ -0,0 +1,117 @@
+package dataflow
+
+import (
+	"eeye/src/models"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestSplitRange(t *testing.T) {
+	day := func(d int) time.Time {
+		return time.Date(2025, time.January, d, 0, 0, 0, 0, time.UTC)
+	}
+
+	tests := []struct {
+		name    string
+		from    time.Time
+		to      time.Time
+		maxDays int
+		// want holds the first and last (excluded) day of every range
+		want [][2]time.Time
+	}{
+		{name: "shorter than a chunk", from: day(1), to: day(10), maxDays: 30, want: [][2]time.Time{{day(1), day(10)}}},
+		{name: "exactly one chunk", from: day(1), to: day(11), maxDays: 10, want: [][2]time.Time{{day(1), day(11)}}},
+		{
+			name:    "exact multiple",
+			from:    day(1),
+			to:      day(31),
+			maxDays: 10,
+			want:    [][2]time.Time{{day(1), day(11)}, {day(11), day(21)}, {day(21), day(31)}},
+		},
+		{
+			name:    "remainder",
+			from:    day(1),
+			to:      day(26),
+			maxDays: 10,
+			want:    [][2]time.Time{{day(1), day(11)}, {day(11), day(21)}, {day(21), day(26)}},
+		},
+		{name: "single day", from: day(5), to: day(6), maxDays: 10, want: [][2]time.Time{{day(5), day(6)}}},
+		{name: "empty", from: day(5), to: day(5), maxDays: 10, want: [][2]time.Time{}},
+		{name: "reversed", from: day(6), to: day(5), maxDays: 10, want: [][2]time.Time{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := make([][2]time.Time, 0)
+			for _, r := range splitRange(tt.from, tt.to, tt.maxDays) {
+				got = append(got, [2]time.Time{r.from, r.to})
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("splitRange() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDropSeen(t *testing.T) {
+	candle := func(d int, hour int, closePrice float64) models.Candle {
+		return models.Candle{
+			Symbol:    "TCS",
+			Timestamp: time.Date(2025, time.January, d, hour, 0, 0, 0, time.UTC),
+			Close:     closePrice,
+		}
+	}
+
+	tests := []struct {
+		name   string
+		chunks [][]models.Candle
+		// want holds the closes of the candles kept from every chunk
+		want [][]float64
+	}{
+		{
+			name:   "distinct days",
+			chunks: [][]models.Candle{{candle(1, 0, 1), candle(2, 0, 2)}, {candle(3, 0, 3)}},
+			want:   [][]float64{{1, 2}, {3}},
+		},
+		{
+			name:   "day repeated at the chunk edge",
+			chunks: [][]models.Candle{{candle(1, 0, 1), candle(2, 0, 2)}, {candle(2, 0, 20), candle(3, 0, 3)}},
+			want:   [][]float64{{1, 2}, {3}},
+		},
+		{
+			name:   "same day at another time",
+			chunks: [][]models.Candle{{candle(1, 0, 1), candle(2, 0, 2)}, {candle(2, 9, 20), candle(3, 9, 3)}},
+			want:   [][]float64{{1, 2}, {3}},
+		},
+		{
+			name:   "duplicate within a chunk keeps the first",
+			chunks: [][]models.Candle{{candle(1, 0, 1), candle(1, 0, 10), candle(2, 0, 2)}},
+			want:   [][]float64{{1, 2}},
+		},
+		{
+			name:   "chunk entirely seen",
+			chunks: [][]models.Candle{{candle(1, 0, 1), candle(2, 0, 2)}, {candle(2, 0, 20)}},
+			want:   [][]float64{{1, 2}, {}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var (
+				seen = make(map[string]struct{})
+				got  = make([][]float64, 0, len(tt.chunks))
+			)
+			for _, chunk := range tt.chunks {
+				closes := make([]float64, 0, len(chunk))
+				for _, c := range dropSeen(chunk, seen) {
+					closes = append(closes, c.Close)
+				}
+				got = append(got, closes)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("dropSeen() kept %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,117 @@
+package dataflow
+
+import (
+	"context"
+	"eeye/src/calendar"
+	"eeye/src/config"
+	"eeye/src/db"
+	"eeye/src/models"
+	"eeye/src/utils"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+)
+
+// listedSymbols returns the symbols traded in the capital market segment of a bhavcopy,
+// in any series: a stock moved to another series (e.g. BE) is still listed
+func listedSymbols(rows []models.NSEStockData) map[string]struct{} {
+	res := make(map[string]struct{}, len(rows))
+	for i := range rows {
+		if strings.TrimSpace(rows[i].Segment) == "CM" {
+			res[strings.TrimSpace(rows[i].Symbol)] = struct{}{}
+		}
+	}
+	return res
+}
+
+// splitDelisted separates the stale stocks missing from the bhavcopy (delisted) from
+// those still listed, whose ingestion is most likely failing
+func splitDelisted(
+	stale []models.DelistedStock,
+	listed map[string]struct{},
+) ([]models.DelistedStock, []models.DelistedStock) {
+	var (
+		delisted    = utils.EmptySlice[models.DelistedStock]()
+		stillListed = utils.EmptySlice[models.DelistedStock]()
+	)
+	for i := range stale {
+		if _, ok := listed[stale[i].Symbol]; ok {
+			stillListed = append(stillListed, stale[i])
+		} else {
+			delisted = append(delisted, stale[i])
+		}
+	}
+	return delisted, stillListed
+}
+
+// delistCutoff returns the last session and the oldest session a delisted stock has no
+// candle on: the stock has to be missing on the cutoff session and every session after it
+func delistCutoff() (time.Time, time.Time) {
+	lastSession := calendar.NSE.LastSession(utils.Now())
+	return lastSession, calendar.NSE.AddTradingDays(lastSession, 1-config.Cleanup.DelistAfterSessions)
+}
+
+// FindDelistedStocks returns the stored stocks which are no longer listed on NSE. A stock
+// is delisted only if it is missing from the latest bhavcopy and has no candle for the last
+// config.Cleanup.DelistAfterSessions trading sessions, so that neither a failed ingestion
+// nor a short suspension deletes a live stock. db.ErrNotSynced is returned if the
+// database is not synced up to the last session.
+func FindDelistedStocks(ctx context.Context) ([]models.DelistedStock, error) {
+	_, rows, _, err := fetchLatestStocksFromNSE(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch the latest bhavcopy: %w", err)
+	}
+
+	listed := listedSymbols(rows)
+	if len(listed) == 0 {
+		return nil, errors.New("the latest bhavcopy lists no stocks")
+	}
+
+	lastSession, cutoff := delistCutoff()
+	stale, err := db.FetchStaleStocks(ctx, lastSession, cutoff)
+	if err != nil {
+		return nil, err
+	}
+
+	delisted, stillListed := splitDelisted(stale, listed)
+	for i := range stillListed {
+		slog.Warn(
+			"stock is listed but has no recent candles, skipping cleanup",
+			"symbol", stillListed[i].Symbol,
+			"last_candle", stillListed[i].Range.Last.Format("2006-01-02"),
+		)
+	}
+
+	slog.Info(
+		"delisted stocks found",
+		"delisted", len(delisted),
+		"stale_but_listed", len(stillListed),
+		"since", cutoff.Format("2006-01-02"),
+	)
+	return delisted, nil
+}
+
+// ArchiveDelistedStocks moves the candles of the delisted stocks to the archive table and
+// returns the number of archived candles. A stock which received candles since it was found
+// delisted is skipped.
+func ArchiveDelistedStocks(ctx context.Context, delisted []models.DelistedStock) (int64, error) {
+	if len(delisted) == 0 {
+		return 0, nil
+	}
+
+	symbols := make([]string, 0, len(delisted))
+	for i := range delisted {
+		symbols = append(symbols, delisted[i].Symbol)
+	}
+
+	_, cutoff := delistCutoff()
+	rows, err := db.ArchiveStocks(ctx, symbols, cutoff)
+	if err != nil {
+		return 0, err
+	}
+
+	slog.Info("delisted stocks archived", "stocks", len(symbols), "candles", rows)
+	return rows, nil
+}
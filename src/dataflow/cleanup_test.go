@@ -0,0 +1,39 @@
+package dataflow
+
+import (
+	"eeye/src/models"
+	"reflect"
+	"testing"
+)
+
+func TestListedSymbols(t *testing.T) {
+	rows := []models.NSEStockData{
+		{Symbol: "TCS", Segment: "CM", Series: "EQ"},
+		{Symbol: " SUZLON ", Segment: "CM", Series: "BE"},
+		{Symbol: "NIFTY", Segment: "FO", Series: ""},
+	}
+
+	want := map[string]struct{}{"TCS": {}, "SUZLON": {}}
+	if got := listedSymbols(rows); !reflect.DeepEqual(got, want) {
+		t.Errorf("listedSymbols() = %v, want %v", got, want)
+	}
+}
+
+func TestSplitDelisted(t *testing.T) {
+	var (
+		stale = []models.DelistedStock{
+			{Symbol: "OLD"},
+			{Symbol: "TCS"},
+			{Symbol: "GONE"},
+		}
+		listed = map[string]struct{}{"TCS": {}, "INFY": {}}
+	)
+
+	delisted, stillListed := splitDelisted(stale, listed)
+	if want := []models.DelistedStock{{Symbol: "OLD"}, {Symbol: "GONE"}}; !reflect.DeepEqual(delisted, want) {
+		t.Errorf("delisted = %v, want %v", delisted, want)
+	}
+	if want := []models.DelistedStock{{Symbol: "TCS"}}; !reflect.DeepEqual(stillListed, want) {
+		t.Errorf("stillListed = %v, want %v", stillListed, want)
+	}
+}
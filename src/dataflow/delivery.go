@@ -0,0 +1,66 @@
+package dataflow
+
+import (
+	"context"
+	"eeye/src/api"
+	"eeye/src/calendar"
+	"eeye/src/config"
+	"eeye/src/db"
+	"eeye/src/models"
+	"eeye/src/utils"
+	"fmt"
+	"log/slog"
+	"strings"
+)
+
+// syncDelivery fetches the NSE security-wise delivery files of the recent days
+// (config.Ingestion.DeliveryDays) whose candles have no delivery data yet and stores
+// the delivery quantity, delivery percentage and turnover on those candles.
+// A day without a published file is skipped and tried again on the next run.
+func syncDelivery(ctx context.Context) error {
+	if config.Ingestion.DeliveryDays == 0 {
+		return nil
+	}
+
+	from := calendar.NSE.Day(utils.Now()).AddDate(0, 0, -config.Ingestion.DeliveryDays)
+	days, err := db.FetchDaysMissingDelivery(ctx, from)
+	if err != nil {
+		return fmt.Errorf("failed to fetch days missing delivery data: %w", err)
+	}
+
+	slog.Info("days need delivery data", "days", len(days))
+	for _, day := range days {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		key := day.Format("2006-01-02")
+
+		var rows []models.NSEDeliveryData
+		err := withRetry(ctx, key, func() error {
+			var err error
+			rows, err = api.DownloadDeliveryData(ctx, day)
+			return err
+		})
+		if err != nil {
+			slog.Warn("skipping delivery data", "day", key, "err", err)
+			continue
+		}
+
+		// A symbol can be listed in several series, candles are only kept for the listed ones
+		equity := make([]models.NSEDeliveryData, 0, len(rows))
+		for i := range rows {
+			if _, ok := listedSeries[strings.TrimSpace(rows[i].Series)]; ok {
+				equity = append(equity, rows[i])
+			}
+		}
+
+		updated, err := db.UpdateDelivery(ctx, key, equity)
+		if err != nil {
+			return fmt.Errorf("failed to store delivery data of %v: %w", key, err)
+		}
+		slog.Info("stored delivery data", "day", key, "candles", updated)
+	}
+
+	return nil
+}
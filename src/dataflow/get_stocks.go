@@ -1,32 +1,39 @@
 // Package dataflow helps in fetching stocks data from NSE
-// and fetching latest candles for each stock from Groww
+// and fetching latest candles for each stock from Groww or the NSE bhavcopy
 package dataflow
 
 import (
+	"context"
 	"eeye/src/api"
+	"eeye/src/config"
+	"eeye/src/constants"
+	"eeye/src/db"
 	"eeye/src/models"
 	"eeye/src/utils"
-	"log"
+	"errors"
+	"log/slog"
 	"strings"
+	"time"
 )
 
 // fetchLatestStocksFromNSE fetches latest available stocks from NSE
-func fetchLatestStocksFromNSE() ([]models.Stock, string, error) {
-	log.Printf("fetching data from NSE")
-	stocks, lastTradingDay, err := api.DownloadLatestBhavcopy()
+// along with the raw bhavcopy rows of the last trading day
+func fetchLatestStocksFromNSE(ctx context.Context) ([]models.Stock, []models.NSEStockData, string, error) {
+	slog.Info("fetching data from NSE")
+	stocks, lastTradingDay, err := api.DownloadLatestBhavcopy(ctx)
 	empty := utils.EmptySlice[models.Stock]()
 	if err != nil {
-		return empty, "", err
+		return empty, stocks, "", err
 	}
 
-	filtered := make([]models.Stock, 0, len(stocks))
+	var (
+		filtered = make([]models.Stock, 0, len(stocks))
+		seen     = make(map[string]struct{}, len(stocks))
+	)
 	for _, s := range stocks {
-		// Filter for equity stocks in capital market segment
-		// Exclude ETFs by checking ISIN prefix (stocks start with INE, ETFs with INF)
-		if strings.TrimSpace(s.Segment) == "CM" &&
-			strings.TrimSpace(s.InstrumentType) == "STK" &&
-			strings.TrimSpace(s.Series) == "EQ" &&
-			strings.HasPrefix(strings.TrimSpace(s.ISIN), "INE") {
+		// Filter for equity stocks in capital market segment, once even if listed in several series
+		if _, ok := seen[strings.TrimSpace(s.Symbol)]; !ok && isListedEquity(&s) {
+			seen[strings.TrimSpace(s.Symbol)] = struct{}{}
 			filtered = append(filtered, models.Stock{
 				Symbol:   strings.TrimSpace(s.Symbol),
 				Name:     strings.TrimSpace(s.Name),
@@ -36,18 +43,62 @@ func fetchLatestStocksFromNSE() ([]models.Stock, string, error) {
 		}
 	}
 
-	log.Printf("fetched %d stocks from NSE\n", len(filtered))
-	return filtered, lastTradingDay, nil
+	slog.Info("fetched stocks from NSE", "stocks", len(filtered))
+	return filtered, stocks, lastTradingDay, nil
 }
 
-// GetStocks retrieves the list of available stocks from an external source or
-// fallbacks to distinct stock symbols in the database
-func GetStocks() []models.Stock {
-	stocks, lastTradingDay, err := fetchLatestStocksFromNSE()
+// GetStocks retrieves the list of available stocks from an external source and
+// brings their historical data in the database up to date from the source
+// configured in config.Ingestion.Source. If ctx is cancelled, in-flight requests and
+// queries are aborted and the context's error is returned.
+func GetStocks(ctx context.Context) ([]models.Stock, error) {
+	stocks, bhavcopy, lastTradingDay, err := fetchLatestStocksFromNSE(ctx)
 	if err != nil {
-		log.Fatal(err)
+		return stocks, err
 	}
 
-	ingestor(stocks, lastTradingDay)
-	return stocks
+	// Renamed stocks get their history moved before the new symbol is ingested
+	symbols, err := syncListings(ctx, bhavcopy, lastTradingDay)
+	if err != nil {
+		return stocks, err
+	}
+
+	if config.Ingestion.Source == constants.CandleSourceBhavcopy {
+		err = bhavcopyIngestor(ctx, bhavcopy, lastTradingDay, symbols)
+	} else {
+		err = ingestor(ctx, stocks, lastTradingDay)
+	}
+
+	if err != nil {
+		return stocks, err
+	}
+
+	// Delivery data is published separately; screening works without it
+	if err := syncDelivery(ctx); err != nil {
+		slog.Error("delivery data sync failed", "err", err)
+	}
+
+	if ctx.Err() != nil {
+		return stocks, ctx.Err()
+	}
+
+	// Compression and retention are background jobs; a failure only affects storage
+	if err := db.SyncStoragePolicies(ctx); err != nil {
+		slog.Error("storage policy sync failed", "err", err)
+	}
+
+	return stocks, nil
+}
+
+// LatestSession returns the trading day of the latest stored candle, which is the day
+// GetStocks last ingested rather than the last session of the wall clock
+func LatestSession(ctx context.Context) (time.Time, error) {
+	_, last, err := db.GetStoredRange(ctx)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if last == nil {
+		return time.Time{}, errors.New("no candles stored")
+	}
+	return candleDay(*last), nil
 }
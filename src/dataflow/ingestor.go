@@ -1,72 +1,222 @@
 package dataflow
 
 import (
-	"eeye/src/api"
+	"context"
+	"eeye/src/calendar"
 	"eeye/src/config"
-	"eeye/src/constants"
 	"eeye/src/db"
+	"eeye/src/metrics"
 	"eeye/src/models"
 	"eeye/src/utils"
 	"fmt"
-	"log"
+	"log/slog"
+	"sort"
 	"sync"
-	"time"
 
 	progressbar "github.com/schollz/progressbar/v3"
 )
 
 // backFillCandles fetches and stores new candle data for a stock starting from the day
-// after the latest candle present in the database up to the current day. It ensures
-// that only new data is fetched to avoid duplicates and minimize API calls.
-func backFillCandles(stock *models.Stock) error {
-	latestCandle, err := db.GetLastCandle(stock.Symbol)
+// of the latest candle present in the database up to the last completed trading
+// session. The latest stored day is fetched again so that late corrections by the
+// provider are picked up; the upsert in the database makes the overlap harmless.
+//
+// The range is requested in chunks of at most config.Groww.MaxRangeDays days, oldest
+// first, and every chunk is stored as soon as it arrives. If a chunk fails, the next
+// attempt resumes from the last stored candle instead of starting over.
+func backFillCandles(ctx context.Context, stock *models.Stock) error {
+	latestCandle, err := db.GetLastCandle(ctx, stock.Symbol)
 	if err != nil {
 		return fmt.Errorf("failed to fetch latest candle for %v: %w", stock.Symbol, err)
 	}
 
-	startOfDayPlusOne := func(t time.Time) time.Time {
-		return t.UTC().Truncate(24*time.Hour).AddDate(0, 0, 1)
+	var (
+		isNewListing = latestCandle.Close == 0
+		from         = candleDay(latestCandle.Timestamp)
+		to           = calendar.NSE.LastSession(utils.Now()).AddDate(0, 0, 1)
+		previous     = &latestCandle
+		seen         = make(map[string]struct{})
+	)
+
+	for _, r := range splitRange(from, to, config.Groww.MaxRangeDays) {
+		candles, err := fetchRange(ctx, stock, r, seen)
+		if err != nil {
+			return err
+		}
+
+		last, err := ingestCandles(ctx, stock, previous, candles)
+		if err != nil {
+			return err
+		}
+
+		if last != nil {
+			previous = last
+		}
+	}
+
+	// A new listing was fetched from the start of the history window
+	if isNewListing {
+		if err := db.SetHistoryFrom(ctx, stock.Symbol, from); err != nil {
+			return fmt.Errorf("failed to record history start for %v: %w", stock.Symbol, err)
+		}
+	}
+
+	return nil
+}
+
+// backFillHistory extends the stored history of a stock back to the start of the
+// configured history window (config.Ingestion.HistoryDays). Chunks are fetched newest
+// first, right before the earliest stored candle, so that an interrupted run resumes
+// from the oldest stored chunk. Once the window is covered it is recorded in the
+// ingestion status table and the stock is not considered again.
+func backFillHistory(ctx context.Context, stock *models.Stock) error {
+	firstCandle, err := db.GetFirstCandle(ctx, stock.Symbol)
+	if err != nil {
+		return fmt.Errorf("failed to fetch first candle for %v: %w", stock.Symbol, err)
 	}
 
 	var (
-		start = utils.GetFormattedTimestamp(startOfDayPlusOne(latestCandle.Timestamp))
-		end   = utils.GetFormattedTimestamp(startOfDayPlusOne(time.Now()))
+		from   = calendar.NSE.Day(utils.Now()).AddDate(0, 0, -config.Ingestion.HistoryDays)
+		to     = candleDay(firstCandle.Timestamp)
+		ranges = splitRange(from, to, config.Groww.MaxRangeDays)
+		seen   = make(map[string]struct{})
 	)
 
-	newCandles, err := api.GetCandles(stock, start, end)
-	if err != nil {
-		return fmt.Errorf("failed to fetch latest candles for %v: %w", stock.Symbol, err)
+	slog.Info("extending history", "symbol", stock.Symbol, "from", from.Format("2006-01-02"), "to", to.Format("2006-01-02"))
+	for i := len(ranges) - 1; i >= 0; i-- {
+		candles, err := fetchRange(ctx, stock, ranges[i], seen)
+		if err != nil {
+			return err
+		}
+
+		if _, err := ingestCandles(ctx, stock, nil, candles); err != nil {
+			return err
+		}
 	}
 
-	if err = db.BackfillCandles(stock, newCandles); err != nil {
-		return fmt.Errorf("failed to ingest data for %v: %w", stock.Symbol, err)
+	if err := db.SetHistoryFrom(ctx, stock.Symbol, from); err != nil {
+		return fmt.Errorf("failed to record history start for %v: %w", stock.Symbol, err)
 	}
 
 	return nil
 }
 
+// ingestionJob is a unit of work for the ingestion workers
+type ingestionJob struct {
+	// stock is the stock to ingest
+	stock *models.Stock
+
+	// backfill fetches and stores the missing candles of the stock
+	backfill func(ctx context.Context, stock *models.Stock) error
+}
+
+// ingestionFailure is a stock whose backfill still failed after all retries
+type ingestionFailure struct {
+	symbol string
+	err    error
+}
+
 // ingestionWorker processes stocks from the input channel and backfills their candle data.
-func ingestionWorker(in <-chan *models.Stock, bar *progressbar.ProgressBar) {
-	for stock := range in {
-		if err := backFillCandles(stock); err != nil {
-			log.Printf("ingestion failed for %v: %v\n", stock.Symbol, err)
+// Transient failures are retried with backoff, the outcome of every stock is recorded
+// in the ingestion status table and stocks that still failed are sent to failed.
+// Once ctx is done, the remaining jobs are drained without being processed and
+// interrupted stocks are not recorded as failures.
+func ingestionWorker(ctx context.Context, in chan ingestionJob, failed chan<- ingestionFailure, bar *progressbar.ProgressBar) {
+	for job := range in {
+		metrics.IngestionQueueDepth.Set(float64(len(in)))
+
+		if ctx.Err() != nil {
+			continue
+		}
+
+		stock := job.stock
+		err := withRetry(ctx, stock.Symbol, func() error {
+			return job.backfill(ctx, stock)
+		})
+		if ctx.Err() != nil {
+			slog.Info("ingestion interrupted", "symbol", stock.Symbol)
+			continue
+		}
+
+		if err != nil {
+			slog.Error("ingestion failed", "symbol", stock.Symbol, "err", err)
+			failed <- ingestionFailure{symbol: stock.Symbol, err: err}
+			metrics.StocksIngested.Inc("failed")
+		} else {
+			metrics.StocksIngested.Inc("ok")
+		}
+
+		if statusErr := db.RecordIngestionAttempt(ctx, stock.Symbol, err); statusErr != nil {
+			slog.Warn("failed to record ingestion status", "symbol", stock.Symbol, "err", statusErr)
 		}
 		_ = bar.Add(1)
 	}
 }
 
+// logIngestionSummary logs the number of ingested stocks and lists the ones that failed
+func logIngestionSummary(total int, failures []ingestionFailure) {
+	slog.Info("ingestion summary", "total", total, "succeeded", total-len(failures), "failed", len(failures))
+	if len(failures) == 0 {
+		return
+	}
+
+	sort.Slice(failures, func(i, j int) bool {
+		return failures[i].symbol < failures[j].symbol
+	})
+
+	for i := range failures {
+		slog.Warn("stock failed ingestion", "symbol", failures[i].symbol, "err", failures[i].err)
+	}
+}
+
+// runIngestionJobs runs the jobs on a pool of ingestion workers and returns the
+// jobs that still failed after retries. If ctx is done, no further jobs are started.
+func runIngestionJobs(ctx context.Context, jobs []ingestionJob, description string) []ingestionFailure {
+	var (
+		in = make(chan ingestionJob, config.Workers.IngestionQueueSize)
+		wg = sync.WaitGroup{}
+	)
+
+	// Buffered for every job so that workers never block on reporting a failure
+	failed := make(chan ingestionFailure, len(jobs))
+	bar := utils.GetProgressTracker(len(jobs), description)
+	for range config.Workers.IngestionWorkers {
+		wg.Go(func() {
+			ingestionWorker(ctx, in, failed, bar)
+		})
+	}
+
+	// Requests are rate limited inside the api package, so stocks can be fed as fast
+	// as the workers accept them
+	for i := range jobs {
+		if ctx.Err() != nil {
+			break
+		}
+		in <- jobs[i]
+	}
+	close(in)
+
+	wg.Wait()
+	close(failed)
+
+	failures := make([]ingestionFailure, 0, len(failed))
+	for f := range failed {
+		failures = append(failures, f)
+	}
+
+	return failures
+}
+
 // ingestor updates the historical price data for a stock by fetching new candles
 // from the API and storing them in the database. It only fetches data newer than
 // the most recent candle in the database to avoid duplicates and minimize API calls.
-func ingestor(stocks []models.Stock, lastTradingDay string) {
-	currentStocks, err := db.FetchAllStocks()
+func ingestor(ctx context.Context, stocks []models.Stock, lastTradingDay string) error {
+	currentStocks, err := db.FetchAllStocks(ctx)
 	if err != nil {
-		log.Fatal(err)
+		return err
 	}
 
 	var (
-		in                    = make(chan *models.Stock, constants.IngestionBufferSize)
-		wg                    = sync.WaitGroup{}
 		currentStocksSet      = make(map[string]struct{})
 		fetchedStocksSet      = make(map[string]struct{})
 		stocksNeedingBackfill = make([]*models.Stock, 0)
@@ -91,39 +241,34 @@ func ingestor(stocks []models.Stock, lastTradingDay string) {
 	}
 
 	// from db get those stocks whose needs backfilling
-	outOfSyncStocks, err := db.FetchOutOfSyncStock(lastTradingDay)
+	outOfSyncStocks, err := db.FetchOutOfSyncStock(ctx, lastTradingDay)
 	if err != nil {
-		log.Fatal(err)
+		return err
 	}
 
 	for i := range outOfSyncStocks {
 		stocksNeedingBackfill = append(stocksNeedingBackfill, &outOfSyncStocks[i])
 	}
 
-	bar := utils.GetProgressTracker(len(stocksNeedingBackfill), "Ingesting most recent data...")
-	for range constants.NumOfIngestionWorkers {
-		wg.Go(func() {
-			ingestionWorker(in, bar)
-		})
+	// stocks whose stored history does not reach back to the configured window
+	historyFrom := calendar.NSE.Day(utils.Now()).AddDate(0, 0, -config.Ingestion.HistoryDays)
+	stocksNeedingHistory, err := db.FetchStocksMissingHistory(ctx, historyFrom)
+	if err != nil {
+		return err
 	}
 
-	log.Printf("%v stocks need backfilling\n", len(stocksNeedingBackfill))
-	start := time.Now()
+	jobs := make([]ingestionJob, 0, len(stocksNeedingBackfill)+len(stocksNeedingHistory))
 	for i := range stocksNeedingBackfill {
-		in <- stocksNeedingBackfill[i]
-
-		// Make parallel calls and then sleep for the remaining time left in the second
-		if (i+1)%config.Groww.RequestPerSecond == 0 {
-			elapsed := time.Since(start)
-
-			if rem := time.Second - elapsed; rem > 0 {
-				time.Sleep(rem)
-			}
+		jobs = append(jobs, ingestionJob{stock: stocksNeedingBackfill[i], backfill: backFillCandles})
+	}
 
-			start = time.Now()
-		}
+	for i := range stocksNeedingHistory {
+		jobs = append(jobs, ingestionJob{stock: &stocksNeedingHistory[i], backfill: backFillHistory})
 	}
-	close(in)
 
-	wg.Wait()
+	slog.Info("stocks need ingestion", "backfill", len(stocksNeedingBackfill), "older_history", len(stocksNeedingHistory))
+	failures := runIngestionJobs(ctx, jobs, "Ingesting most recent data...")
+	logIngestionSummary(len(jobs), failures)
+
+	return ctx.Err()
 }
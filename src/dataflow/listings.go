@@ -0,0 +1,196 @@
+package dataflow
+
+import (
+	"context"
+	"eeye/src/calendar"
+	"eeye/src/db"
+	"eeye/src/models"
+	"fmt"
+	"log/slog"
+	"sort"
+	"strings"
+	"time"
+)
+
+// listedSeries maps the series in which stocks are ingested to the status of their
+// listing. Stocks in BE and BZ trade for trade only, but still have daily candles.
+var listedSeries = map[string]string{
+	"EQ": models.ListingActive,
+	"BE": models.ListingRestricted,
+	"BZ": models.ListingRestricted,
+}
+
+// isPreferredSeries reports whether a stock found in several series on the same day
+// (e.g. while moving between EQ and BE) is kept in series rather than in another one
+func isPreferredSeries(series string) bool {
+	return strings.TrimSpace(series) == "EQ"
+}
+
+// listingRows returns the bhavcopy row of every listed equity by ISIN. A stock found in
+// several series on the same day is kept in EQ.
+func listingRows(rows []models.NSEStockData) map[string]*models.NSEStockData {
+	res := make(map[string]*models.NSEStockData, len(rows))
+	for i := range rows {
+		row := &rows[i]
+		if !isListedEquity(row) {
+			continue
+		}
+
+		isin := strings.TrimSpace(row.ISIN)
+		if prev, ok := res[isin]; ok && isPreferredSeries(prev.Series) {
+			continue
+		}
+		res[isin] = row
+	}
+	return res
+}
+
+// diffListings compares the known listings with the bhavcopy of day and returns the
+// listings to store along with the detected events:
+//   - a known ISIN traded under another symbol is renamed
+//   - a new ISIN traded under the symbol of a known ISIN missing from the bhavcopy
+//     replaces it (e.g. on a change of the face value); the symbol keeps its history
+//   - a known ISIN missing from the bhavcopy is suspended until it trades again
+//     or the cleanup delists it
+func diffListings(
+	known []models.Listing,
+	rows []models.NSEStockData,
+	day time.Time,
+) ([]models.Listing, []models.ListingEvent) {
+	var (
+		current  = listingRows(rows)
+		byISIN   = make(map[string]*models.Listing, len(known))
+		bySymbol = make(map[string]*models.Listing, len(known))
+		listings = make([]models.Listing, 0, len(current))
+		events   = make([]models.ListingEvent, 0)
+	)
+
+	for i := range known {
+		byISIN[known[i].ISIN] = &known[i]
+		if _, ok := current[known[i].ISIN]; !ok {
+			bySymbol[known[i].Symbol] = &known[i]
+		}
+	}
+
+	event := func(isin string, name string, old string, new string) {
+		events = append(events, models.ListingEvent{ISIN: isin, Day: day, Event: name, Old: old, New: new})
+	}
+
+	isins := make([]string, 0, len(current))
+	for isin := range current {
+		isins = append(isins, isin)
+	}
+	sort.Strings(isins)
+
+	replaced := make(map[string]struct{})
+	for _, isin := range isins {
+		var (
+			row     = current[isin]
+			listing = models.Listing{
+				ISIN:      isin,
+				Symbol:    strings.TrimSpace(row.Symbol),
+				Name:      strings.TrimSpace(row.Name),
+				Series:    strings.TrimSpace(row.Series),
+				FirstSeen: day,
+				LastSeen:  day,
+			}
+		)
+		listing.Status = listedSeries[listing.Series]
+
+		prev, ok := byISIN[isin]
+		if !ok {
+			if old, ok := bySymbol[listing.Symbol]; ok {
+				event(isin, models.EventISINChanged, old.ISIN, isin)
+				replaced[old.ISIN] = struct{}{}
+			}
+			listings = append(listings, listing)
+			continue
+		}
+
+		listing.FirstSeen = prev.FirstSeen
+		if prev.Symbol != listing.Symbol {
+			event(isin, models.EventRenamed, prev.Symbol, listing.Symbol)
+		}
+		if prev.Series != listing.Series {
+			event(isin, models.EventSeriesChanged, prev.Series, listing.Series)
+		}
+		if prev.Status == models.ListingSuspended || prev.Status == models.ListingDelisted {
+			event(isin, models.EventResumed, prev.Status, listing.Status)
+		}
+		listings = append(listings, listing)
+	}
+
+	for i := range known {
+		listing := known[i]
+		if _, ok := current[listing.ISIN]; ok {
+			continue
+		}
+
+		switch _, ok := replaced[listing.ISIN]; {
+		case ok && listing.Status != models.ListingDelisted:
+			listing.Status = models.ListingDelisted
+		case !ok && listing.Status != models.ListingSuspended && listing.Status != models.ListingDelisted:
+			event(listing.ISIN, models.EventSuspended, listing.Status, models.ListingSuspended)
+			listing.Status = models.ListingSuspended
+		default:
+			continue
+		}
+		listings = append(listings, listing)
+	}
+
+	return listings, events
+}
+
+// symbolsByISIN returns the current symbol of every listing by ISIN
+func symbolsByISIN(listings []models.Listing) map[string]string {
+	res := make(map[string]string, len(listings))
+	for i := range listings {
+		res[listings[i].ISIN] = listings[i].Symbol
+	}
+	return res
+}
+
+// syncListings tracks the listings found in the bhavcopy of the last trading day and
+// returns the current symbol of every listing by ISIN. The stored history of renamed
+// stocks is moved to their new symbol before the listings are saved, so that a failed
+// move is retried on the next run. A bhavcopy older than the tracked listings is ignored.
+func syncListings(ctx context.Context, rows []models.NSEStockData, lastTradingDay string) (map[string]string, error) {
+	day, err := time.ParseInLocation("2006-01-02", lastTradingDay, calendar.NSE.Location())
+	if err != nil {
+		return nil, fmt.Errorf("invalid last trading day %q: %w", lastTradingDay, err)
+	}
+
+	known, err := db.FetchListings(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch listings: %w", err)
+	}
+
+	for i := range known {
+		if known[i].LastSeen.After(day) {
+			slog.Warn("skipping listing sync of an old bhavcopy", "day", lastTradingDay)
+			return symbolsByISIN(known), nil
+		}
+	}
+
+	listings, events := diffListings(known, rows, day)
+	for i := range events {
+		event := &events[i]
+		if event.Event != models.EventRenamed {
+			slog.Info("listing changed", "isin", event.ISIN, "event", event.Event, "old", event.Old, "new", event.New)
+			continue
+		}
+
+		moved, err := db.RenameSymbol(ctx, event.Old, event.New)
+		if err != nil {
+			return nil, fmt.Errorf("failed to rename %v to %v: %w", event.Old, event.New, err)
+		}
+		slog.Info("symbol renamed", "isin", event.ISIN, "old", event.Old, "new", event.New, "candles", moved)
+	}
+
+	if err := db.SaveListings(ctx, listings, events); err != nil {
+		return nil, fmt.Errorf("failed to save listings: %w", err)
+	}
+
+	slog.Info("listings synced", "listings", len(listings), "events", len(events))
+	return symbolsByISIN(append(known, listings...)), nil
+}
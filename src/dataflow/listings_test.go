@@ -0,0 +1,115 @@
+package dataflow
+
+import (
+	"eeye/src/models"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestDiffListings(t *testing.T) {
+	var (
+		yesterday = time.Date(2025, 3, 19, 0, 0, 0, 0, time.UTC)
+		day       = time.Date(2025, 3, 20, 0, 0, 0, 0, time.UTC)
+		listing   = func(isin, symbol, series, status string) models.Listing {
+			return models.Listing{
+				ISIN:      isin,
+				Symbol:    symbol,
+				Name:      symbol,
+				Series:    series,
+				Status:    status,
+				FirstSeen: yesterday,
+				LastSeen:  yesterday,
+			}
+		}
+		row = func(isin, symbol, series string) models.NSEStockData {
+			return models.NSEStockData{ISIN: isin, Symbol: symbol, Name: symbol, Series: series, Segment: "CM", InstrumentType: "STK"}
+		}
+		seen = func(l models.Listing) models.Listing {
+			l.LastSeen = day
+			return l
+		}
+		event = func(isin, name, old, new string) models.ListingEvent {
+			return models.ListingEvent{ISIN: isin, Day: day, Event: name, Old: old, New: new}
+		}
+	)
+
+	known := []models.Listing{
+		listing("INE000A01001", "TCS", "EQ", models.ListingActive),
+		listing("INE000A01002", "ZOMATO", "EQ", models.ListingActive),
+		listing("INE000A01003", "SUZLON", "EQ", models.ListingActive),
+		listing("INE000A01004", "GONE", "EQ", models.ListingActive),
+		listing("INE000A01005", "BACK", "BE", models.ListingSuspended),
+		listing("INE000A01006", "SPLIT", "EQ", models.ListingActive),
+		listing("INE000A01007", "OLD", "EQ", models.ListingDelisted),
+	}
+	rows := []models.NSEStockData{
+		row("INE000A01001", "TCS", "EQ"),
+		row("INE000A01002", "ETERNAL", "EQ"),
+		row("INE000A01003", "SUZLON", "BE"),
+		row("INE000A01005", "BACK", "BE"),
+		row("INE000A01099", "SPLIT", "EQ"),
+		row("INE000A01100", "NEW", "BZ"),
+		row("INE000A01100", "NEW", "SM"),
+		row("INF000A01001", "NIFTYBEES", "EQ"),
+	}
+
+	newListing := func(isin, symbol, series, status string) models.Listing {
+		l := listing(isin, symbol, series, status)
+		l.FirstSeen, l.LastSeen = day, day
+		return l
+	}
+
+	wantListings := []models.Listing{
+		seen(listing("INE000A01001", "TCS", "EQ", models.ListingActive)),
+		seen(listing("INE000A01002", "ETERNAL", "EQ", models.ListingActive)),
+		seen(listing("INE000A01003", "SUZLON", "BE", models.ListingRestricted)),
+		seen(listing("INE000A01005", "BACK", "BE", models.ListingRestricted)),
+		newListing("INE000A01099", "SPLIT", "EQ", models.ListingActive),
+		newListing("INE000A01100", "NEW", "BZ", models.ListingRestricted),
+		listing("INE000A01004", "GONE", "EQ", models.ListingSuspended),
+		listing("INE000A01006", "SPLIT", "EQ", models.ListingDelisted),
+	}
+	wantEvents := []models.ListingEvent{
+		event("INE000A01002", models.EventRenamed, "ZOMATO", "ETERNAL"),
+		event("INE000A01003", models.EventSeriesChanged, "EQ", "BE"),
+		event("INE000A01005", models.EventResumed, models.ListingSuspended, models.ListingRestricted),
+		event("INE000A01099", models.EventISINChanged, "INE000A01006", "INE000A01099"),
+		event("INE000A01004", models.EventSuspended, models.ListingActive, models.ListingSuspended),
+	}
+
+	listings, events := diffListings(known, rows, day)
+	if !reflect.DeepEqual(listings, wantListings) {
+		t.Errorf("listings = %+v, want %+v", listings, wantListings)
+	}
+	if !reflect.DeepEqual(events, wantEvents) {
+		t.Errorf("events = %+v, want %+v", events, wantEvents)
+	}
+}
+
+func TestDiffListingsIsIdempotent(t *testing.T) {
+	var (
+		day  = time.Date(2025, 3, 20, 0, 0, 0, 0, time.UTC)
+		rows = []models.NSEStockData{
+			{ISIN: "INE000A01001", Symbol: "TCS", Series: "EQ", Segment: "CM", InstrumentType: "STK"},
+		}
+	)
+
+	listings, _ := diffListings(nil, rows, day)
+	if _, events := diffListings(listings, rows, day); len(events) != 0 {
+		t.Errorf("events = %+v, want none", events)
+	}
+}
+
+func TestSymbolsByISIN(t *testing.T) {
+	listings := []models.Listing{
+		{ISIN: "INE000A01002", Symbol: "ZOMATO"},
+		{ISIN: "INE000A01002", Symbol: "ETERNAL"},
+		{ISIN: "INE000A01001", Symbol: "TCS"},
+	}
+
+	want := map[string]string{"INE000A01001": "TCS", "INE000A01002": "ETERNAL"}
+	if got := symbolsByISIN(listings); !reflect.DeepEqual(got, want) {
+		t.Errorf("symbolsByISIN() = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,54 @@
+package dataflow
+
+import (
+	"context"
+	"eeye/src/api"
+	"eeye/src/config"
+	"log/slog"
+	"math/rand/v2"
+	"time"
+)
+
+// backoff returns the wait before the given retry attempt (starting at 1):
+// exponential growth from the base delay, capped at the max delay, with up to
+// 20% jitter so that concurrent workers do not retry in lockstep.
+func backoff(attempt int) time.Duration {
+	delay := config.Groww.RetryBaseDelay << (attempt - 1)
+	if delay <= 0 || delay > config.Groww.RetryMaxDelay {
+		delay = config.Groww.RetryMaxDelay
+	}
+
+	jitter := time.Duration(rand.Int64N(int64(delay)/5 + 1))
+	return delay + jitter
+}
+
+// withRetry runs fn and retries it with exponential backoff as long as it fails
+// with a transient error (network failure, 429 or 5xx), up to config.Groww.MaxRetries
+// times. A Retry-After requested by the server is honoured if it is longer.
+// Waiting stops as soon as ctx is done, returning the context's error.
+func withRetry(ctx context.Context, symbol string, fn func() error) error {
+	for attempt := 1; ; attempt++ {
+		err := fn()
+		if err == nil || ctx.Err() != nil || !api.IsTransient(err) || attempt > config.Groww.MaxRetries {
+			return err
+		}
+
+		wait := max(backoff(attempt), api.RetryAfter(err))
+		slog.Warn(
+			"transient failure, retrying",
+			"symbol", symbol,
+			"attempt", attempt,
+			"attempts", config.Groww.MaxRetries+1,
+			"wait", wait,
+			"err", err,
+		)
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+}
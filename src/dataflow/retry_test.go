@@ -0,0 +1,149 @@
+package dataflow
+
+import (
+	"context"
+	"eeye/src/api"
+	"eeye/src/config"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+)
+
+// retryConfig sets the retry configuration for the duration of a test
+func retryConfig(t *testing.T, maxRetries int, base time.Duration, maxDelay time.Duration) {
+	t.Helper()
+
+	saved := config.Groww
+	t.Cleanup(func() { config.Groww = saved })
+
+	config.Groww.MaxRetries = maxRetries
+	config.Groww.RetryBaseDelay = base
+	config.Groww.RetryMaxDelay = maxDelay
+}
+
+func TestBackoff(t *testing.T) {
+	retryConfig(t, 5, 100*time.Millisecond, time.Second)
+
+	tests := []struct {
+		name    string
+		attempt int
+		// want is the delay without jitter, which adds up to 20%
+		want time.Duration
+	}{
+		{name: "first retry", attempt: 1, want: 100 * time.Millisecond},
+		{name: "doubles", attempt: 2, want: 200 * time.Millisecond},
+		{name: "doubles again", attempt: 4, want: 800 * time.Millisecond},
+		{name: "capped", attempt: 5, want: time.Second},
+		{name: "overflow is capped", attempt: 70, want: time.Second},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for range 20 {
+				got := backoff(tt.attempt)
+				if got < tt.want || got > tt.want+tt.want/5 {
+					t.Fatalf("backoff(%d) = %v, want between %v and %v", tt.attempt, got, tt.want, tt.want+tt.want/5)
+				}
+			}
+		})
+	}
+}
+
+func TestWithRetry(t *testing.T) {
+	var (
+		transient = &api.HTTPError{StatusCode: http.StatusServiceUnavailable}
+		permanent = &api.HTTPError{StatusCode: http.StatusNotFound}
+	)
+
+	tests := []struct {
+		name string
+		// errs are the errors returned by the successive calls, nil once exhausted
+		errs      []error
+		wantCalls int
+		wantErr   error
+	}{
+		{name: "success", wantCalls: 1},
+		{name: "transient failure then success", errs: []error{transient, transient}, wantCalls: 3},
+		{name: "permanent failure", errs: []error{permanent}, wantCalls: 1, wantErr: permanent},
+		{name: "retries exhausted", errs: []error{transient, transient, transient, transient}, wantCalls: 3, wantErr: transient},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			retryConfig(t, 2, time.Millisecond, time.Millisecond)
+
+			calls := 0
+			err := withRetry(context.Background(), "TCS", func() error {
+				calls++
+				if calls <= len(tt.errs) {
+					return tt.errs[calls-1]
+				}
+				return nil
+			})
+
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("withRetry() error = %v, want %v", err, tt.wantErr)
+			}
+			if calls != tt.wantCalls {
+				t.Errorf("withRetry() called fn %d times, want %d", calls, tt.wantCalls)
+			}
+		})
+	}
+}
+
+func TestWithRetryHonoursRetryAfter(t *testing.T) {
+	retryConfig(t, 1, time.Millisecond, time.Millisecond)
+
+	var (
+		throttled = &api.HTTPError{StatusCode: http.StatusTooManyRequests, RetryAfter: 50 * time.Millisecond}
+		calls     = 0
+		start     = time.Now()
+	)
+	err := withRetry(context.Background(), "TCS", func() error {
+		calls++
+		if calls == 1 {
+			return throttled
+		}
+		return nil
+	})
+
+	if err != nil || calls != 2 {
+		t.Fatalf("withRetry() = %v after %d calls, want success after 2", err, calls)
+	}
+	if elapsed := time.Since(start); elapsed < throttled.RetryAfter {
+		t.Errorf("withRetry() retried after %v, want at least the Retry-After of %v", elapsed, throttled.RetryAfter)
+	}
+}
+
+func TestWithRetryCancelled(t *testing.T) {
+	retryConfig(t, 5, time.Hour, time.Hour)
+
+	var (
+		ctx, cancel = context.WithCancel(context.Background())
+		calls       = 0
+		done        = make(chan error, 1)
+	)
+	defer cancel()
+
+	go func() {
+		done <- withRetry(ctx, "TCS", func() error {
+			calls++
+			return &api.HTTPError{StatusCode: http.StatusBadGateway}
+		})
+	}()
+	// cancelled while waiting for the first retry
+	time.AfterFunc(20*time.Millisecond, cancel)
+
+	select {
+	case err := <-done:
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("withRetry() error = %v, want %v", err, context.Canceled)
+		}
+		if calls != 1 {
+			t.Errorf("withRetry() called fn %d times, want 1", calls)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("withRetry() kept waiting after the context was cancelled")
+	}
+}
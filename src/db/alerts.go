@@ -0,0 +1,228 @@
+package db
+
+import (
+	"context"
+	"eeye/src/metrics"
+	"eeye/src/models"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v4"
+)
+
+// alertColumns are the columns scanned by scanAlerts
+const alertColumns = `
+	id, symbol, condition, level, period, direction, expires_on, created_at,
+	evaluated_on, triggered_on, triggered_level, triggered_close
+`
+
+// scanAlerts reads the alerts selected with alertColumns
+func scanAlerts(rows pgx.Rows) ([]models.Alert, error) {
+	defer rows.Close()
+
+	res := make([]models.Alert, 0)
+	for rows.Next() {
+		var (
+			alert          = models.Alert{}
+			level          *float64
+			period         *int32
+			triggeredLevel *float64
+			triggeredClose *float64
+		)
+
+		err := rows.Scan(
+			&alert.ID,
+			&alert.Symbol,
+			&alert.Condition,
+			&level,
+			&period,
+			&alert.Direction,
+			&alert.ExpiresOn,
+			&alert.CreatedAt,
+			&alert.EvaluatedOn,
+			&alert.TriggeredOn,
+			&triggeredLevel,
+			&triggeredClose,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("scanning failed: %w", err)
+		}
+
+		if level != nil {
+			alert.Level = *level
+		}
+		if period != nil {
+			alert.Period = int(*period)
+		}
+		if triggeredLevel != nil {
+			alert.TriggeredLevel = *triggeredLevel
+		}
+		if triggeredClose != nil {
+			alert.TriggeredClose = *triggeredClose
+		}
+		res = append(res, alert)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows error: %w", err)
+	}
+
+	return res, nil
+}
+
+// CreateAlert stores a new alert and sets its ID and creation time
+func CreateAlert(ctx context.Context, alert *models.Alert) error {
+	defer metrics.DBQueryDuration.ObserveSince(time.Now(), "create_alert")
+
+	var (
+		level     *float64
+		period    *int
+		expiresOn *string
+	)
+	if alert.Condition == models.AlertPrice {
+		level = &alert.Level
+	}
+	if alert.Condition == models.AlertEMA {
+		period = &alert.Period
+	}
+	if alert.ExpiresOn != nil {
+		day := alert.ExpiresOn.Format("2006-01-02")
+		expiresOn = &day
+	}
+
+	err := Pool.QueryRow(ctx, `
+		INSERT INTO alerts (symbol, condition, level, period, direction, expires_on)
+		VALUES ($1, $2, $3, $4, $5, $6::date)
+		RETURNING id, created_at
+	`,
+		alert.Symbol,
+		alert.Condition,
+		level,
+		period,
+		alert.Direction,
+		expiresOn,
+	).Scan(&alert.ID, &alert.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("insert failed: %w", err)
+	}
+
+	return nil
+}
+
+// FetchAlerts returns all stored alerts, ordered by symbol and creation
+func FetchAlerts(ctx context.Context) ([]models.Alert, error) {
+	defer metrics.DBQueryDuration.ObserveSince(time.Now(), "fetch_alerts")
+
+	rows, err := Pool.Query(ctx, `
+		SELECT `+alertColumns+`
+		FROM alerts
+		ORDER BY symbol, id
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("query failed: %w", err)
+	}
+
+	return scanAlerts(rows)
+}
+
+// FetchPendingAlerts returns the alerts which have not triggered and were not evaluated
+// up to their expiry yet, ordered by symbol and creation
+func FetchPendingAlerts(ctx context.Context) ([]models.Alert, error) {
+	defer metrics.DBQueryDuration.ObserveSince(time.Now(), "fetch_pending_alerts")
+
+	rows, err := Pool.Query(ctx, `
+		SELECT `+alertColumns+`
+		FROM alerts
+		WHERE triggered_on IS NULL
+			AND (expires_on IS NULL OR evaluated_on IS NULL OR evaluated_on < expires_on)
+		ORDER BY symbol, id
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("query failed: %w", err)
+	}
+
+	return scanAlerts(rows)
+}
+
+// DeleteAlert deletes an alert and reports whether it existed
+func DeleteAlert(ctx context.Context, id int64) (bool, error) {
+	defer metrics.DBQueryDuration.ObserveSince(time.Now(), "delete_alert")
+
+	tag, err := Pool.Exec(ctx, `DELETE FROM alerts WHERE id = $1`, id)
+	if err != nil {
+		return false, fmt.Errorf("delete failed: %w", err)
+	}
+
+	return tag.RowsAffected() > 0, nil
+}
+
+// SaveAlertEvaluations stores the EvaluatedOn of the alerts, and marks those with a
+// TriggeredOn as triggered with their TriggeredLevel and TriggeredClose, in a single
+// transaction. Alerts which already triggered are left as they are.
+func SaveAlertEvaluations(ctx context.Context, alerts []models.Alert) error {
+	defer metrics.DBQueryDuration.ObserveSince(time.Now(), "save_alert_evaluations")
+
+	batch := &pgx.Batch{}
+	for i := range alerts {
+		alert := &alerts[i]
+		if alert.EvaluatedOn == nil {
+			continue
+		}
+
+		var (
+			triggeredOn    *string
+			triggeredLevel *float64
+			triggeredClose *float64
+		)
+		if alert.TriggeredOn != nil {
+			day := alert.TriggeredOn.Format("2006-01-02")
+			triggeredOn = &day
+			triggeredLevel = &alert.TriggeredLevel
+			triggeredClose = &alert.TriggeredClose
+		}
+
+		batch.Queue(`
+			UPDATE alerts SET
+				evaluated_on = $2::date,
+				triggered_on = $3::date,
+				triggered_level = $4,
+				triggered_close = $5
+			WHERE id = $1 AND triggered_on IS NULL
+		`,
+			alert.ID,
+			alert.EvaluatedOn.Format("2006-01-02"),
+			triggeredOn,
+			triggeredLevel,
+			triggeredClose,
+		)
+	}
+
+	if batch.Len() == 0 {
+		return nil
+	}
+
+	tx, err := Pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("begin transaction failed: %w", err)
+	}
+	defer func() {
+		_ = tx.Rollback(ctx)
+	}()
+
+	results := tx.SendBatch(ctx, batch)
+	for range batch.Len() {
+		if _, err := results.Exec(); err != nil {
+			_ = results.Close()
+			return fmt.Errorf("update failed: %w", err)
+		}
+	}
+	if err := results.Close(); err != nil {
+		return fmt.Errorf("update failed: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("commit failed: %w", err)
+	}
+
+	return nil
+}
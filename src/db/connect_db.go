@@ -8,6 +8,7 @@ import (
 	"eeye/src/config"
 	"fmt"
 	"log"
+	"log/slog"
 
 	"github.com/jackc/pgx/v4/pgxpool"
 )
@@ -38,7 +39,7 @@ func Connect() {
 		log.Fatalf("Unable to connect to database: %v\n", err)
 	}
 	Pool = pool
-	log.Println("Connected to database")
+	slog.Info("connected to database")
 }
 
 // Disconnect closes the database connection pool if it exists.
@@ -47,6 +48,6 @@ func Connect() {
 func Disconnect() {
 	if Pool != nil {
 		Pool.Close()
-		log.Println("Disconnected from database")
+		slog.Info("disconnected from database")
 	}
 }
@@ -0,0 +1,111 @@
+package db
+
+import (
+	"context"
+	"eeye/src/config"
+	"eeye/src/constants"
+	"eeye/src/metrics"
+	"eeye/src/models"
+	"eeye/src/utils"
+	"fmt"
+	"log/slog"
+	"time"
+)
+
+// RecordIngestionAttempt stores the outcome of an ingestion attempt for a symbol.
+// A nil ingestErr marks the attempt as successful and resets the failure streak.
+func RecordIngestionAttempt(ctx context.Context, symbol string, ingestErr error) error {
+	defer metrics.DBQueryDuration.ObserveSince(time.Now(), "record_ingestion_attempt")
+
+	var err error
+	if ingestErr == nil {
+		_, err = Pool.Exec(ctx, `
+			INSERT INTO ingestion_status (symbol, last_attempt_at, last_success_at, consecutive_failures, last_error)
+			VALUES ($1, NOW(), NOW(), 0, NULL)
+			ON CONFLICT (symbol) DO UPDATE SET
+				last_attempt_at = EXCLUDED.last_attempt_at,
+				last_success_at = EXCLUDED.last_success_at,
+				consecutive_failures = 0,
+				last_error = NULL
+		`, symbol)
+	} else {
+		_, err = Pool.Exec(ctx, `
+			INSERT INTO ingestion_status (symbol, last_attempt_at, consecutive_failures, last_error)
+			VALUES ($1, NOW(), 1, $2)
+			ON CONFLICT (symbol) DO UPDATE SET
+				last_attempt_at = EXCLUDED.last_attempt_at,
+				consecutive_failures = ingestion_status.consecutive_failures + 1,
+				last_error = EXCLUDED.last_error
+		`, symbol, ingestErr.Error())
+	}
+
+	if err != nil {
+		return fmt.Errorf("upsert failed: %w", err)
+	}
+
+	return nil
+}
+
+// SetHistoryFrom records the day from which the history of a symbol has been fetched.
+// The provider has no data before the listing of a stock, so the day is recorded
+// even if the oldest stored candle is younger.
+func SetHistoryFrom(ctx context.Context, symbol string, from time.Time) error {
+	defer metrics.DBQueryDuration.ObserveSince(time.Now(), "set_history_from")
+
+	_, err := Pool.Exec(ctx, `
+		INSERT INTO ingestion_status (symbol, last_attempt_at, history_from)
+		VALUES ($1, NOW(), $2::date)
+		ON CONFLICT (symbol) DO UPDATE SET
+			history_from = EXCLUDED.history_from
+	`, symbol, from.Format("2006-01-02"))
+	if err != nil {
+		return fmt.Errorf("upsert failed: %w", err)
+	}
+
+	return nil
+}
+
+// FetchStocksMissingHistory fetches stocks whose stored history starts after the
+// given day and whose history has not yet been fetched back to that day.
+// Stocks whose oldest candle is within a week of the day are considered complete,
+// since the first days of the window may not have a trading session.
+func FetchStocksMissingHistory(ctx context.Context, from time.Time) ([]models.Stock, error) {
+	defer metrics.DBQueryDuration.ObserveSince(time.Now(), "fetch_stocks_missing_history")
+
+	slog.Debug("fetching stocks missing history")
+
+	rows, err := Pool.Query(ctx, `
+		SELECT p.symbol
+		FROM stock_prices p
+		LEFT JOIN ingestion_status s ON s.symbol = p.symbol
+		GROUP BY p.symbol, s.history_from
+		HAVING MIN(p.timestamp) > (($1::date + 7)::timestamp AT TIME ZONE $2)
+			AND (s.history_from IS NULL OR s.history_from > $1::date)
+		ORDER BY p.symbol ASC
+	`, from.Format("2006-01-02"), config.DB.Tz)
+
+	var (
+		empty = utils.EmptySlice[models.Stock]()
+		res   = make([]models.Stock, 0, constants.NumOfStocks)
+	)
+
+	if err != nil {
+		return empty, fmt.Errorf("query failed: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		stock := models.Stock{Segment: "CASH", Exchange: "NSE"}
+
+		err := rows.Scan(&stock.Symbol)
+
+		if err != nil {
+			return empty, fmt.Errorf("scanning failed: %w", err)
+		}
+		stock.Name = stock.Symbol
+
+		res = append(res, stock)
+	}
+
+	return res, nil
+}
@@ -0,0 +1,245 @@
+package db
+
+import (
+	"context"
+	"eeye/src/constants"
+	"eeye/src/metrics"
+	"eeye/src/models"
+	"eeye/src/utils"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/jackc/pgx/v4"
+)
+
+// FetchListings returns all tracked listings, ordered by symbol
+func FetchListings(ctx context.Context) ([]models.Listing, error) {
+	defer metrics.DBQueryDuration.ObserveSince(time.Now(), "fetch_listings")
+
+	rows, err := Pool.Query(ctx, `
+		SELECT isin, symbol, name, series, status, first_seen, last_seen
+		FROM listings
+		ORDER BY symbol, isin
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("query failed: %w", err)
+	}
+	defer rows.Close()
+
+	res := make([]models.Listing, 0, constants.NumOfStocks)
+	for rows.Next() {
+		listing := models.Listing{}
+		err := rows.Scan(
+			&listing.ISIN,
+			&listing.Symbol,
+			&listing.Name,
+			&listing.Series,
+			&listing.Status,
+			&listing.FirstSeen,
+			&listing.LastSeen,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("scanning failed: %w", err)
+		}
+		res = append(res, listing)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows error: %w", err)
+	}
+
+	return res, nil
+}
+
+// SaveListings upserts the given listings and records their events in a single transaction
+func SaveListings(ctx context.Context, listings []models.Listing, events []models.ListingEvent) error {
+	defer metrics.DBQueryDuration.ObserveSince(time.Now(), "save_listings")
+
+	if len(listings) == 0 && len(events) == 0 {
+		return nil
+	}
+
+	batch := &pgx.Batch{}
+	for i := range listings {
+		listing := &listings[i]
+		batch.Queue(`
+			INSERT INTO listings (isin, symbol, name, series, status, first_seen, last_seen)
+			VALUES ($1, $2, $3, $4, $5, $6::date, $7::date)
+			ON CONFLICT (isin) DO UPDATE SET
+				symbol = EXCLUDED.symbol,
+				name = EXCLUDED.name,
+				series = EXCLUDED.series,
+				status = EXCLUDED.status,
+				last_seen = EXCLUDED.last_seen,
+				updated_at = NOW()
+		`,
+			listing.ISIN,
+			listing.Symbol,
+			listing.Name,
+			listing.Series,
+			listing.Status,
+			listing.FirstSeen.Format("2006-01-02"),
+			listing.LastSeen.Format("2006-01-02"),
+		)
+	}
+	for i := range events {
+		event := &events[i]
+		batch.Queue(`
+			INSERT INTO listing_events (isin, day, event, old_value, new_value)
+			VALUES ($1, $2::date, $3, $4, $5)
+			ON CONFLICT (isin, day, event) DO UPDATE SET
+				old_value = EXCLUDED.old_value,
+				new_value = EXCLUDED.new_value,
+				detected_at = NOW()
+		`,
+			event.ISIN,
+			event.Day.Format("2006-01-02"),
+			event.Event,
+			event.Old,
+			event.New,
+		)
+	}
+
+	tx, err := Pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("begin transaction failed: %w", err)
+	}
+	defer func() {
+		_ = tx.Rollback(ctx)
+	}()
+
+	results := tx.SendBatch(ctx, batch)
+	for range batch.Len() {
+		if _, err := results.Exec(); err != nil {
+			_ = results.Close()
+			return fmt.Errorf("upsert failed: %w", err)
+		}
+	}
+	if err := results.Close(); err != nil {
+		return fmt.Errorf("upsert failed: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("commit failed: %w", err)
+	}
+
+	return nil
+}
+
+// FetchListingEvents returns the listing events since the given day with the current
+// symbol of their listing, most recent first
+func FetchListingEvents(ctx context.Context, since time.Time) ([]models.ListingEvent, error) {
+	defer metrics.DBQueryDuration.ObserveSince(time.Now(), "fetch_listing_events")
+
+	rows, err := Pool.Query(ctx, `
+		SELECT e.isin, COALESCE(l.symbol, ''), e.day, e.event, e.old_value, e.new_value
+		FROM listing_events e
+		LEFT JOIN listings l ON l.isin = e.isin
+		WHERE e.day >= $1::date
+		ORDER BY e.day DESC, l.symbol, e.event
+	`, since.Format("2006-01-02"))
+	if err != nil {
+		return nil, fmt.Errorf("query failed: %w", err)
+	}
+	defer rows.Close()
+
+	res := utils.EmptySlice[models.ListingEvent]()
+	for rows.Next() {
+		event := models.ListingEvent{}
+		if err := rows.Scan(&event.ISIN, &event.Symbol, &event.Day, &event.Event, &event.Old, &event.New); err != nil {
+			return nil, fmt.Errorf("scanning failed: %w", err)
+		}
+		res = append(res, event)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows error: %w", err)
+	}
+
+	return res, nil
+}
+
+// renameStatements move the rows of a symbol ($1) to its new symbol ($2). Rows already
+// stored under the new symbol win over the moved ones, so that a rename detected late
+// (after the new symbol has been ingested) does not fail.
+var renameStatements = []string{
+	`WITH
+		moved AS (
+			DELETE FROM stock_prices
+			WHERE symbol = $1
+			RETURNING open, close, high, low, timestamp, volume, delivery_qty, delivery_pct, turnover
+		)
+	INSERT INTO stock_prices (symbol, open, close, high, low, timestamp, volume, delivery_qty, delivery_pct, turnover)
+	SELECT $2, open, close, high, low, timestamp, volume, delivery_qty, delivery_pct, turnover
+	FROM moved
+	ON CONFLICT (symbol, timestamp) DO NOTHING`,
+
+	`UPDATE ingestion_status SET symbol = $2
+	WHERE symbol = $1 AND NOT EXISTS (SELECT 1 FROM ingestion_status WHERE symbol = $2)`,
+	`DELETE FROM ingestion_status WHERE symbol = $1`,
+
+	`UPDATE candle_issues i SET symbol = $2
+	WHERE i.symbol = $1 AND NOT EXISTS (
+		SELECT 1 FROM candle_issues n
+		WHERE n.symbol = $2 AND n.timestamp = i.timestamp AND n.check_name = i.check_name
+	)`,
+	`DELETE FROM candle_issues WHERE symbol = $1`,
+
+	`UPDATE corporate_actions a SET symbol = $2
+	WHERE a.symbol = $1 AND NOT EXISTS (
+		SELECT 1 FROM corporate_actions n
+		WHERE n.symbol = $2 AND n.ex_date = a.ex_date AND n.action = a.action
+	)`,
+	`DELETE FROM corporate_actions WHERE symbol = $1`,
+
+	`UPDATE signals s SET symbol = $2
+	WHERE s.symbol = $1 AND NOT EXISTS (
+		SELECT 1 FROM signals n
+		WHERE n.symbol = $2 AND n.strategy = s.strategy AND n.day = s.day
+	)`,
+	`DELETE FROM signals WHERE symbol = $1`,
+
+	`UPDATE alerts SET symbol = $2 WHERE symbol = $1`,
+}
+
+// RenameSymbol moves the stored history of a renamed stock (candles, ingestion status,
+// quality issues, corporate actions, signals and alerts) from its old symbol to the new
+// one in a single transaction, and returns the number of moved candles. The weekly bars are
+// refreshed afterwards, since continuous aggregates cannot be refreshed within a transaction.
+func RenameSymbol(ctx context.Context, from string, to string) (int64, error) {
+	defer metrics.DBQueryDuration.ObserveSince(time.Now(), "rename_symbol")
+
+	tx, err := Pool.Begin(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("begin transaction failed: %w", err)
+	}
+	defer func() {
+		_ = tx.Rollback(ctx)
+	}()
+
+	var moved int64
+	for i, statement := range renameStatements {
+		tag, err := tx.Exec(ctx, statement, from, to)
+		if err != nil {
+			return 0, fmt.Errorf("query failed: %w", err)
+		}
+		if i == 0 {
+			moved = tag.RowsAffected()
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return 0, fmt.Errorf("commit failed: %w", err)
+	}
+
+	if moved > 0 {
+		_, err := Pool.Exec(ctx, `CALL refresh_continuous_aggregate('stock_prices_weekly', NULL, NULL)`)
+		if err != nil {
+			// the refresh policy catches up with recent weeks anyway
+			slog.Warn("failed to refresh the weekly bars", "err", err)
+		}
+	}
+
+	return moved, nil
+}
@@ -0,0 +1,328 @@
+package db
+
+import (
+	"context"
+	"eeye/src/config"
+	"embed"
+	"fmt"
+	"io"
+	"io/fs"
+	"log/slog"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/jackc/pgx/v4"
+)
+
+//go:embed migrations/*.sql
+var migrationFiles embed.FS
+
+// migrationLock is the advisory lock key held while migrations run, so that two
+// processes never migrate the same database concurrently
+const migrationLock = 7_312_025
+
+// Migration is a versioned schema change with the SQL to apply and revert it.
+// Migrations are embedded from migrations/<version>_<name>.(up|down).sql
+type Migration struct {
+	Version int
+	Name    string
+	Up      string
+	Down    string
+}
+
+// MigrationState is a migration together with the time it was applied (zero if pending)
+type MigrationState struct {
+	Migration
+	AppliedAt time.Time
+}
+
+// parseMigrationName splits a migration file name into version, name and direction.
+// e.g. 0002_candle_quality.up.sql -> 2, candle_quality, up
+func parseMigrationName(file string) (int, string, string, error) {
+	base, ok := strings.CutSuffix(file, ".sql")
+	if !ok {
+		return 0, "", "", fmt.Errorf("not a sql file: %v", file)
+	}
+
+	dot := strings.LastIndex(base, ".")
+	if dot == -1 {
+		return 0, "", "", fmt.Errorf("missing direction: %v", file)
+	}
+	base, direction := base[:dot], base[dot+1:]
+	if direction != "up" && direction != "down" {
+		return 0, "", "", fmt.Errorf("invalid direction %q: %v", direction, file)
+	}
+
+	prefix, name, ok := strings.Cut(base, "_")
+	if !ok || name == "" {
+		return 0, "", "", fmt.Errorf("missing name: %v", file)
+	}
+
+	version, err := strconv.Atoi(prefix)
+	if err != nil || version <= 0 {
+		return 0, "", "", fmt.Errorf("invalid version %q: %v", prefix, file)
+	}
+
+	return version, name, direction, nil
+}
+
+// loadMigrations reads the migrations of the given file system sorted by version.
+// Every migration must have both an up and a down file and versions must be unique.
+func loadMigrations(fsys fs.FS, dir string) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read migrations: %w", err)
+	}
+
+	byVersion := make(map[int]*Migration)
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+
+		version, name, direction, err := parseMigrationName(entry.Name())
+		if err != nil {
+			return nil, err
+		}
+
+		data, err := fs.ReadFile(fsys, path.Join(dir, entry.Name()))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read migration %v: %w", entry.Name(), err)
+		}
+
+		migration, ok := byVersion[version]
+		if !ok {
+			migration = &Migration{Version: version, Name: name}
+			byVersion[version] = migration
+		}
+		if migration.Name != name {
+			return nil, fmt.Errorf("migration %d has two names: %v and %v", version, migration.Name, name)
+		}
+
+		if direction == "up" {
+			migration.Up = string(data)
+		} else {
+			migration.Down = string(data)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, migration := range byVersion {
+		if migration.Up == "" || migration.Down == "" {
+			return nil, fmt.Errorf("migration %04d_%v needs both up and down files", migration.Version, migration.Name)
+		}
+		migrations = append(migrations, *migration)
+	}
+
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+	return migrations, nil
+}
+
+// Migrations returns the migrations embedded in the binary sorted by version
+func Migrations() ([]Migration, error) {
+	return loadMigrations(migrationFiles, "migrations")
+}
+
+// withMigrationLock runs fn on a dedicated connection holding the migration lock.
+// The schema_migrations table is created if it does not exist.
+func withMigrationLock(ctx context.Context, fn func(ctx context.Context, conn *pgx.Conn) error) error {
+	conn, err := Pool.Acquire(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to acquire connection: %w", err)
+	}
+	defer conn.Release()
+
+	if _, err := conn.Exec(ctx, "SELECT pg_advisory_lock($1)", migrationLock); err != nil {
+		return fmt.Errorf("failed to acquire migration lock: %w", err)
+	}
+	defer func() {
+		if _, err := conn.Exec(ctx, "SELECT pg_advisory_unlock($1)", migrationLock); err != nil {
+			slog.Error("failed to release migration lock", "err", err)
+		}
+	}()
+
+	_, err = conn.Exec(ctx, `
+		CREATE TABLE IF NOT EXISTS schema_migrations (
+			version INTEGER PRIMARY KEY,
+			name TEXT NOT NULL,
+			applied_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+		)
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to create schema_migrations: %w", err)
+	}
+
+	return fn(ctx, conn.Conn())
+}
+
+// appliedMigrations returns the applied versions and the time they were applied
+func appliedMigrations(ctx context.Context, conn *pgx.Conn) (map[int]time.Time, error) {
+	rows, err := conn.Query(ctx, "SELECT version, applied_at FROM schema_migrations")
+	if err != nil {
+		return nil, fmt.Errorf("query failed: %w", err)
+	}
+	defer rows.Close()
+
+	applied := make(map[int]time.Time)
+	for rows.Next() {
+		var (
+			version   int
+			appliedAt time.Time
+		)
+		if err := rows.Scan(&version, &appliedAt); err != nil {
+			return nil, fmt.Errorf("scan failed: %w", err)
+		}
+		applied[version] = appliedAt
+	}
+
+	return applied, rows.Err()
+}
+
+// expandMigration replaces the placeholders of a migration script with the configuration:
+// {{tz}} becomes the EEYE_TZ timezone as a string literal
+func expandMigration(script string, tz string) string {
+	return strings.ReplaceAll(script, "{{tz}}", "'"+strings.ReplaceAll(tz, "'", "''")+"'")
+}
+
+// runMigration executes the SQL of a migration and records (or forgets) its
+// version in a single transaction, so a failed migration leaves no trace
+func runMigration(ctx context.Context, conn *pgx.Conn, migration Migration, up bool) error {
+	tx, err := conn.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer func() {
+		_ = tx.Rollback(ctx)
+	}()
+
+	script := migration.Down
+	if up {
+		script = migration.Up
+	}
+	if _, err := tx.Exec(ctx, expandMigration(script, config.DB.Tz)); err != nil {
+		return fmt.Errorf("migration %04d_%v failed: %w", migration.Version, migration.Name, err)
+	}
+
+	if up {
+		_, err = tx.Exec(ctx, "INSERT INTO schema_migrations (version, name) VALUES ($1, $2)", migration.Version, migration.Name)
+	} else {
+		_, err = tx.Exec(ctx, "DELETE FROM schema_migrations WHERE version = $1", migration.Version)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to record migration %04d_%v: %w", migration.Version, migration.Name, err)
+	}
+
+	return tx.Commit(ctx)
+}
+
+// MigrateUp applies all pending migrations in version order and returns how many were applied
+func MigrateUp(ctx context.Context) (int, error) {
+	migrations, err := Migrations()
+	if err != nil {
+		return 0, err
+	}
+
+	count := 0
+	err = withMigrationLock(ctx, func(ctx context.Context, conn *pgx.Conn) error {
+		applied, err := appliedMigrations(ctx, conn)
+		if err != nil {
+			return err
+		}
+
+		for _, migration := range migrations {
+			if _, ok := applied[migration.Version]; ok {
+				continue
+			}
+
+			if err := runMigration(ctx, conn, migration, true); err != nil {
+				return err
+			}
+			slog.Info("applied migration", "version", migration.Version, "name", migration.Name)
+			count++
+		}
+		return nil
+	})
+
+	return count, err
+}
+
+// MigrateDown reverts the latest steps applied migrations in reverse version order
+// and returns how many were reverted
+func MigrateDown(ctx context.Context, steps int) (int, error) {
+	migrations, err := Migrations()
+	if err != nil {
+		return 0, err
+	}
+
+	count := 0
+	err = withMigrationLock(ctx, func(ctx context.Context, conn *pgx.Conn) error {
+		applied, err := appliedMigrations(ctx, conn)
+		if err != nil {
+			return err
+		}
+
+		for i := len(migrations) - 1; i >= 0 && count < steps; i-- {
+			migration := migrations[i]
+			if _, ok := applied[migration.Version]; !ok {
+				continue
+			}
+
+			if err := runMigration(ctx, conn, migration, false); err != nil {
+				return err
+			}
+			slog.Info("reverted migration", "version", migration.Version, "name", migration.Name)
+			count++
+		}
+		return nil
+	})
+
+	return count, err
+}
+
+// FetchMigrationStates returns every embedded migration with the time it was applied
+func FetchMigrationStates(ctx context.Context) ([]MigrationState, error) {
+	migrations, err := Migrations()
+	if err != nil {
+		return nil, err
+	}
+
+	states := make([]MigrationState, 0, len(migrations))
+	err = withMigrationLock(ctx, func(ctx context.Context, conn *pgx.Conn) error {
+		applied, err := appliedMigrations(ctx, conn)
+		if err != nil {
+			return err
+		}
+
+		for _, migration := range migrations {
+			states = append(states, MigrationState{Migration: migration, AppliedAt: applied[migration.Version]})
+		}
+		return nil
+	})
+
+	return states, err
+}
+
+// MigrationStatus writes a table of the embedded migrations and whether they are applied
+func MigrationStatus(ctx context.Context, w io.Writer) error {
+	states, err := FetchMigrationStates(ctx)
+	if err != nil {
+		return err
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "VERSION\tNAME\tAPPLIED AT")
+	for _, state := range states {
+		appliedAt := "pending"
+		if !state.AppliedAt.IsZero() {
+			appliedAt = state.AppliedAt.Format(time.RFC3339)
+		}
+		fmt.Fprintf(tw, "%04d\t%v\t%v\n", state.Version, state.Name, appliedAt)
+	}
+	return tw.Flush()
+}
@@ -0,0 +1,128 @@
+package db
+
+import (
+	"testing"
+	"testing/fstest"
+)
+
+func TestParseMigrationName(t *testing.T) {
+	tests := []struct {
+		file          string
+		wantVersion   int
+		wantName      string
+		wantDirection string
+		wantErr       bool
+	}{
+		{file: "0001_stock_prices.up.sql", wantVersion: 1, wantName: "stock_prices", wantDirection: "up"},
+		{file: "0012_add_index.down.sql", wantVersion: 12, wantName: "add_index", wantDirection: "down"},
+		{file: "0001_stock_prices.sql", wantErr: true},
+		{file: "0001_stock_prices.sideways.sql", wantErr: true},
+		{file: "first_stock_prices.up.sql", wantErr: true},
+		{file: "0000_zero.up.sql", wantErr: true},
+		{file: "0001.up.sql", wantErr: true},
+		{file: "README.md", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.file, func(t *testing.T) {
+			version, name, direction, err := parseMigrationName(tt.file)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseMigrationName() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if !tt.wantErr && (version != tt.wantVersion || name != tt.wantName || direction != tt.wantDirection) {
+				t.Errorf("parseMigrationName() = %v, %v, %v, want %v, %v, %v", version, name, direction, tt.wantVersion, tt.wantName, tt.wantDirection)
+			}
+		})
+	}
+}
+
+func TestLoadMigrations(t *testing.T) {
+	file := func(data string) *fstest.MapFile {
+		return &fstest.MapFile{Data: []byte(data)}
+	}
+
+	tests := []struct {
+		name         string
+		files        fstest.MapFS
+		wantVersions []int
+		wantErr      bool
+	}{
+		{
+			name: "sorted by version",
+			files: fstest.MapFS{
+				"m/0002_b.up.sql":   file("B"),
+				"m/0002_b.down.sql": file("-B"),
+				"m/0001_a.up.sql":   file("A"),
+				"m/0001_a.down.sql": file("-A"),
+			},
+			wantVersions: []int{1, 2},
+		},
+		{
+			name:    "missing down",
+			files:   fstest.MapFS{"m/0001_a.up.sql": file("A")},
+			wantErr: true,
+		},
+		{
+			name: "conflicting names",
+			files: fstest.MapFS{
+				"m/0001_a.up.sql":   file("A"),
+				"m/0001_b.down.sql": file("-B"),
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			migrations, err := loadMigrations(tt.files, "m")
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("loadMigrations() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			if len(migrations) != len(tt.wantVersions) {
+				t.Fatalf("loadMigrations() returned %d migrations, want %d", len(migrations), len(tt.wantVersions))
+			}
+
+			for i, version := range tt.wantVersions {
+				if migrations[i].Version != version {
+					t.Errorf("migrations[%d].Version = %v, want %v", i, migrations[i].Version, version)
+				}
+			}
+		})
+	}
+}
+
+func TestEmbeddedMigrations(t *testing.T) {
+	migrations, err := Migrations()
+	if err != nil {
+		t.Fatalf("Migrations() error = %v", err)
+	}
+
+	for i, migration := range migrations {
+		if migration.Version != i+1 {
+			t.Errorf("migration %v has version %d, want %d (versions must be contiguous)", migration.Name, migration.Version, i+1)
+		}
+	}
+}
+
+func TestExpandMigration(t *testing.T) {
+	tests := []struct {
+		name   string
+		script string
+		tz     string
+		want   string
+	}{
+		{name: "timezone", script: "SELECT time_bucket(INTERVAL '1 week', ts, {{tz}})", tz: "Asia/Kolkata", want: "SELECT time_bucket(INTERVAL '1 week', ts, 'Asia/Kolkata')"},
+		{name: "quoted", script: "SELECT {{tz}}", tz: "it's", want: "SELECT 'it''s'"},
+		{name: "no placeholder", script: "SELECT 1", tz: "UTC", want: "SELECT 1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := expandMigration(tt.script, tt.tz); got != tt.want {
+				t.Errorf("expandMigration() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -1,4 +1,4 @@
--- Create the stock prices table if it doesn't exist
+-- Daily candles of every listed stock
 CREATE TABLE IF NOT EXISTS stock_prices (
   symbol TEXT NOT NULL,
   open NUMERIC(12, 4),
@@ -10,5 +10,5 @@ CREATE TABLE IF NOT EXISTS stock_prices (
   PRIMARY KEY (symbol, timestamp)
 );
 
--- Convert to hypertable if not already (separate transaction)
+-- Convert to hypertable if not already
 SELECT create_hypertable('stock_prices', 'timestamp', if_not_exists => TRUE);
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS corporate_actions;
+DROP TABLE IF EXISTS candle_issues;
@@ -0,0 +1,25 @@
+-- Data quality issues detected while ingesting candles.
+-- Quarantined candles are kept here (with their raw OHLCV) instead of stock_prices.
+CREATE TABLE IF NOT EXISTS candle_issues (
+  symbol TEXT NOT NULL,
+  timestamp TIMESTAMPTZ NOT NULL,
+  check_name TEXT NOT NULL,
+  severity TEXT NOT NULL,
+  detail TEXT NOT NULL,
+  quarantined BOOLEAN NOT NULL DEFAULT FALSE,
+  open NUMERIC(12, 4),
+  close NUMERIC(12, 4),
+  high NUMERIC(12, 4),
+  low NUMERIC(12, 4),
+  volume BIGINT,
+  detected_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+  PRIMARY KEY (symbol, timestamp, check_name)
+);
+
+-- Corporate actions (splits, bonuses, etc.) which explain large price gaps
+CREATE TABLE IF NOT EXISTS corporate_actions (
+  symbol TEXT NOT NULL,
+  ex_date DATE NOT NULL,
+  action TEXT NOT NULL,
+  PRIMARY KEY (symbol, ex_date, action)
+);
@@ -0,0 +1,11 @@
+-- Outcome of the latest ingestion attempt per symbol
+CREATE TABLE IF NOT EXISTS ingestion_status (
+  symbol TEXT PRIMARY KEY,
+  last_attempt_at TIMESTAMPTZ NOT NULL,
+  last_success_at TIMESTAMPTZ,
+  consecutive_failures INTEGER NOT NULL DEFAULT 0,
+  last_error TEXT
+);
+
+-- Earliest day of history requested for the symbol (set when it was first backfilled)
+ALTER TABLE ingestion_status ADD COLUMN IF NOT EXISTS history_from DATE;
@@ -0,0 +1,3 @@
+ALTER TABLE stock_prices DROP COLUMN IF EXISTS turnover;
+ALTER TABLE stock_prices DROP COLUMN IF EXISTS delivery_pct;
+ALTER TABLE stock_prices DROP COLUMN IF EXISTS delivery_qty;
@@ -0,0 +1,4 @@
+-- Delivery and turnover data published by NSE
+ALTER TABLE stock_prices ADD COLUMN IF NOT EXISTS delivery_qty BIGINT;
+ALTER TABLE stock_prices ADD COLUMN IF NOT EXISTS delivery_pct DOUBLE PRECISION;
+ALTER TABLE stock_prices ADD COLUMN IF NOT EXISTS turnover DOUBLE PRECISION;
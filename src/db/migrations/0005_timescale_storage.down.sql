@@ -0,0 +1,7 @@
+DROP VIEW IF EXISTS stock_liquidity_20d;
+DROP MATERIALIZED VIEW IF EXISTS stock_prices_weekly;
+
+SELECT remove_retention_policy('stock_prices', if_exists => TRUE);
+SELECT remove_compression_policy('stock_prices', if_exists => TRUE);
+SELECT decompress_chunk(chunk, if_compressed => TRUE) FROM show_chunks('stock_prices') AS chunk;
+ALTER TABLE stock_prices SET (timescaledb.compress = FALSE);
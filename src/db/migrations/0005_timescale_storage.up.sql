@@ -0,0 +1,48 @@
+-- Compressed chunks are segmented by symbol so that a single stock's candles are
+-- decompressed together. The compression policy itself is managed by the app
+-- (EEYE_COMPRESS_AFTER_DAYS) since its interval is configurable.
+ALTER TABLE stock_prices SET (
+  timescaledb.compress,
+  timescaledb.compress_segmentby = 'symbol',
+  timescaledb.compress_orderby = 'timestamp DESC'
+);
+
+-- Weekly bars, bucketed by NSE weeks (Monday to Friday in EEYE_TZ, see expandMigration).
+-- The timezone is fixed when the view is created.
+CREATE MATERIALIZED VIEW IF NOT EXISTS stock_prices_weekly
+WITH (timescaledb.continuous) AS
+SELECT
+  symbol,
+  time_bucket(INTERVAL '1 week', timestamp, {{tz}}) AS week,
+  first(open, timestamp) AS open,
+  max(high) AS high,
+  min(low) AS low,
+  last(close, timestamp) AS close,
+  sum(volume) AS volume,
+  sum(turnover) AS turnover,
+  count(*) AS sessions
+FROM stock_prices
+GROUP BY symbol, week
+WITH NO DATA;
+
+SELECT add_continuous_aggregate_policy(
+  'stock_prices_weekly',
+  start_offset => INTERVAL '2 months',
+  end_offset => INTERVAL '1 day',
+  schedule_interval => INTERVAL '1 day',
+  if_not_exists => TRUE
+);
+
+-- Rolling 20 session averages of volume and turnover. Continuous aggregates cannot
+-- hold window functions, so this is a plain view; filter it by symbol.
+CREATE OR REPLACE VIEW stock_liquidity_20d AS
+SELECT
+  symbol,
+  timestamp,
+  volume,
+  turnover,
+  avg(volume) OVER w AS avg_volume_20d,
+  avg(turnover) OVER w AS avg_turnover_20d,
+  count(*) OVER w AS sessions
+FROM stock_prices
+WINDOW w AS (PARTITION BY symbol ORDER BY timestamp ROWS BETWEEN 19 PRECEDING AND CURRENT ROW);
@@ -0,0 +1,15 @@
+-- Candles of de-listed stocks moved out of stock_prices by the cleanup
+CREATE TABLE IF NOT EXISTS stock_prices_archive (
+  symbol TEXT NOT NULL,
+  open NUMERIC(12, 4),
+  close NUMERIC(12, 4),
+  high NUMERIC(12, 4),
+  low NUMERIC(12, 4),
+  timestamp TIMESTAMPTZ NOT NULL,
+  volume BIGINT,
+  delivery_qty BIGINT,
+  delivery_pct DOUBLE PRECISION,
+  turnover DOUBLE PRECISION,
+  archived_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+  PRIMARY KEY (symbol, timestamp)
+);
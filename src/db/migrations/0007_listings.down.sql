@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS listing_events;
+DROP TABLE IF EXISTS listings;
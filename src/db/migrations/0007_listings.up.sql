@@ -0,0 +1,24 @@
+-- NSE listings keyed by ISIN, which unlike the symbol does not change on renames
+CREATE TABLE IF NOT EXISTS listings (
+  isin TEXT PRIMARY KEY,
+  symbol TEXT NOT NULL,
+  name TEXT NOT NULL,
+  series TEXT NOT NULL,
+  status TEXT NOT NULL,
+  first_seen DATE NOT NULL,
+  last_seen DATE NOT NULL,
+  updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS listings_symbol_idx ON listings (symbol);
+
+-- Renames, series transitions and suspensions of the listings
+CREATE TABLE IF NOT EXISTS listing_events (
+  isin TEXT NOT NULL,
+  day DATE NOT NULL,
+  event TEXT NOT NULL,
+  old_value TEXT NOT NULL,
+  new_value TEXT NOT NULL,
+  detected_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+  PRIMARY KEY (isin, day, event)
+);
@@ -0,0 +1,17 @@
+-- Completed screening runs of every strategy, one per trading day (re-runs replace it)
+CREATE TABLE IF NOT EXISTS signal_runs (
+  day DATE NOT NULL,
+  strategy TEXT NOT NULL,
+  stocks INTEGER NOT NULL,
+  completed_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+  PRIMARY KEY (strategy, day)
+);
+
+-- Stocks selected by a run
+CREATE TABLE IF NOT EXISTS signals (
+  day DATE NOT NULL,
+  strategy TEXT NOT NULL,
+  symbol TEXT NOT NULL,
+  PRIMARY KEY (strategy, day, symbol),
+  FOREIGN KEY (strategy, day) REFERENCES signal_runs (strategy, day) ON DELETE CASCADE
+);
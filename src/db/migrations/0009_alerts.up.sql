@@ -0,0 +1,16 @@
+-- Price alerts: a stock watched for its close crossing a price, an EMA or a
+-- support/resistance level. Alerts trigger once.
+CREATE TABLE IF NOT EXISTS alerts (
+  id BIGSERIAL PRIMARY KEY,
+  symbol TEXT NOT NULL,
+  condition TEXT NOT NULL CHECK (condition IN ('price', 'ema', 'support', 'resistance')),
+  level DOUBLE PRECISION CHECK (condition <> 'price' OR level > 0),
+  period INTEGER CHECK (condition <> 'ema' OR period > 1),
+  direction TEXT NOT NULL CHECK (direction IN ('above', 'below')),
+  expires_on DATE,
+  created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+  evaluated_on DATE,
+  triggered_on DATE,
+  triggered_level DOUBLE PRECISION,
+  triggered_close DOUBLE PRECISION
+);
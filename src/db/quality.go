@@ -0,0 +1,193 @@
+package db
+
+import (
+	"context"
+	"eeye/src/config"
+	"eeye/src/metrics"
+	"eeye/src/models"
+	"eeye/src/utils"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/jackc/pgx/v4"
+)
+
+// SaveCandleIssues records data quality issues. Re-detecting the same check for
+// the same candle refreshes the existing row instead of creating a duplicate.
+func SaveCandleIssues(ctx context.Context, issues []models.CandleIssue) error {
+	defer metrics.DBQueryDuration.ObserveSince(time.Now(), "save_candle_issues")
+
+	if len(issues) == 0 {
+		return nil
+	}
+
+	slog.Debug("saving candle issues", "issues", len(issues))
+	var (
+		batch = &pgx.Batch{}
+	)
+
+	for i := range issues {
+		issue := &issues[i]
+		batch.Queue(`
+			INSERT INTO candle_issues (
+				symbol, timestamp, check_name, severity, detail, quarantined,
+				open, close, high, low, volume
+			)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+			ON CONFLICT (symbol, timestamp, check_name) DO UPDATE SET
+				severity = EXCLUDED.severity,
+				detail = EXCLUDED.detail,
+				quarantined = EXCLUDED.quarantined,
+				open = EXCLUDED.open,
+				close = EXCLUDED.close,
+				high = EXCLUDED.high,
+				low = EXCLUDED.low,
+				volume = EXCLUDED.volume,
+				detected_at = NOW()
+		`,
+			issue.Symbol,
+			issue.Timestamp,
+			issue.Check,
+			issue.Severity,
+			issue.Detail,
+			issue.Quarantined,
+			issue.Candle.Open,
+			issue.Candle.Close,
+			issue.Candle.High,
+			issue.Candle.Low,
+			issue.Candle.Volume,
+		)
+	}
+
+	results := Pool.SendBatch(ctx, batch)
+	defer func() {
+		_ = results.Close()
+	}()
+
+	for range issues {
+		if _, err := results.Exec(); err != nil {
+			return fmt.Errorf("insert failed: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// FetchCandleIssues returns the issues detected since the given time,
+// most recent trading day first.
+func FetchCandleIssues(ctx context.Context, since time.Time) ([]models.CandleIssue, error) {
+	defer metrics.DBQueryDuration.ObserveSince(time.Now(), "fetch_candle_issues")
+
+	slog.Debug("fetching candle issues", "since", since)
+
+	rows, err := Pool.Query(ctx, `
+		SELECT
+			symbol, (timestamp AT TIME ZONE $2) as timestamp, check_name, severity, detail,
+			quarantined, COALESCE(open, 0), COALESCE(close, 0), COALESCE(high, 0),
+			COALESCE(low, 0), COALESCE(volume, 0), detected_at
+		FROM candle_issues
+		WHERE detected_at >= $1
+		ORDER BY timestamp DESC, symbol ASC, check_name ASC
+	`, since, config.DB.Tz)
+
+	var (
+		empty = utils.EmptySlice[models.CandleIssue]()
+		res   = make([]models.CandleIssue, 0)
+	)
+
+	if err != nil {
+		return empty, fmt.Errorf("query failed: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		issue := models.CandleIssue{}
+
+		err := rows.Scan(
+			&issue.Symbol,
+			&issue.Timestamp,
+			&issue.Check,
+			&issue.Severity,
+			&issue.Detail,
+			&issue.Quarantined,
+			&issue.Candle.Open,
+			&issue.Candle.Close,
+			&issue.Candle.High,
+			&issue.Candle.Low,
+			&issue.Candle.Volume,
+			&issue.DetectedAt,
+		)
+
+		if err != nil {
+			return empty, fmt.Errorf("scanning failed: %w", err)
+		}
+		issue.Candle.Symbol = issue.Symbol
+		issue.Candle.Timestamp = issue.Timestamp
+
+		res = append(res, issue)
+	}
+
+	return res, nil
+}
+
+// SaveCorporateActions records corporate actions. Actions already recorded are kept.
+func SaveCorporateActions(ctx context.Context, actions []models.CorporateAction) error {
+	defer metrics.DBQueryDuration.ObserveSince(time.Now(), "save_corporate_actions")
+
+	if len(actions) == 0 {
+		return nil
+	}
+
+	slog.Debug("saving corporate actions", "actions", len(actions))
+	batch := &pgx.Batch{}
+	for i := range actions {
+		batch.Queue(`
+			INSERT INTO corporate_actions (symbol, ex_date, action)
+			VALUES ($1, $2::date, $3)
+			ON CONFLICT DO NOTHING
+		`, actions[i].Symbol, actions[i].ExDate.Format("2006-01-02"), actions[i].Action)
+	}
+
+	results := Pool.SendBatch(ctx, batch)
+	defer func() {
+		_ = results.Close()
+	}()
+
+	for range actions {
+		if _, err := results.Exec(); err != nil {
+			return fmt.Errorf("insert failed: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// FetchCorporateActions returns the corporate actions of a stock with an ex-date on
+// or after the given time, keyed by ex-date (YYYY-MM-DD).
+func FetchCorporateActions(ctx context.Context, symbol string, from time.Time) (map[string]string, error) {
+	defer metrics.DBQueryDuration.ObserveSince(time.Now(), "fetch_corporate_actions")
+
+	rows, err := Pool.Query(ctx, `
+		SELECT TO_CHAR(ex_date, 'YYYY-MM-DD'), STRING_AGG(action, ', ')
+		FROM corporate_actions
+		WHERE symbol = $1 AND ex_date >= $2::date
+		GROUP BY ex_date
+	`, symbol, from.Format("2006-01-02"))
+
+	res := make(map[string]string)
+	if err != nil {
+		return res, fmt.Errorf("query failed: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var exDate, action string
+		if err := rows.Scan(&exDate, &action); err != nil {
+			return res, fmt.Errorf("scanning failed: %w", err)
+		}
+		res[exDate] = action
+	}
+
+	return res, nil
+}
@@ -4,10 +4,12 @@ import (
 	"context"
 	"eeye/src/config"
 	"eeye/src/constants"
+	"eeye/src/metrics"
 	"eeye/src/models"
 	"eeye/src/utils"
+	"errors"
 	"fmt"
-	"log"
+	"log/slog"
 	"time"
 
 	"github.com/jackc/pgx/v4"
@@ -15,13 +17,18 @@ import (
 
 // GetLastCandle retrieves the most recent candlestick data for a given stock symbol.
 // The timestamp in the returned candle is adjusted to the timezone specified in DB.
-func GetLastCandle(symbol string) (models.Candle, error) {
-	log.Printf("getting last candle for %s\n", symbol)
-	ctx := context.Background()
+// If the stock has no candles yet, only the timestamp is set (to the start of the
+// history window, see config.Ingestion.HistoryDays) and all prices are zero.
+func GetLastCandle(ctx context.Context, symbol string) (models.Candle, error) {
+	defer metrics.DBQueryDuration.ObserveSince(time.Now(), "get_last_candle")
+
+	slog.Debug("getting last candle", "symbol", symbol)
 
 	// Trading API works in current timezone so do the conversion of timestamp
 	rows, err := Pool.Query(ctx, `
-		SELECT (timestamp AT TIME ZONE $2) as timestamp
+		SELECT
+			open, close, high, low, (timestamp AT TIME ZONE $2) as timestamp, volume,
+			COALESCE(delivery_qty, 0), COALESCE(delivery_pct, 0), COALESCE(turnover, 0)
 		FROM stock_prices
 		WHERE symbol = $1
 		ORDER BY timestamp DESC
@@ -30,7 +37,7 @@ func GetLastCandle(symbol string) (models.Candle, error) {
 
 	var ret = models.Candle{
 		Symbol:    symbol,
-		Timestamp: time.Now().UTC().Truncate(24*time.Hour).AddDate(0, 0, -constants.LookBackDays),
+		Timestamp: utils.Now().UTC().Truncate(24*time.Hour).AddDate(0, 0, -config.Ingestion.HistoryDays),
 	}
 
 	if err != nil {
@@ -39,7 +46,17 @@ func GetLastCandle(symbol string) (models.Candle, error) {
 	defer rows.Close()
 
 	if rows.Next() {
-		err := rows.Scan(&ret.Timestamp)
+		err := rows.Scan(
+			&ret.Open,
+			&ret.Close,
+			&ret.High,
+			&ret.Low,
+			&ret.Timestamp,
+			&ret.Volume,
+			&ret.DeliveryQty,
+			&ret.DeliveryPct,
+			&ret.Turnover,
+		)
 		if err != nil {
 			return ret, fmt.Errorf("scanning failed: %w", err)
 		}
@@ -48,10 +65,95 @@ func GetLastCandle(symbol string) (models.Candle, error) {
 	return ret, nil
 }
 
-// BackfillCandles efficiently inserts multiple candlestick records into the database
-// using PostgreSQL's COPY protocol. This is optimized for bulk insertions of historical data.
-func BackfillCandles(stock *models.Stock, candles []models.Candle) error {
-	log.Printf("backfilling %d candles for %v\n", len(candles), stock.Symbol)
+// GetFirstCandle retrieves the oldest candlestick data for a given stock symbol.
+// The timestamp in the returned candle is adjusted to the timezone specified in DB.
+// If the stock has no candles yet, only the timestamp is set (to the current day)
+// and all prices are zero.
+func GetFirstCandle(ctx context.Context, symbol string) (models.Candle, error) {
+	defer metrics.DBQueryDuration.ObserveSince(time.Now(), "get_first_candle")
+
+	slog.Debug("getting first candle", "symbol", symbol)
+
+	rows, err := Pool.Query(ctx, `
+		SELECT
+			open, close, high, low, (timestamp AT TIME ZONE $2) as timestamp, volume,
+			COALESCE(delivery_qty, 0), COALESCE(delivery_pct, 0), COALESCE(turnover, 0)
+		FROM stock_prices
+		WHERE symbol = $1
+		ORDER BY timestamp ASC
+		LIMIT 1
+	`, symbol, config.DB.Tz)
+
+	var ret = models.Candle{
+		Symbol:    symbol,
+		Timestamp: utils.Now().UTC().Truncate(24 * time.Hour),
+	}
+
+	if err != nil {
+		return ret, fmt.Errorf("query failed: %w", err)
+	}
+	defer rows.Close()
+
+	if rows.Next() {
+		err := rows.Scan(
+			&ret.Open,
+			&ret.Close,
+			&ret.High,
+			&ret.Low,
+			&ret.Timestamp,
+			&ret.Volume,
+			&ret.DeliveryQty,
+			&ret.DeliveryPct,
+			&ret.Turnover,
+		)
+		if err != nil {
+			return ret, fmt.Errorf("scanning failed: %w", err)
+		}
+	}
+
+	return ret, nil
+}
+
+// unknownIfZero maps the zero value of optional candle fields to NULL
+func unknownIfZero[T uint64 | float64](v T) any {
+	if v == 0 {
+		return nil
+	}
+	return v
+}
+
+// GetStoredRange returns the timestamps of the oldest and the newest candle stored
+// for any stock, adjusted to the timezone specified in DB. Both are nil if the
+// database holds no candles.
+func GetStoredRange(ctx context.Context) (*time.Time, *time.Time, error) {
+	defer metrics.DBQueryDuration.ObserveSince(time.Now(), "get_stored_range")
+
+	var first, last *time.Time
+	err := Pool.QueryRow(ctx, `
+		SELECT MIN(timestamp AT TIME ZONE $1), MAX(timestamp AT TIME ZONE $1)
+		FROM stock_prices
+	`, config.DB.Tz).Scan(&first, &last)
+	if err != nil {
+		return nil, nil, fmt.Errorf("query failed: %w", err)
+	}
+
+	return first, last, nil
+}
+
+// BackfillCandles idempotently upserts multiple candlestick records into the database.
+// Candles are streamed with PostgreSQL's COPY protocol into a temporary staging table
+// and then merged into stock_prices with INSERT ... ON CONFLICT DO UPDATE, so that
+// re-ingesting overlapping ranges is safe and late corrections to existing candles
+// are picked up. Delivery and turnover unknown to the provider (zero) do not
+// overwrite values stored earlier.
+func BackfillCandles(ctx context.Context, stock *models.Stock, candles []models.Candle) error {
+	defer metrics.DBQueryDuration.ObserveSince(time.Now(), "backfill_candles")
+
+	slog.Debug("backfilling candles", "symbol", stock.Symbol, "candles", len(candles))
+	if len(candles) == 0 {
+		return nil
+	}
+
 	entries := make([][]any, 0, len(candles))
 	for i := range candles {
 		candle := &candles[i]
@@ -63,36 +165,91 @@ func BackfillCandles(stock *models.Stock, candles []models.Candle) error {
 			candle.Low,
 			candle.Timestamp,
 			candle.Volume,
+			unknownIfZero(candle.DeliveryQty),
+			unknownIfZero(candle.DeliveryPct),
+			unknownIfZero(candle.Turnover),
 		})
 	}
 
 	var (
-		columns   = []string{"symbol", "open", "close", "high", "low", "timestamp", "volume"}
-		tableName = "stock_prices"
-		ctx       = context.Background()
+		columns = []string{
+			"symbol", "open", "close", "high", "low", "timestamp", "volume",
+			"delivery_qty", "delivery_pct", "turnover",
+		}
+		stagingTable = "stock_prices_staging"
 	)
 
+	tx, err := Pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("begin transaction failed: %w", err)
+	}
+	defer func() {
+		_ = tx.Rollback(ctx)
+	}()
+
+	// Staging table is private to this transaction and dropped on commit
+	_, err = tx.Exec(ctx, `
+		CREATE TEMP TABLE stock_prices_staging (LIKE stock_prices INCLUDING DEFAULTS)
+		ON COMMIT DROP
+	`)
+	if err != nil {
+		return fmt.Errorf("create staging table failed: %w", err)
+	}
+
 	/*
 		COPY FROM is a PostgreSQL protocol (binary) which helps in efficient insertion.
 		Instead of creating and closing HTTP connection per insert, it creates a single connection,
 		and insert params are streamed in batches.
 	*/
-	_, err := Pool.CopyFrom(ctx, pgx.Identifier{tableName}, columns, pgx.CopyFromRows(entries))
+	_, err = tx.CopyFrom(ctx, pgx.Identifier{stagingTable}, columns, pgx.CopyFromRows(entries))
 	if err != nil {
 		return fmt.Errorf("copy from failed: %w", err)
 	}
 
+	// Unchanged rows are skipped to avoid rewriting (and bloating) existing chunks
+	tag, err := tx.Exec(ctx, `
+		INSERT INTO stock_prices (symbol, open, close, high, low, timestamp, volume, delivery_qty, delivery_pct, turnover)
+		SELECT DISTINCT ON (symbol, timestamp) symbol, open, close, high, low, timestamp, volume, delivery_qty, delivery_pct, turnover
+		FROM stock_prices_staging
+		ORDER BY symbol, timestamp
+		ON CONFLICT (symbol, timestamp) DO UPDATE SET
+			open = EXCLUDED.open,
+			close = EXCLUDED.close,
+			high = EXCLUDED.high,
+			low = EXCLUDED.low,
+			volume = EXCLUDED.volume,
+			delivery_qty = COALESCE(EXCLUDED.delivery_qty, stock_prices.delivery_qty),
+			delivery_pct = COALESCE(EXCLUDED.delivery_pct, stock_prices.delivery_pct),
+			turnover = COALESCE(EXCLUDED.turnover, stock_prices.turnover)
+		WHERE (stock_prices.open, stock_prices.close, stock_prices.high, stock_prices.low, stock_prices.volume)
+			IS DISTINCT FROM (EXCLUDED.open, EXCLUDED.close, EXCLUDED.high, EXCLUDED.low, EXCLUDED.volume)
+			OR (EXCLUDED.delivery_qty IS NOT NULL AND EXCLUDED.delivery_qty IS DISTINCT FROM stock_prices.delivery_qty)
+			OR (EXCLUDED.delivery_pct IS NOT NULL AND EXCLUDED.delivery_pct IS DISTINCT FROM stock_prices.delivery_pct)
+			OR (EXCLUDED.turnover IS NOT NULL AND EXCLUDED.turnover IS DISTINCT FROM stock_prices.turnover)
+	`)
+	if err != nil {
+		return fmt.Errorf("upsert failed: %w", err)
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return fmt.Errorf("commit failed: %w", err)
+	}
+
+	slog.Debug("upserted candles", "symbol", stock.Symbol, "upserted", tag.RowsAffected(), "candles", len(candles))
 	return nil
 }
 
 // FetchAllCandles retrieves all stored candlestick data for a given stock.
 // The timestamps in the returned candles are adjusted to the timezone specified in DB.
-func FetchAllCandles(stock *models.Stock) ([]models.Candle, error) {
-	log.Printf("fetching all candles: %v\n", stock.Symbol)
-	ctx := context.Background()
+func FetchAllCandles(ctx context.Context, stock *models.Stock) ([]models.Candle, error) {
+	defer metrics.DBQueryDuration.ObserveSince(time.Now(), "fetch_all_candles")
+
+	slog.Debug("fetching all candles", "symbol", stock.Symbol)
 
 	rows, err := Pool.Query(ctx, `
-		SELECT symbol, open, close, high, low, (timestamp AT TIME ZONE $2) as timestamp, volume
+		SELECT
+			symbol, open, close, high, low, (timestamp AT TIME ZONE $2) as timestamp, volume,
+			COALESCE(delivery_qty, 0), COALESCE(delivery_pct, 0), COALESCE(turnover, 0)
 		FROM stock_prices
 		WHERE symbol = $1
 		ORDER BY timestamp ASC
@@ -119,6 +276,9 @@ func FetchAllCandles(stock *models.Stock) ([]models.Candle, error) {
 			&candle.Low,
 			&candle.Timestamp,
 			&candle.Volume,
+			&candle.DeliveryQty,
+			&candle.DeliveryPct,
+			&candle.Turnover,
 		)
 
 		if err != nil {
@@ -131,10 +291,183 @@ func FetchAllCandles(stock *models.Stock) ([]models.Candle, error) {
 	return res, nil
 }
 
+// FetchCandlesBatch retrieves the candles of several stocks in a single query, keyed by symbol.
+// If limit is positive only the latest limit candles of every stock are returned.
+// Candles of each stock are ordered by time and adjusted to the timezone specified in DB;
+// stocks without candles are absent from the result.
+func FetchCandlesBatch(ctx context.Context, symbols []string, limit int) (map[string][]models.Candle, error) {
+	defer metrics.DBQueryDuration.ObserveSince(time.Now(), "fetch_candles_batch")
+
+	slog.Debug("fetching candles", "stocks", len(symbols), "limit", limit)
+
+	// The lateral join walks the (symbol, timestamp) primary key backwards per symbol,
+	// so trimming to the latest candles does not scan the full history
+	rows, err := Pool.Query(ctx, `
+		SELECT
+			c.symbol, c.open, c.close, c.high, c.low, (c.timestamp AT TIME ZONE $2) as timestamp, c.volume,
+			COALESCE(c.delivery_qty, 0), COALESCE(c.delivery_pct, 0), COALESCE(c.turnover, 0)
+		FROM unnest($1::text[]) AS s(symbol)
+		CROSS JOIN LATERAL (
+			SELECT *
+			FROM stock_prices p
+			WHERE p.symbol = s.symbol
+			ORDER BY p.timestamp DESC
+			LIMIT NULLIF($3::int, 0)
+		) AS c
+		ORDER BY c.symbol ASC, c.timestamp ASC
+	`, symbols, config.DB.Tz, limit)
+
+	var (
+		empty = make(map[string][]models.Candle)
+		res   = make(map[string][]models.Candle, len(symbols))
+	)
+
+	if err != nil {
+		return empty, fmt.Errorf("query failed: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		candle := models.Candle{}
+
+		err := rows.Scan(
+			&candle.Symbol,
+			&candle.Open,
+			&candle.Close,
+			&candle.High,
+			&candle.Low,
+			&candle.Timestamp,
+			&candle.Volume,
+			&candle.DeliveryQty,
+			&candle.DeliveryPct,
+			&candle.Turnover,
+		)
+
+		if err != nil {
+			return empty, fmt.Errorf("scanning failed: %w", err)
+		}
+
+		res[candle.Symbol] = append(res[candle.Symbol], candle)
+	}
+
+	if err := rows.Err(); err != nil {
+		return empty, fmt.Errorf("rows error: %w", err)
+	}
+
+	return res, nil
+}
+
+// FetchCandlesSince retrieves the candles of several stocks in a single query, keyed by symbol.
+// since[i] is the earliest (wall clock) time of the candles returned for symbols[i].
+// Candles of each stock are ordered by time and adjusted to the timezone specified in DB.
+func FetchCandlesSince(ctx context.Context, symbols []string, since []time.Time) (map[string][]models.Candle, error) {
+	defer metrics.DBQueryDuration.ObserveSince(time.Now(), "fetch_candles_since")
+
+	slog.Debug("fetching recent candles", "stocks", len(symbols))
+
+	rows, err := Pool.Query(ctx, `
+		SELECT
+			p.symbol, p.open, p.close, p.high, p.low, (p.timestamp AT TIME ZONE $3) as timestamp, p.volume,
+			COALESCE(p.delivery_qty, 0), COALESCE(p.delivery_pct, 0), COALESCE(p.turnover, 0)
+		FROM unnest($1::text[], $2::timestamp[]) AS s(symbol, since)
+		JOIN stock_prices p ON p.symbol = s.symbol AND p.timestamp >= (s.since AT TIME ZONE $3)
+		ORDER BY p.symbol ASC, p.timestamp ASC
+	`, symbols, since, config.DB.Tz)
+
+	var (
+		empty = make(map[string][]models.Candle)
+		res   = make(map[string][]models.Candle, len(symbols))
+	)
+
+	if err != nil {
+		return empty, fmt.Errorf("query failed: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		candle := models.Candle{}
+
+		err := rows.Scan(
+			&candle.Symbol,
+			&candle.Open,
+			&candle.Close,
+			&candle.High,
+			&candle.Low,
+			&candle.Timestamp,
+			&candle.Volume,
+			&candle.DeliveryQty,
+			&candle.DeliveryPct,
+			&candle.Turnover,
+		)
+
+		if err != nil {
+			return empty, fmt.Errorf("scanning failed: %w", err)
+		}
+
+		res[candle.Symbol] = append(res[candle.Symbol], candle)
+	}
+
+	if err := rows.Err(); err != nil {
+		return empty, fmt.Errorf("rows error: %w", err)
+	}
+
+	return res, nil
+}
+
+// FetchCandleBounds returns the first and last (wall clock) times of the stored candles of
+// several stocks. Unlike a range over the whole history, it only looks up two index entries
+// per stock, so the candles are not counted (Count is zero). Stocks without candles are
+// absent from the result.
+func FetchCandleBounds(ctx context.Context, symbols []string) (map[string]models.CandleRange, error) {
+	defer metrics.DBQueryDuration.ObserveSince(time.Now(), "fetch_candle_bounds")
+
+	rows, err := Pool.Query(ctx, `
+		SELECT
+			s.symbol, (f.timestamp AT TIME ZONE $2) as first, (l.timestamp AT TIME ZONE $2) as last
+		FROM unnest($1::text[]) AS s(symbol)
+		CROSS JOIN LATERAL (
+			SELECT timestamp FROM stock_prices WHERE symbol = s.symbol ORDER BY timestamp ASC LIMIT 1
+		) f
+		CROSS JOIN LATERAL (
+			SELECT timestamp FROM stock_prices WHERE symbol = s.symbol ORDER BY timestamp DESC LIMIT 1
+		) l
+	`, symbols, config.DB.Tz)
+
+	var (
+		empty = make(map[string]models.CandleRange)
+		res   = make(map[string]models.CandleRange, len(symbols))
+	)
+
+	if err != nil {
+		return empty, fmt.Errorf("query failed: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			symbol string
+			r      models.CandleRange
+		)
+
+		if err := rows.Scan(&symbol, &r.First, &r.Last); err != nil {
+			return empty, fmt.Errorf("scanning failed: %w", err)
+		}
+
+		res[symbol] = r
+	}
+
+	if err := rows.Err(); err != nil {
+		return empty, fmt.Errorf("rows error: %w", err)
+	}
+
+	return res, nil
+}
+
 // FetchAllStocks returns distinct stocks from the DB
-func FetchAllStocks() ([]models.Stock, error) {
-	log.Println("fetching all distinct stocks from DB")
-	ctx := context.Background()
+func FetchAllStocks(ctx context.Context) ([]models.Stock, error) {
+	defer metrics.DBQueryDuration.ObserveSince(time.Now(), "fetch_all_stocks")
+
+	slog.Debug("fetching all distinct stocks")
 
 	rows, err := Pool.Query(ctx, `
 		SELECT symbol
@@ -166,23 +499,24 @@ func FetchAllStocks() ([]models.Stock, error) {
 		res = append(res, stock)
 	}
 
-	log.Printf("fetched %v distinct stock from DB\n", len(res))
+	slog.Debug("fetched distinct stocks", "stocks", len(res))
 	return res, nil
 }
 
-// FetchOutOfSyncStock fetches stocks that are not synced with latest market data
-func FetchOutOfSyncStock(lastTradingDay string) ([]models.Stock, error) {
-	log.Println("fetching out of sync stocks")
-	ctx := context.Background()
+// FetchOutOfSyncStock fetches stocks whose latest candle is older than the given
+// trading day (YYYY-MM-DD). Stocks already holding newer candles are not reported.
+func FetchOutOfSyncStock(ctx context.Context, lastTradingDay string) ([]models.Stock, error) {
+	defer metrics.DBQueryDuration.ObserveSince(time.Now(), "fetch_out_of_sync_stock")
 
-	query := fmt.Sprintf(`
+	slog.Debug("fetching out of sync stocks")
+
+	rows, err := Pool.Query(ctx, `
 		SELECT symbol
 		FROM stock_prices
 		GROUP BY symbol
-		HAVING MAX(timestamp) <> TIMESTAMPTZ '%v'
+		HAVING MAX(timestamp) < ($1::date::timestamp AT TIME ZONE $2)
 		ORDER BY symbol ASC
-	`, fmt.Sprintf("%v 00:00:00 %v", lastTradingDay, config.DB.Tz))
-	rows, err := Pool.Query(ctx, query)
+	`, lastTradingDay, config.DB.Tz)
 
 	var (
 		empty = utils.EmptySlice[models.Stock]()
@@ -210,37 +544,226 @@ func FetchOutOfSyncStock(lastTradingDay string) ([]models.Stock, error) {
 	return res, nil
 }
 
-// DeleteDelistedStocks deletes the stock data which is no longer listed on NSE
-// Only to be executed on successful completion of the analysis
-func DeleteDelistedStocks() {
-	log.Println("finding delisted stocks for deletion")
-	ctx := context.Background()
+// ErrNotSynced is returned by the cleanup queries when the database has no candle
+// for the last completed trading session, in which case every stock would look stale
+var ErrNotSynced = errors.New("database is not synced up to the last session")
 
-	_, err := Pool.Exec(ctx, `
+// checkSynced returns ErrNotSynced if the latest stored candle is older than lastSession
+func checkSynced(ctx context.Context, lastSession time.Time) error {
+	var latest *time.Time
+	err := Pool.QueryRow(ctx, `
+		SELECT MAX(timestamp)
+		FROM stock_prices
+	`).Scan(&latest)
+	if err != nil {
+		return fmt.Errorf("query failed: %w", err)
+	}
+
+	if latest == nil || latest.Before(lastSession) {
+		return fmt.Errorf("%w (%v)", ErrNotSynced, lastSession.Format("2006-01-02"))
+	}
+	return nil
+}
+
+// FetchStaleStocks returns the stocks without a candle since cutoff, with their stored
+// candles. ErrNotSynced is returned if the database itself is not synced up to lastSession,
+// so that an incomplete ingestion does not make live stocks look stale.
+func FetchStaleStocks(ctx context.Context, lastSession time.Time, cutoff time.Time) ([]models.DelistedStock, error) {
+	defer metrics.DBQueryDuration.ObserveSince(time.Now(), "fetch_stale_stocks")
+
+	if err := checkSynced(ctx, lastSession); err != nil {
+		return nil, err
+	}
+
+	rows, err := Pool.Query(ctx, `
+		SELECT
+			symbol,
+			COUNT(*),
+			MIN(timestamp AT TIME ZONE $2),
+			MAX(timestamp AT TIME ZONE $2)
+		FROM stock_prices
+		GROUP BY symbol
+		HAVING MAX(timestamp) < $1
+		ORDER BY symbol
+	`, cutoff, config.DB.Tz)
+	if err != nil {
+		return nil, fmt.Errorf("query failed: %w", err)
+	}
+	defer rows.Close()
+
+	res := utils.EmptySlice[models.DelistedStock]()
+	for rows.Next() {
+		stock := models.DelistedStock{}
+		if err := rows.Scan(&stock.Symbol, &stock.Range.Count, &stock.Range.First, &stock.Range.Last); err != nil {
+			return nil, fmt.Errorf("scanning failed: %w", err)
+		}
+		res = append(res, stock)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows error: %w", err)
+	}
+
+	return res, nil
+}
+
+// ArchiveStocks moves all candles of the given symbols from stock_prices to
+// stock_prices_archive in a single statement, marks their listings delisted and returns
+// the number of moved candles. Candles archived before (e.g. of a re-listed symbol) are replaced.
+// Staleness is checked again by the statement: symbols which received a candle since
+// cutoff after they were found stale (see FetchStaleStocks) are left untouched.
+func ArchiveStocks(ctx context.Context, symbols []string, cutoff time.Time) (int64, error) {
+	defer metrics.DBQueryDuration.ObserveSince(time.Now(), "archive_stocks")
+
+	tag, err := Pool.Exec(ctx, `
 		WITH
-			ts_info AS (
-				SELECT MAX(timestamp) AS ts
-				FROM stock_prices
+			stale AS (
+				SELECT s.symbol
+				FROM unnest($1::text[]) AS s(symbol)
+				WHERE NOT EXISTS (
+					SELECT 1 FROM stock_prices r
+					WHERE r.symbol = s.symbol AND r.timestamp >= $3
+				)
+			),
+			deleted AS (
+				DELETE FROM stock_prices
+				WHERE symbol IN (SELECT symbol FROM stale)
+				RETURNING
+					symbol, open, close, high, low, timestamp, volume,
+					delivery_qty, delivery_pct, turnover
 			),
 			delisted AS (
-				SELECT symbol
-				FROM stock_prices
-				GROUP BY symbol
-				HAVING MAX(timestamp) <> (
-					SELECT ts
-					FROM ts_info
-				)
+				UPDATE listings SET status = $2, updated_at = NOW()
+				WHERE symbol IN (SELECT symbol FROM stale)
 			)
-		DELETE FROM stock_prices
-		WHERE symbol IN (
-			SELECT symbol
-			FROM delisted
+		INSERT INTO stock_prices_archive (
+			symbol, open, close, high, low, timestamp, volume,
+			delivery_qty, delivery_pct, turnover
+		)
+		SELECT *
+		FROM deleted
+		ON CONFLICT (symbol, timestamp) DO UPDATE SET
+			open = EXCLUDED.open,
+			close = EXCLUDED.close,
+			high = EXCLUDED.high,
+			low = EXCLUDED.low,
+			volume = EXCLUDED.volume,
+			delivery_qty = EXCLUDED.delivery_qty,
+			delivery_pct = EXCLUDED.delivery_pct,
+			turnover = EXCLUDED.turnover,
+			archived_at = now()
+	`, symbols, models.ListingDelisted, cutoff)
+	if err != nil {
+		return 0, fmt.Errorf("query failed: %w", err)
+	}
+
+	return tag.RowsAffected(), nil
+}
+
+// FetchDaysMissingDelivery returns the days since from with stored candles of which
+// none has delivery data. Days are returned oldest first, at midnight UTC.
+func FetchDaysMissingDelivery(ctx context.Context, from time.Time) ([]time.Time, error) {
+	defer metrics.DBQueryDuration.ObserveSince(time.Now(), "fetch_days_missing_delivery")
+
+	rows, err := Pool.Query(ctx, `
+		SELECT (timestamp AT TIME ZONE $2)::date AS day
+		FROM stock_prices
+		WHERE timestamp >= ($1::date::timestamp AT TIME ZONE $2)
+		GROUP BY day
+		HAVING COUNT(delivery_pct) = 0
+		ORDER BY day ASC
+	`, from.Format("2006-01-02"), config.DB.Tz)
+
+	var (
+		empty = utils.EmptySlice[time.Time]()
+		res   = make([]time.Time, 0)
+	)
+
+	if err != nil {
+		return empty, fmt.Errorf("query failed: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var day time.Time
+		if err := rows.Scan(&day); err != nil {
+			return empty, fmt.Errorf("scanning failed: %w", err)
+		}
+
+		res = append(res, day)
+	}
+
+	return res, nil
+}
+
+// UpdateDelivery stores the delivery quantity, delivery percentage and turnover of
+// the given trading day (YYYY-MM-DD) on the candles already present for that day.
+// Rows of symbols without a candle are ignored.
+func UpdateDelivery(ctx context.Context, day string, rows []models.NSEDeliveryData) (int64, error) {
+	defer metrics.DBQueryDuration.ObserveSince(time.Now(), "update_delivery")
+
+	if len(rows) == 0 {
+		return 0, nil
+	}
+
+	entries := make([][]any, 0, len(rows))
+	for i := range rows {
+		row := &rows[i]
+		entries = append(entries, []any{
+			row.Symbol,
+			unknownIfZero(row.DeliveryQty),
+			unknownIfZero(row.DeliveryPct),
+			unknownIfZero(row.Turnover),
+		})
+	}
+
+	tx, err := Pool.Begin(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("begin transaction failed: %w", err)
+	}
+	defer func() {
+		_ = tx.Rollback(ctx)
+	}()
+
+	_, err = tx.Exec(ctx, `
+		CREATE TEMP TABLE delivery_staging (
+			symbol TEXT NOT NULL,
+			delivery_qty BIGINT,
+			delivery_pct DOUBLE PRECISION,
+			turnover DOUBLE PRECISION
 		)
+		ON COMMIT DROP
 	`)
 	if err != nil {
-		log.Printf("deletion of delisted stocks failed: %v\n", err)
-		return
+		return 0, fmt.Errorf("create staging table failed: %w", err)
+	}
+
+	_, err = tx.CopyFrom(
+		ctx,
+		pgx.Identifier{"delivery_staging"},
+		[]string{"symbol", "delivery_qty", "delivery_pct", "turnover"},
+		pgx.CopyFromRows(entries),
+	)
+	if err != nil {
+		return 0, fmt.Errorf("copy from failed: %w", err)
+	}
+
+	tag, err := tx.Exec(ctx, `
+		UPDATE stock_prices p SET
+			delivery_qty = s.delivery_qty,
+			delivery_pct = s.delivery_pct,
+			turnover = COALESCE(s.turnover, p.turnover)
+		FROM delivery_staging s
+		WHERE p.symbol = s.symbol
+			AND p.timestamp = ($1::date::timestamp AT TIME ZONE $2)
+	`, day, config.DB.Tz)
+	if err != nil {
+		return 0, fmt.Errorf("update failed: %w", err)
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return 0, fmt.Errorf("commit failed: %w", err)
 	}
 
-	log.Printf("deletion of delisted stocks done")
+	return tag.RowsAffected(), nil
 }
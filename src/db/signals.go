@@ -0,0 +1,104 @@
+package db
+
+import (
+	"context"
+	"eeye/src/metrics"
+	"eeye/src/utils"
+	"fmt"
+	"time"
+)
+
+// SaveSignals stores the stocks selected by a strategy on the given trading day,
+// replacing those of an earlier run on the same day
+func SaveSignals(ctx context.Context, day time.Time, strategy string, symbols []string) error {
+	defer metrics.DBQueryDuration.ObserveSince(time.Now(), "save_signals")
+
+	tx, err := Pool.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("begin transaction failed: %w", err)
+	}
+	defer func() {
+		_ = tx.Rollback(ctx)
+	}()
+
+	_, err = tx.Exec(ctx, `
+		INSERT INTO signal_runs (day, strategy, stocks)
+		VALUES ($1::date, $2, $3)
+		ON CONFLICT (strategy, day) DO UPDATE SET
+			stocks = EXCLUDED.stocks,
+			completed_at = NOW()
+	`, day.Format("2006-01-02"), strategy, len(symbols))
+	if err != nil {
+		return fmt.Errorf("upsert failed: %w", err)
+	}
+
+	_, err = tx.Exec(ctx, `
+		DELETE FROM signals
+		WHERE day = $1::date AND strategy = $2
+	`, day.Format("2006-01-02"), strategy)
+	if err != nil {
+		return fmt.Errorf("delete failed: %w", err)
+	}
+
+	_, err = tx.Exec(ctx, `
+		INSERT INTO signals (day, strategy, symbol)
+		SELECT $1::date, $2, symbol
+		FROM unnest($3::text[]) AS symbol
+		ON CONFLICT DO NOTHING
+	`, day.Format("2006-01-02"), strategy, symbols)
+	if err != nil {
+		return fmt.Errorf("insert failed: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("commit failed: %w", err)
+	}
+
+	return nil
+}
+
+// FetchPreviousSignals returns the trading day and the symbols (ordered) of the latest
+// run of a strategy before the given day. The day is nil if the strategy never ran before.
+func FetchPreviousSignals(ctx context.Context, strategy string, before time.Time) (*time.Time, []string, error) {
+	defer metrics.DBQueryDuration.ObserveSince(time.Now(), "fetch_previous_signals")
+
+	var previous *time.Time
+	err := Pool.QueryRow(ctx, `
+		SELECT MAX(day)
+		FROM signal_runs
+		WHERE strategy = $1 AND day < $2::date
+	`, strategy, before.Format("2006-01-02")).Scan(&previous)
+	if err != nil {
+		return nil, nil, fmt.Errorf("query failed: %w", err)
+	}
+
+	symbols := utils.EmptySlice[string]()
+	if previous == nil {
+		return nil, symbols, nil
+	}
+
+	rows, err := Pool.Query(ctx, `
+		SELECT symbol
+		FROM signals
+		WHERE strategy = $1 AND day = $2
+		ORDER BY symbol
+	`, strategy, *previous)
+	if err != nil {
+		return nil, nil, fmt.Errorf("query failed: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var symbol string
+		if err := rows.Scan(&symbol); err != nil {
+			return nil, nil, fmt.Errorf("scanning failed: %w", err)
+		}
+		symbols = append(symbols, symbol)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, nil, fmt.Errorf("rows error: %w", err)
+	}
+
+	return previous, symbols, nil
+}
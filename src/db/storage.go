@@ -0,0 +1,308 @@
+package db
+
+import (
+	"context"
+	"eeye/src/config"
+	"eeye/src/metrics"
+	"eeye/src/models"
+	"eeye/src/utils"
+	"fmt"
+	"io"
+	"log/slog"
+	"text/tabwriter"
+	"time"
+)
+
+// storagePolicy describes how a configurable TimescaleDB policy of stock_prices is
+// managed: the job procedure, the config key holding its interval and the SQL to
+// add (with the interval in days as $1) or remove it
+type storagePolicy struct {
+	name   string
+	proc   string
+	key    string
+	add    string
+	remove string
+}
+
+var (
+	compressionPolicy = storagePolicy{
+		name:   "compression",
+		proc:   "policy_compression",
+		key:    "compress_after",
+		add:    "SELECT add_compression_policy('stock_prices', compress_after => make_interval(days => $1::int))",
+		remove: "SELECT remove_compression_policy('stock_prices', if_exists => TRUE)",
+	}
+
+	retentionPolicy = storagePolicy{
+		name:   "retention",
+		proc:   "policy_retention",
+		key:    "drop_after",
+		add:    "SELECT add_retention_policy('stock_prices', drop_after => make_interval(days => $1::int))",
+		remove: "SELECT remove_retention_policy('stock_prices', if_exists => TRUE)",
+	}
+)
+
+// syncStoragePolicy makes the policy match the configured number of days.
+// Zero days removes the policy; a policy with another interval is replaced.
+func syncStoragePolicy(ctx context.Context, policy storagePolicy, days int) error {
+	if days == 0 {
+		if _, err := Pool.Exec(ctx, policy.remove); err != nil {
+			return fmt.Errorf("failed to remove %v policy: %w", policy.name, err)
+		}
+		return nil
+	}
+
+	var current bool
+	err := Pool.QueryRow(ctx, `
+		SELECT EXISTS (
+			SELECT 1 FROM timescaledb_information.jobs
+			WHERE proc_name = $1
+				AND hypertable_name = 'stock_prices'
+				AND (config->>$2)::interval = make_interval(days => $3::int)
+		)
+	`, policy.proc, policy.key, days).Scan(&current)
+	if err != nil {
+		return fmt.Errorf("query failed: %w", err)
+	}
+
+	if current {
+		return nil
+	}
+
+	if _, err := Pool.Exec(ctx, policy.remove); err != nil {
+		return fmt.Errorf("failed to remove %v policy: %w", policy.name, err)
+	}
+
+	if _, err := Pool.Exec(ctx, policy.add, days); err != nil {
+		return fmt.Errorf("failed to add %v policy: %w", policy.name, err)
+	}
+
+	slog.Info("storage policy updated", "policy", policy.name, "days", days)
+	return nil
+}
+
+// SyncStoragePolicies applies the configured compression and retention policies
+// to the stock_prices hypertable. It is idempotent and only touches policies whose
+// interval changed.
+func SyncStoragePolicies(ctx context.Context) error {
+	defer metrics.DBQueryDuration.ObserveSince(time.Now(), "sync_storage_policies")
+
+	if err := syncStoragePolicy(ctx, compressionPolicy, config.Storage.CompressAfterDays); err != nil {
+		return err
+	}
+
+	return syncStoragePolicy(ctx, retentionPolicy, config.Storage.RetentionDays)
+}
+
+// FetchChunkStats returns the chunks of stock_prices ordered by time, with their
+// size before and after compression (equal for uncompressed chunks)
+func FetchChunkStats(ctx context.Context) ([]models.ChunkStats, error) {
+	defer metrics.DBQueryDuration.ObserveSince(time.Now(), "fetch_chunk_stats")
+
+	rows, err := Pool.Query(ctx, `
+		SELECT
+			c.chunk_name, c.range_start, c.range_end, c.is_compressed,
+			COALESCE(s.before_compression_total_bytes, d.total_bytes),
+			COALESCE(s.after_compression_total_bytes, d.total_bytes)
+		FROM timescaledb_information.chunks c
+		JOIN chunks_detailed_size('stock_prices') d ON d.chunk_name = c.chunk_name
+		LEFT JOIN chunk_compression_stats('stock_prices') s ON s.chunk_name = c.chunk_name
+		WHERE c.hypertable_name = 'stock_prices'
+		ORDER BY c.range_start ASC
+	`)
+
+	var (
+		empty = utils.EmptySlice[models.ChunkStats]()
+		res   = make([]models.ChunkStats, 0)
+	)
+
+	if err != nil {
+		return empty, fmt.Errorf("query failed: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		chunk := models.ChunkStats{}
+
+		err := rows.Scan(
+			&chunk.Name,
+			&chunk.RangeStart,
+			&chunk.RangeEnd,
+			&chunk.Compressed,
+			&chunk.BeforeBytes,
+			&chunk.AfterBytes,
+		)
+		if err != nil {
+			return empty, fmt.Errorf("scan failed: %w", err)
+		}
+
+		res = append(res, chunk)
+	}
+
+	if err := rows.Err(); err != nil {
+		return empty, fmt.Errorf("rows error: %w", err)
+	}
+
+	return res, nil
+}
+
+// FetchStoragePolicies returns the compression, retention and continuous aggregate
+// refresh jobs of the database
+func FetchStoragePolicies(ctx context.Context) ([]models.StoragePolicy, error) {
+	defer metrics.DBQueryDuration.ObserveSince(time.Now(), "fetch_storage_policies")
+
+	rows, err := Pool.Query(ctx, `
+		SELECT
+			j.job_id, j.proc_name, COALESCE(j.hypertable_name, ''), j.schedule_interval::text,
+			COALESCE(j.config::text, ''), COALESCE(j.next_start, 'epoch'), COALESCE(s.last_run_status, '')
+		FROM timescaledb_information.jobs j
+		LEFT JOIN timescaledb_information.job_stats s ON s.job_id = j.job_id
+		WHERE j.proc_name IN ('policy_compression', 'policy_retention', 'policy_refresh_continuous_aggregate')
+		ORDER BY j.job_id ASC
+	`)
+
+	var (
+		empty = utils.EmptySlice[models.StoragePolicy]()
+		res   = make([]models.StoragePolicy, 0)
+	)
+
+	if err != nil {
+		return empty, fmt.Errorf("query failed: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		policy := models.StoragePolicy{}
+
+		err := rows.Scan(
+			&policy.JobID,
+			&policy.Kind,
+			&policy.Target,
+			&policy.Schedule,
+			&policy.Config,
+			&policy.NextStart,
+			&policy.LastRunStatus,
+		)
+		if err != nil {
+			return empty, fmt.Errorf("scan failed: %w", err)
+		}
+
+		res = append(res, policy)
+	}
+
+	if err := rows.Err(); err != nil {
+		return empty, fmt.Errorf("rows error: %w", err)
+	}
+
+	return res, nil
+}
+
+// formatBytes renders a size in bytes with a binary unit (e.g. 1.5 MiB)
+func formatBytes(bytes int64) string {
+	const unit = 1024
+	if bytes < unit {
+		return fmt.Sprintf("%d B", bytes)
+	}
+
+	div, exp := int64(unit), 0
+	for n := bytes / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(bytes)/float64(div), "KMGTPE"[exp])
+}
+
+// compressionRatio returns before/after, or zero when nothing was stored
+func compressionRatio(before int64, after int64) float64 {
+	if after == 0 {
+		return 0
+	}
+	return float64(before) / float64(after)
+}
+
+// ChunkReport writes the chunks of stock_prices with their sizes and compression ratios
+func ChunkReport(ctx context.Context, w io.Writer) error {
+	chunks, err := FetchChunkStats(ctx)
+	if err != nil {
+		return err
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintln(tw, "CHUNK\tFROM\tTO\tCOMPRESSED\tBEFORE\tAFTER\tRATIO")
+	for i := range chunks {
+		chunk := &chunks[i]
+		_, _ = fmt.Fprintf(
+			tw,
+			"%v\t%v\t%v\t%v\t%v\t%v\t%.1fx\n",
+			chunk.Name,
+			chunk.RangeStart.Format("2006-01-02"),
+			chunk.RangeEnd.Format("2006-01-02"),
+			chunk.Compressed,
+			formatBytes(chunk.BeforeBytes),
+			formatBytes(chunk.AfterBytes),
+			compressionRatio(chunk.BeforeBytes, chunk.AfterBytes),
+		)
+	}
+	return tw.Flush()
+}
+
+// StorageReport writes the total size and compression ratio of stock_prices
+// together with the TimescaleDB policies managing it
+func StorageReport(ctx context.Context, w io.Writer) error {
+	chunks, err := FetchChunkStats(ctx)
+	if err != nil {
+		return err
+	}
+
+	policies, err := FetchStoragePolicies(ctx)
+	if err != nil {
+		return err
+	}
+
+	var (
+		compressed int
+		before     int64
+		after      int64
+		tw         = tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	)
+	for i := range chunks {
+		if chunks[i].Compressed {
+			compressed++
+		}
+		before += chunks[i].BeforeBytes
+		after += chunks[i].AfterBytes
+	}
+
+	_, _ = fmt.Fprintf(
+		tw,
+		"stock_prices: %d chunks (%d compressed), %v uncompressed, %v stored, ratio %.1fx\n",
+		len(chunks),
+		compressed,
+		formatBytes(before),
+		formatBytes(after),
+		compressionRatio(before, after),
+	)
+	_, _ = fmt.Fprintf(
+		tw,
+		"configured: compress after %d days, retention %d days (0 disables)\n\n",
+		config.Storage.CompressAfterDays,
+		config.Storage.RetentionDays,
+	)
+
+	_, _ = fmt.Fprintln(tw, "JOB\tPOLICY\tHYPERTABLE\tSCHEDULE\tNEXT RUN\tLAST RUN\tCONFIG")
+	for i := range policies {
+		policy := &policies[i]
+		_, _ = fmt.Fprintf(
+			tw,
+			"%d\t%v\t%v\t%v\t%v\t%v\t%v\n",
+			policy.JobID,
+			policy.Kind,
+			policy.Target,
+			policy.Schedule,
+			policy.NextStart.Format(time.RFC3339),
+			policy.LastRunStatus,
+			policy.Config,
+		)
+	}
+	return tw.Flush()
+}
@@ -0,0 +1,41 @@
+package db
+
+import "testing"
+
+func TestFormatBytes(t *testing.T) {
+	tests := []struct {
+		bytes int64
+		want  string
+	}{
+		{bytes: 0, want: "0 B"},
+		{bytes: 1023, want: "1023 B"},
+		{bytes: 1024, want: "1.0 KiB"},
+		{bytes: 1536, want: "1.5 KiB"},
+		{bytes: 5 * 1024 * 1024, want: "5.0 MiB"},
+		{bytes: 3 * 1024 * 1024 * 1024, want: "3.0 GiB"},
+	}
+
+	for _, tt := range tests {
+		if got := formatBytes(tt.bytes); got != tt.want {
+			t.Errorf("formatBytes(%d) = %v, want %v", tt.bytes, got, tt.want)
+		}
+	}
+}
+
+func TestCompressionRatio(t *testing.T) {
+	tests := []struct {
+		before int64
+		after  int64
+		want   float64
+	}{
+		{before: 0, after: 0, want: 0},
+		{before: 100, after: 100, want: 1},
+		{before: 1000, after: 100, want: 10},
+	}
+
+	for _, tt := range tests {
+		if got := compressionRatio(tt.before, tt.after); got != tt.want {
+			t.Errorf("compressionRatio(%d, %d) = %v, want %v", tt.before, tt.after, got, tt.want)
+		}
+	}
+}
@@ -2,35 +2,112 @@
 package handlers
 
 import (
+	"crypto/rand"
+	"eeye/src/config"
+	"eeye/src/constants"
+	"encoding/hex"
 	"io"
 	"log"
+	"log/slog"
 	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	// logFilePrefix and logFileSuffix frame the run id in the name of a run log file
+	logFilePrefix = "eeye-"
+	logFileSuffix = ".log"
 )
 
 // AppLog creates a instance to start capturing the app logs
 type AppLog struct {
 	handle *os.File
+
+	// RunID identifies the run in every log record and in the name of its log file
+	RunID string
+}
+
+// newRunID returns a sortable run id made of the start time and a random suffix,
+// e.g. 20250102-183000-1a2b3c
+func newRunID(now time.Time) string {
+	suffix := make([]byte, 3)
+	_, _ = rand.Read(suffix)
+	return now.Format("20060102-150405") + "-" + hex.EncodeToString(suffix)
+}
+
+// rotateLogs deletes the oldest run log files in dir so that at most keep files remain.
+// Run ids start with the start time, so the names sort in chronological order.
+func rotateLogs(dir string, keep int) error {
+	if keep <= 0 {
+		return nil
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return err
+	}
+
+	files := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		name := entry.Name()
+		if !entry.IsDir() && strings.HasPrefix(name, logFilePrefix) && strings.HasSuffix(name, logFileSuffix) {
+			files = append(files, name)
+		}
+	}
+	sort.Strings(files)
+
+	for len(files) > keep {
+		if err := os.Remove(filepath.Join(dir, files[0])); err != nil {
+			return err
+		}
+		files = files[1:]
+	}
+	return nil
 }
 
-// Init initializes the log output based on verbose flag
-// If verbose is true, writes to both stdout and app.log file
-// If verbose is false, writes only to app.log file
+// newHandler returns the slog handler writing records to w in the configured format
+func newHandler(w io.Writer, format string, level slog.Leveler) slog.Handler {
+	opts := &slog.HandlerOptions{Level: level}
+	if format == constants.LogFormatJSON {
+		return slog.NewJSONHandler(w, opts)
+	}
+	return slog.NewTextHandler(w, opts)
+}
+
+// Init opens a new log file for this run in config.Log.Dir and installs a structured
+// logger writing to it (and to stdout if verbose is true) as the default slog logger.
+// Every record carries the run id. Output of the standard log package is routed through
+// the same logger at info level. Only the latest config.Log.MaxFiles run logs are kept.
 func (a *AppLog) Init(verbose bool) {
-	logFile, err := os.OpenFile("app.log", os.O_CREATE|os.O_WRONLY, 0666)
+	if err := os.MkdirAll(config.Log.Dir, 0o755); err != nil {
+		log.Fatal(err)
+	}
+
+	a.RunID = newRunID(time.Now())
+	path := filepath.Join(config.Log.Dir, logFilePrefix+a.RunID+logFileSuffix)
+	logFile, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY|os.O_APPEND, 0o644)
 	if err != nil {
 		log.Fatal(err)
 	}
+	a.handle = logFile
 
+	var w io.Writer = logFile
 	if verbose {
-		mw := io.MultiWriter(os.Stdout, logFile)
-		log.SetOutput(mw)
-	} else {
-		log.SetOutput(logFile)
+		w = io.MultiWriter(os.Stdout, logFile)
 	}
-	a.handle = logFile
+
+	slog.SetDefault(slog.New(newHandler(w, config.Log.Format, config.Log.Level)).With("run_id", a.RunID))
+
+	if err := rotateLogs(config.Log.Dir, config.Log.MaxFiles); err != nil {
+		slog.Warn("log rotation failed", "dir", config.Log.Dir, "err", err)
+	}
+	slog.Info("logging to file", "path", path, "level", config.Log.Level.String(), "format", config.Log.Format)
 }
 
-// Close closes the multi-writer
+// Close closes the log file of the run
 func (a *AppLog) Close() {
 	if a.handle != nil {
 		_ = a.handle.Close()
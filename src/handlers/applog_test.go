@@ -0,0 +1,84 @@
+package handlers
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+)
+
+func TestNewRunID(t *testing.T) {
+	now := time.Date(2025, 1, 2, 18, 30, 0, 0, time.UTC)
+
+	a, b := newRunID(now), newRunID(now)
+	if a[:16] != "20250102-183000-" || len(a) != 22 {
+		t.Errorf("newRunID() = %v, want 20250102-183000-<6 hex digits>", a)
+	}
+	if a == b {
+		t.Errorf("newRunID() returned %v twice", a)
+	}
+}
+
+func TestRotateLogs(t *testing.T) {
+	tests := []struct {
+		name string
+		keep int
+		want []string
+	}{
+		{
+			name: "keeps the latest runs",
+			keep: 2,
+			want: []string{"eeye-20250103-000000-aaaaaa.log", "eeye-20250104-000000-aaaaaa.log", "notes.txt"},
+		},
+		{
+			name: "zero keeps all runs",
+			keep: 0,
+			want: []string{
+				"eeye-20250101-000000-aaaaaa.log",
+				"eeye-20250102-000000-aaaaaa.log",
+				"eeye-20250103-000000-aaaaaa.log",
+				"eeye-20250104-000000-aaaaaa.log",
+				"notes.txt",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			files := []string{
+				"eeye-20250103-000000-aaaaaa.log",
+				"eeye-20250101-000000-aaaaaa.log",
+				"eeye-20250104-000000-aaaaaa.log",
+				"eeye-20250102-000000-aaaaaa.log",
+				// files which are not run logs are never deleted
+				"notes.txt",
+			}
+			for _, name := range files {
+				if err := os.WriteFile(filepath.Join(dir, name), nil, 0o644); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			if err := rotateLogs(dir, tt.keep); err != nil {
+				t.Fatalf("rotateLogs() error = %v", err)
+			}
+
+			entries, err := os.ReadDir(dir)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := make([]string, 0, len(entries))
+			for _, entry := range entries {
+				got = append(got, entry.Name())
+			}
+			sort.Strings(got)
+
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("rotateLogs() left %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -1,14 +1,20 @@
 package handlers
 
 import (
+	"context"
 	"os"
 	"os/signal"
 	"syscall"
 )
 
-// GetInterruptHandlerChannel returns a channel that can be used to capture keyboard interrupts
-func GetInterruptHandlerChannel() <-chan os.Signal {
-	quit := make(chan os.Signal, 1)
-	signal.Notify(quit, os.Interrupt, syscall.SIGTERM)
-	return quit
+// GetInterruptContext returns a context which is cancelled on keyboard interrupts (SIGINT)
+// or SIGTERM, and a function to release it. Once the first signal has been caught the
+// default behaviour is restored, so a second signal terminates the process immediately.
+func GetInterruptContext() (context.Context, context.CancelFunc) {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	go func() {
+		<-ctx.Done()
+		stop()
+	}()
+	return ctx, stop
 }
@@ -6,7 +6,7 @@ import (
 	"eeye/src/models"
 	"eeye/src/utils"
 	"fmt"
-	"log"
+	"log/slog"
 	"net/url"
 	"strings"
 
@@ -14,7 +14,7 @@ import (
 )
 
 func handleResource(
-	_ context.Context,
+	ctx context.Context,
 	req *mcp.ReadResourceRequest,
 ) (*mcp.ReadResourceResult, error) {
 	u, err := url.Parse(req.Params.URI)
@@ -23,16 +23,16 @@ func handleResource(
 	}
 
 	scheme := u.Scheme
-	log.Printf("HandleResource scheme: %v\n", scheme)
+	slog.Debug("handling resource", "scheme", scheme)
 
 	switch scheme {
 	case "db":
 		resource := u.Opaque
-		log.Printf("HandleResource resource: %v\n", resource)
+		slog.Debug("handling resource", "resource", resource)
 
 		switch resource {
 		case "stocks":
-			return handleStocksResource(req)
+			return handleStocksResource(ctx, req)
 		default:
 			return nil, fmt.Errorf("invalid resource: %v", resource)
 		}
@@ -41,8 +41,8 @@ func handleResource(
 	return nil, fmt.Errorf("invalid scheme: %v", scheme)
 }
 
-func handleStocksResource(req *mcp.ReadResourceRequest) (*mcp.ReadResourceResult, error) {
-	stocks, err := db.FetchAllStocks()
+func handleStocksResource(ctx context.Context, req *mcp.ReadResourceRequest) (*mcp.ReadResourceResult, error) {
+	stocks, err := db.FetchAllStocks(ctx)
 	if err != nil {
 		return nil, fmt.Errorf("failed to fetch stocks: %w", err)
 	}
@@ -61,6 +61,101 @@ type GetOhlcDataOutput struct {
 	Data   []OhlcWithTimestamp `json:"data"`
 }
 
+//revive:disable-next-line exported
+type CreateAlertInput struct {
+	Symbol    string  `json:"symbol"`
+	Condition string  `json:"condition"`
+	Level     float64 `json:"level,omitempty"`
+	Period    int     `json:"period,omitempty"`
+	Direction string  `json:"direction"`
+	Expires   string  `json:"expires,omitempty"`
+}
+
+//revive:disable-next-line exported
+type ListAlertsInput struct {
+	Symbol string `json:"symbol,omitempty"`
+	All    bool   `json:"all,omitempty"`
+}
+
+//revive:disable-next-line exported
+type DeleteAlertInput struct {
+	ID int64 `json:"id"`
+}
+
+//revive:disable-next-line exported
+type AlertData struct {
+	ID             int64   `json:"id"`
+	Symbol         string  `json:"symbol"`
+	Condition      string  `json:"condition"`
+	Level          float64 `json:"level,omitempty"`
+	Period         int     `json:"period,omitempty"`
+	Direction      string  `json:"direction"`
+	Expires        string  `json:"expires,omitempty"`
+	Status         string  `json:"status"`
+	CreatedAt      string  `json:"created_at"`
+	TriggeredOn    string  `json:"triggered_on,omitempty"`
+	TriggeredLevel float64 `json:"triggered_level,omitempty"`
+	TriggeredClose float64 `json:"triggered_close,omitempty"`
+}
+
+//revive:disable-next-line exported
+type CreateAlertOutput struct {
+	Alert AlertData `json:"alert"`
+}
+
+//revive:disable-next-line exported
+type ListAlertsOutput struct {
+	Alerts []AlertData `json:"alerts"`
+}
+
+//revive:disable-next-line exported
+type DeleteAlertOutput struct {
+	ID      int64 `json:"id"`
+	Deleted bool  `json:"deleted"`
+}
+
+// alertDataSchema is the jsonrpc schema of an alert in tool outputs
+var alertDataSchema = jsonschema.Object(
+	jsonschema.Prop("id", jsonschema.Integer()),
+	jsonschema.Prop("symbol", jsonschema.String()),
+	jsonschema.Prop("condition", jsonschema.Enum("price", "ema", "support", "resistance")),
+	jsonschema.Prop("level",
+		jsonschema.Number(
+			jsonschema.Description("Price level of a price alert"),
+		),
+	),
+	jsonschema.Prop("period",
+		jsonschema.Integer(
+			jsonschema.Description("Number of sessions of the EMA of an ema alert"),
+		),
+	),
+	jsonschema.Prop("direction", jsonschema.Enum("above", "below")),
+	jsonschema.Prop("expires",
+		jsonschema.String(
+			jsonschema.Format("date"),
+			jsonschema.Description("Last trading day the alert is evaluated on"),
+		),
+	),
+	jsonschema.Prop("status", jsonschema.Enum("active", "triggered", "expired")),
+	jsonschema.Prop("created_at", jsonschema.String(jsonschema.Format("date-time"))),
+	jsonschema.Prop("triggered_on",
+		jsonschema.String(
+			jsonschema.Format("date"),
+			jsonschema.Description("Trading day of the candle which triggered the alert"),
+		),
+	),
+	jsonschema.Prop("triggered_level",
+		jsonschema.Number(
+			jsonschema.Description("Level crossed when the alert triggered"),
+		),
+	),
+	jsonschema.Prop("triggered_close",
+		jsonschema.Number(
+			jsonschema.Description("Close of the candle which triggered the alert"),
+		),
+	),
+)
+
 var (
 	// GetTechnicalDataInputSchema is the jsonrpc schema for GetTechnicalData tool input
 	GetTechnicalDataInputSchema = jsonschema.Object(
@@ -168,6 +263,89 @@ var (
 			),
 		),
 	)
+	// CreateAlertInputSchema is the jsonrpc schema for CreateAlert tool input
+	CreateAlertInputSchema = jsonschema.Object(
+		jsonschema.Prop(
+			"symbol",
+			jsonschema.String(
+				jsonschema.MinLen(1),
+				jsonschema.Examples("ZOMATO"),
+			),
+		),
+		jsonschema.Prop(
+			"condition",
+			jsonschema.Enum("price", "ema", "support", "resistance"),
+		),
+		jsonschema.Prop(
+			"level",
+			jsonschema.Number(
+				jsonschema.ExclusiveMin(0),
+				jsonschema.Description("Price level, required by the price condition"),
+			),
+		),
+		jsonschema.Prop(
+			"period",
+			jsonschema.Integer(
+				jsonschema.Min(2),
+				jsonschema.Max(200),
+				jsonschema.Description("Number of sessions of the EMA, required by the ema condition"),
+			),
+		),
+		jsonschema.Prop(
+			"direction",
+			jsonschema.Enum("above", "below"),
+		),
+		jsonschema.Prop(
+			"expires",
+			jsonschema.String(
+				jsonschema.Format("date"),
+				jsonschema.Description("Last trading day the alert is evaluated on, e.g. 2025-03-31 (default never)"),
+			),
+		),
+		jsonschema.Required("symbol", "condition", "direction"),
+	)
+	// CreateAlertOutputSchema is the jsonrpc schema for CreateAlert tool output
+	CreateAlertOutputSchema = jsonschema.Object(
+		jsonschema.Prop("alert", alertDataSchema),
+	)
+	// ListAlertsInputSchema is the jsonrpc schema for ListAlerts tool input
+	ListAlertsInputSchema = jsonschema.Object(
+		jsonschema.Prop(
+			"symbol",
+			jsonschema.String(
+				jsonschema.Description("Only list the alerts of this symbol"),
+				jsonschema.Examples("ZOMATO"),
+			),
+		),
+		jsonschema.Prop(
+			"all",
+			jsonschema.Boolean(
+				jsonschema.Description("Also list the triggered and expired alerts"),
+			),
+		),
+	)
+	// ListAlertsOutputSchema is the jsonrpc schema for ListAlerts tool output
+	ListAlertsOutputSchema = jsonschema.Object(
+		jsonschema.Prop("alerts",
+			jsonschema.Array(
+				jsonschema.Items(alertDataSchema),
+			),
+		),
+	)
+	// DeleteAlertInputSchema is the jsonrpc schema for DeleteAlert tool input
+	DeleteAlertInputSchema = jsonschema.Object(
+		jsonschema.Prop("id", jsonschema.Integer(jsonschema.Min(1))),
+		jsonschema.Required("id"),
+	)
+	// DeleteAlertOutputSchema is the jsonrpc schema for DeleteAlert tool output
+	DeleteAlertOutputSchema = jsonschema.Object(
+		jsonschema.Prop("id", jsonschema.Integer()),
+		jsonschema.Prop("deleted",
+			jsonschema.Boolean(
+				jsonschema.Description("False if no alert has this ID"),
+			),
+		),
+	)
 )
 
 // ResolvedSchema stores the schema of tools in JSON format ([]byte)
@@ -187,6 +365,12 @@ func init() {
 		GetTechnicalDataOutputSchema,
 		GetOhlcDataInputSchema,
 		GetOhlcDataOutputSchema,
+		CreateAlertInputSchema,
+		CreateAlertOutputSchema,
+		ListAlertsInputSchema,
+		ListAlertsOutputSchema,
+		DeleteAlertInputSchema,
+		DeleteAlertOutputSchema,
 	}
 
 	for i := range schemas {
@@ -2,16 +2,25 @@
 package mcp
 
 import (
+	"context"
 	"eeye/src/config"
+	"errors"
 	"fmt"
 	"log"
+	"log/slog"
+	"net"
 	"net/http"
+	"time"
 
 	"github.com/modelcontextprotocol/go-sdk/mcp"
 )
 
-// Init is a facade for MCP server functionality
-func Init() {
+// shutdownTimeout bounds the time in-flight MCP requests get to finish on shutdown
+const shutdownTimeout = 5 * time.Second
+
+// Init is a facade for MCP server functionality.
+// It serves until ctx is cancelled and then shuts the HTTP server down gracefully.
+func Init(ctx context.Context) {
 	serverImpl := &mcp.Implementation{
 		Name:    "eeye-mcp",
 		Version: "v0.0.1",
@@ -36,8 +45,27 @@ func Init() {
 	)
 
 	url := fmt.Sprintf("%v:%v", config.MCP.Host, config.MCP.Port)
-	log.Printf("Starting MCP HTTP streamable transport server on %v\n", url)
-	if err := http.ListenAndServe(url, reqHandler); err != nil {
+	slog.Info("starting MCP HTTP streamable transport server", "addr", url)
+	httpServer := &http.Server{
+		Addr:              url,
+		Handler:           reqHandler,
+		ReadHeaderTimeout: 10 * time.Second,
+		BaseContext: func(_ net.Listener) context.Context {
+			return ctx
+		},
+	}
+
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+		defer cancel()
+		if err := httpServer.Shutdown(shutdownCtx); err != nil {
+			slog.Error("MCP server shutdown failed", "err", err)
+		}
+	}()
+
+	if err := httpServer.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
 		log.Fatal(err)
 	}
+	slog.Info("MCP server stopped")
 }
@@ -2,6 +2,8 @@ package mcp
 
 import (
 	"context"
+	"eeye/src/alerts"
+	"eeye/src/calendar"
 	"eeye/src/db"
 	"eeye/src/models"
 	"eeye/src/steps"
@@ -9,12 +11,14 @@ import (
 	"encoding/json"
 	"fmt"
 	"sort"
+	"strings"
+	"time"
 
 	"github.com/modelcontextprotocol/go-sdk/mcp"
 )
 
 func getTechnicalData(
-	_ context.Context,
+	ctx context.Context,
 	_ *mcp.CallToolRequest,
 	input GetTechnicalDataInput,
 ) (*mcp.CallToolResult, GetTechnicalDataOutput, error) {
@@ -29,7 +33,7 @@ func getTechnicalData(
 		Segment:  "CASH",
 		Name:     input.Symbol,
 	}
-	candles, err := db.FetchAllCandles(&stock)
+	candles, err := db.FetchAllCandles(ctx, &stock)
 	if err != nil {
 		return nil, GetTechnicalDataOutput{}, fmt.Errorf("db failure: %v", err)
 	}
@@ -86,7 +90,7 @@ func getTechnicalData(
 }
 
 func getOhlcData(
-	_ context.Context,
+	ctx context.Context,
 	_ *mcp.CallToolRequest,
 	input GetOhlcDataInput,
 ) (*mcp.CallToolResult, GetOhlcDataOutput, error) {
@@ -101,7 +105,7 @@ func getOhlcData(
 		Segment:  "CASH",
 		Name:     input.Symbol,
 	}
-	candles, err := db.FetchAllCandles(&stock)
+	candles, err := db.FetchAllCandles(ctx, &stock)
 	if err != nil {
 		return nil, GetOhlcDataOutput{}, fmt.Errorf("db failure: %v", err)
 	}
@@ -137,6 +141,116 @@ func getOhlcData(
 	return nil, out, nil
 }
 
+// alertData returns the tool output of an alert, with its status on the given day
+func alertData(alert *models.Alert, today time.Time) AlertData {
+	data := AlertData{
+		ID:             alert.ID,
+		Symbol:         alert.Symbol,
+		Condition:      alert.Condition,
+		Level:          alert.Level,
+		Period:         alert.Period,
+		Direction:      alert.Direction,
+		Status:         alert.Status(today),
+		CreatedAt:      alert.CreatedAt.Format(time.RFC3339),
+		TriggeredLevel: utils.Round2(alert.TriggeredLevel),
+		TriggeredClose: utils.Round2(alert.TriggeredClose),
+	}
+	if alert.ExpiresOn != nil {
+		data.Expires = alert.ExpiresOn.Format("2006-01-02")
+	}
+	if alert.TriggeredOn != nil {
+		data.TriggeredOn = alert.TriggeredOn.Format("2006-01-02")
+	}
+	return data
+}
+
+func createAlert(
+	ctx context.Context,
+	_ *mcp.CallToolRequest,
+	input CreateAlertInput,
+) (*mcp.CallToolResult, CreateAlertOutput, error) {
+	alert := &models.Alert{
+		Symbol:    input.Symbol,
+		Condition: input.Condition,
+		Level:     input.Level,
+		Period:    input.Period,
+		Direction: input.Direction,
+	}
+
+	// The enums of the schema are lower case, like the conditions and directions stored
+	alerts.Normalize(alert)
+	input.Symbol, input.Condition, input.Direction = alert.Symbol, alert.Condition, alert.Direction
+
+	res := CreateAlertInputSchema.Validate(input)
+	if !res.IsValid() {
+		return nil, CreateAlertOutput{}, fmt.Errorf("schema error: %v", res.Error())
+	}
+
+	if input.Expires != "" {
+		day, err := time.Parse("2006-01-02", input.Expires)
+		if err != nil {
+			return nil, CreateAlertOutput{}, fmt.Errorf("invalid expires %q, expected YYYY-MM-DD", input.Expires)
+		}
+		alert.ExpiresOn = &day
+	}
+
+	if err := alerts.Create(ctx, alert); err != nil {
+		return nil, CreateAlertOutput{}, fmt.Errorf("alert not created: %v", err)
+	}
+
+	return nil, CreateAlertOutput{Alert: alertData(alert, calendar.NSE.Day(utils.Now()))}, nil
+}
+
+func listAlerts(
+	ctx context.Context,
+	_ *mcp.CallToolRequest,
+	input ListAlertsInput,
+) (*mcp.CallToolResult, ListAlertsOutput, error) {
+	res := ListAlertsInputSchema.Validate(input)
+	if !res.IsValid() {
+		return nil, ListAlertsOutput{}, fmt.Errorf("schema error: %v", res.Error())
+	}
+
+	stored, err := db.FetchAlerts(ctx)
+	if err != nil {
+		return nil, ListAlertsOutput{}, fmt.Errorf("db failure: %v", err)
+	}
+
+	var (
+		today = calendar.NSE.Day(utils.Now())
+		out   = ListAlertsOutput{Alerts: make([]AlertData, 0, len(stored))}
+	)
+	for i := range stored {
+		if input.Symbol != "" && !strings.EqualFold(stored[i].Symbol, input.Symbol) {
+			continue
+		}
+		if !input.All && stored[i].Status(today) != models.AlertActive {
+			continue
+		}
+		out.Alerts = append(out.Alerts, alertData(&stored[i], today))
+	}
+
+	return nil, out, nil
+}
+
+func deleteAlert(
+	ctx context.Context,
+	_ *mcp.CallToolRequest,
+	input DeleteAlertInput,
+) (*mcp.CallToolResult, DeleteAlertOutput, error) {
+	res := DeleteAlertInputSchema.Validate(input)
+	if !res.IsValid() {
+		return nil, DeleteAlertOutput{}, fmt.Errorf("schema error: %v", res.Error())
+	}
+
+	deleted, err := db.DeleteAlert(ctx, input.ID)
+	if err != nil {
+		return nil, DeleteAlertOutput{}, fmt.Errorf("db failure: %v", err)
+	}
+
+	return nil, DeleteAlertOutput{ID: input.ID, Deleted: deleted}, nil
+}
+
 func addTools(server *mcp.Server) {
 	mcp.AddTool(
 		server,
@@ -161,4 +275,41 @@ func addTools(server *mcp.Server) {
 		},
 		getOhlcData,
 	)
+
+	mcp.AddTool(
+		server,
+		&mcp.Tool{
+			Name:  "createAlert",
+			Title: "Create a price alert",
+			Description: "Watches a symbol for its close crossing a price, an EMA or a support/resistance level " +
+				"above or below; alerts are evaluated after every ingestion and trigger once",
+			InputSchema:  json.RawMessage(ResolvedSchema[CreateAlertInputSchema]),
+			OutputSchema: json.RawMessage(ResolvedSchema[CreateAlertOutputSchema]),
+		},
+		createAlert,
+	)
+
+	mcp.AddTool(
+		server,
+		&mcp.Tool{
+			Name:         "listAlerts",
+			Title:        "List price alerts",
+			Description:  "Gives the active price alerts, optionally of a symbol or including the triggered and expired ones",
+			InputSchema:  json.RawMessage(ResolvedSchema[ListAlertsInputSchema]),
+			OutputSchema: json.RawMessage(ResolvedSchema[ListAlertsOutputSchema]),
+		},
+		listAlerts,
+	)
+
+	mcp.AddTool(
+		server,
+		&mcp.Tool{
+			Name:         "deleteAlert",
+			Title:        "Delete a price alert",
+			Description:  "Deletes the price alert with the given ID",
+			InputSchema:  json.RawMessage(ResolvedSchema[DeleteAlertInputSchema]),
+			OutputSchema: json.RawMessage(ResolvedSchema[DeleteAlertOutputSchema]),
+		},
+		deleteAlert,
+	)
 }
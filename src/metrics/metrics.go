@@ -0,0 +1,332 @@
+// Package metrics instruments the ingestion and analysis pipeline.
+// Measurements are exposed in the Prometheus text format on /metrics and
+// summarized in the log at the end of every run.
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// labelSeparator joins label values into a map key; it never occurs in a label value
+const labelSeparator = "\xff"
+
+// durationBuckets are the upper bounds (in seconds) of the duration histograms.
+// They range from the sub-millisecond cost of a strategy to the multi-second bulk queries.
+var durationBuckets = []float64{0.0001, 0.0005, 0.001, 0.005, 0.01, 0.05, 0.1, 0.5, 1, 5, 10}
+
+// collector is a metric which can be written in the Prometheus text format
+type collector interface {
+	write(w io.Writer)
+}
+
+// registry lists the collectors exposed on /metrics in the order they are written
+var registry []collector
+
+// key joins label values into a map key
+func key(values []string) string {
+	return strings.Join(values, labelSeparator)
+}
+
+// formatLabels renders the labels of a series, e.g. {query="fetch_all_stocks"}
+func formatLabels(names []string, joined string, extra ...string) string {
+	pairs := make([]string, 0, len(names)+1)
+	if len(names) > 0 {
+		values := strings.Split(joined, labelSeparator)
+		for i := range names {
+			pairs = append(pairs, fmt.Sprintf("%v=%q", names[i], values[i]))
+		}
+	}
+	if len(extra) == 2 {
+		pairs = append(pairs, fmt.Sprintf("%v=%q", extra[0], extra[1]))
+	}
+
+	if len(pairs) == 0 {
+		return ""
+	}
+	return "{" + strings.Join(pairs, ",") + "}"
+}
+
+// formatFloat renders a sample value the way Prometheus expects it
+func formatFloat(v float64) string {
+	if math.IsInf(v, 1) {
+		return "+Inf"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+// sortedKeys returns the keys of a series map in a stable order
+func sortedKeys[T any](series map[string]T) []string {
+	keys := make([]string, 0, len(series))
+	for k := range series {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// Counter is a monotonically increasing value per combination of label values
+type Counter struct {
+	name   string
+	help   string
+	labels []string
+
+	mu     sync.Mutex
+	values map[string]float64
+}
+
+// newCounter creates and registers a counter
+func newCounter(name, help string, labels ...string) *Counter {
+	c := &Counter{name: name, help: help, labels: labels, values: make(map[string]float64)}
+	registry = append(registry, c)
+	return c
+}
+
+// Inc increments the counter of the given label values by one
+func (c *Counter) Inc(values ...string) {
+	c.Add(1, values...)
+}
+
+// Add increments the counter of the given label values by delta
+func (c *Counter) Add(delta float64, values ...string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.values[key(values)] += delta
+}
+
+// Value returns the counter of the given label values
+func (c *Counter) Value(values ...string) float64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.values[key(values)]
+}
+
+// snapshot copies the counters keyed by joined label values
+func (c *Counter) snapshot() map[string]float64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	res := make(map[string]float64, len(c.values))
+	for k, v := range c.values {
+		res[k] = v
+	}
+	return res
+}
+
+func (c *Counter) write(w io.Writer) {
+	values := c.snapshot()
+
+	fmt.Fprintf(w, "# HELP %v %v\n# TYPE %v counter\n", c.name, c.help, c.name)
+	for _, k := range sortedKeys(values) {
+		fmt.Fprintf(w, "%v%v %v\n", c.name, formatLabels(c.labels, k), formatFloat(values[k]))
+	}
+}
+
+// Gauge is a value which goes up and down, e.g. the depth of a queue.
+// The peak since the last reset is kept for the run summary.
+type Gauge struct {
+	name string
+	help string
+
+	mu    sync.Mutex
+	value float64
+	peak  float64
+}
+
+// newGauge creates and registers a gauge
+func newGauge(name, help string) *Gauge {
+	g := &Gauge{name: name, help: help}
+	registry = append(registry, g)
+	return g
+}
+
+// Set sets the gauge to v
+func (g *Gauge) Set(v float64) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	g.value = v
+	g.peak = max(g.peak, v)
+}
+
+// Value returns the current value of the gauge
+func (g *Gauge) Value() float64 {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	return g.value
+}
+
+// Peak returns the highest value since the last reset
+func (g *Gauge) Peak() float64 {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	return g.peak
+}
+
+// resetPeak forgets the peak of a previous run
+func (g *Gauge) resetPeak() {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	g.peak = g.value
+}
+
+func (g *Gauge) write(w io.Writer) {
+	fmt.Fprintf(w, "# HELP %v %v\n# TYPE %v gauge\n%v %v\n", g.name, g.help, g.name, g.name, formatFloat(g.Value()))
+}
+
+// histogramSeries holds the observations of a single combination of label values
+type histogramSeries struct {
+	// counts holds the observations per bucket (not cumulative)
+	counts []uint64
+	count  uint64
+	sum    float64
+}
+
+// Histogram counts observations (in seconds) in durationBuckets per combination of label values
+type Histogram struct {
+	name   string
+	help   string
+	labels []string
+
+	mu     sync.Mutex
+	series map[string]*histogramSeries
+}
+
+// newHistogram creates and registers a histogram
+func newHistogram(name, help string, labels ...string) *Histogram {
+	h := &Histogram{name: name, help: help, labels: labels, series: make(map[string]*histogramSeries)}
+	registry = append(registry, h)
+	return h
+}
+
+// Observe records a value for the given label values
+func (h *Histogram) Observe(v float64, values ...string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	k := key(values)
+	s, ok := h.series[k]
+	if !ok {
+		s = &histogramSeries{counts: make([]uint64, len(durationBuckets)+1)}
+		h.series[k] = s
+	}
+
+	s.counts[sort.SearchFloat64s(durationBuckets, v)]++
+	s.count++
+	s.sum += v
+}
+
+// ObserveSince records the time elapsed since start for the given label values.
+// It is meant to be deferred: defer metrics.DBQueryDuration.ObserveSince(time.Now(), "query")
+func (h *Histogram) ObserveSince(start time.Time, values ...string) {
+	h.Observe(time.Since(start).Seconds(), values...)
+}
+
+// totals returns the number and sum of observations keyed by joined label values
+func (h *Histogram) totals() map[string]histogramSeries {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	res := make(map[string]histogramSeries, len(h.series))
+	for k, s := range h.series {
+		res[k] = histogramSeries{counts: append([]uint64(nil), s.counts...), count: s.count, sum: s.sum}
+	}
+	return res
+}
+
+func (h *Histogram) write(w io.Writer) {
+	series := h.totals()
+
+	fmt.Fprintf(w, "# HELP %v %v\n# TYPE %v histogram\n", h.name, h.help, h.name)
+	for _, k := range sortedKeys(series) {
+		s := series[k]
+
+		cumulative := uint64(0)
+		for i := range s.counts {
+			bound := math.Inf(1)
+			if i < len(durationBuckets) {
+				bound = durationBuckets[i]
+			}
+
+			cumulative += s.counts[i]
+			fmt.Fprintf(
+				w,
+				"%v_bucket%v %d\n",
+				h.name,
+				formatLabels(h.labels, k, "le", formatFloat(bound)),
+				cumulative,
+			)
+		}
+		fmt.Fprintf(w, "%v_sum%v %v\n", h.name, formatLabels(h.labels, k), formatFloat(s.sum))
+		fmt.Fprintf(w, "%v_count%v %d\n", h.name, formatLabels(h.labels, k), s.count)
+	}
+}
+
+// Write writes all metrics in the Prometheus text exposition format
+func Write(w io.Writer) {
+	for _, c := range registry {
+		c.write(w)
+	}
+}
+
+var (
+	// AnalysisQueueDepth is the number of stocks waiting for a strategy worker
+	AnalysisQueueDepth = newGauge(
+		"eeye_analysis_queue_depth",
+		"Number of stocks waiting in the source channel of the strategy workers.",
+	)
+
+	// IngestionQueueDepth is the number of stocks waiting for an ingestion worker
+	IngestionQueueDepth = newGauge(
+		"eeye_ingestion_queue_depth",
+		"Number of stocks waiting in the input channel of the ingestion workers.",
+	)
+
+	// StocksIngested counts the ingested stocks by result (ok or failed)
+	StocksIngested = newCounter(
+		"eeye_stocks_ingested_total",
+		"Number of stocks ingested by result.",
+		"result",
+	)
+
+	// StocksAnalyzed counts the stocks analyzed by all strategies
+	StocksAnalyzed = newCounter(
+		"eeye_stocks_analyzed_total",
+		"Number of stocks analyzed by all strategies.",
+	)
+
+	// StrategyDuration is the time a strategy takes to analyze a stock
+	StrategyDuration = newHistogram(
+		"eeye_strategy_duration_seconds",
+		"Time taken by a strategy to analyze a single stock.",
+		"strategy",
+	)
+
+	// StoreLookups counts candle lookups by store (memory or disk) and result (hit or miss).
+	// A memory miss is a stock whose batch was not preloaded; a disk miss is a symbol
+	// which had to be loaded in full from the database.
+	StoreLookups = newCounter(
+		"eeye_store_lookups_total",
+		"Number of candle lookups by store and result.",
+		"store",
+		"result",
+	)
+
+	// DBQueryDuration is the latency of database queries by query name
+	DBQueryDuration = newHistogram(
+		"eeye_db_query_duration_seconds",
+		"Latency of database queries.",
+		"query",
+	)
+)
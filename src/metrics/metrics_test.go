@@ -0,0 +1,99 @@
+package metrics
+
+import (
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+func TestCounterWrite(t *testing.T) {
+	c := &Counter{name: "test_total", help: "Test counter.", labels: []string{"store", "result"}, values: make(map[string]float64)}
+	c.Inc("memory", "hit")
+	c.Inc("memory", "hit")
+	c.Add(3, "disk", "miss")
+
+	sb := strings.Builder{}
+	c.write(&sb)
+
+	want := `# HELP test_total Test counter.
+# TYPE test_total counter
+test_total{store="disk",result="miss"} 3
+test_total{store="memory",result="hit"} 2
+`
+	if got := sb.String(); got != want {
+		t.Errorf("write() = \n%v\nwant \n%v", got, want)
+	}
+}
+
+func TestHistogramWrite(t *testing.T) {
+	h := &Histogram{name: "test_seconds", help: "Test histogram.", labels: []string{"query"}, series: make(map[string]*histogramSeries)}
+	h.Observe(0.0001, "q")
+	h.Observe(0.002, "q")
+	h.Observe(20, "q")
+
+	sb := strings.Builder{}
+	h.write(&sb)
+	got := sb.String()
+
+	tests := []string{
+		// an observation equal to a bound falls in that bucket
+		`test_seconds_bucket{query="q",le="0.0001"} 1`,
+		`test_seconds_bucket{query="q",le="0.001"} 1`,
+		`test_seconds_bucket{query="q",le="0.005"} 2`,
+		`test_seconds_bucket{query="q",le="10"} 2`,
+		`test_seconds_bucket{query="q",le="+Inf"} 3`,
+		`test_seconds_sum{query="q"} 20.0021`,
+		`test_seconds_count{query="q"} 3`,
+	}
+	for _, line := range tests {
+		if !strings.Contains(got, line+"\n") {
+			t.Errorf("write() is missing %q in \n%v", line, got)
+		}
+	}
+}
+
+func TestGaugePeak(t *testing.T) {
+	g := &Gauge{name: "test_depth"}
+	g.Set(5)
+	g.Set(2)
+	if g.Value() != 2 || g.Peak() != 5 {
+		t.Errorf("Value() = %v, Peak() = %v, want 2 and 5", g.Value(), g.Peak())
+	}
+
+	g.resetPeak()
+	g.Set(1)
+	if g.Peak() != 2 {
+		t.Errorf("Peak() after reset = %v, want 2", g.Peak())
+	}
+}
+
+func TestRunLogValue(t *testing.T) {
+	StocksAnalyzed.Add(10)
+	StrategyDuration.Observe(1, "old")
+
+	run := StartRun()
+	StocksAnalyzed.Add(3)
+	StrategyDuration.Observe(0.5, "swing")
+	StrategyDuration.Observe(1.5, "swing")
+
+	sb := strings.Builder{}
+	slog.New(slog.NewTextHandler(&sb, nil)).Info("run summary", "metrics", run)
+	got := sb.String()
+
+	tests := []string{
+		"metrics.stocks_analyzed=3 ",
+		"metrics.strategies.swing.calls=2 ",
+		"metrics.strategies.swing.avg=1s ",
+		"metrics.strategies.swing.total=2s",
+	}
+	for _, want := range tests {
+		if !strings.Contains(got, want) {
+			t.Errorf("LogValue() is missing %q in \n%v", want, got)
+		}
+	}
+
+	// strategies without observations during the run are left out
+	if strings.Contains(got, "old") {
+		t.Errorf("LogValue() includes observations before the run: \n%v", got)
+	}
+}
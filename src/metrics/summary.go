@@ -0,0 +1,84 @@
+package metrics
+
+import (
+	"log/slog"
+	"time"
+)
+
+// Run captures the metrics at the start of a run, so that the summary only
+// covers the run even though the exposed metrics are cumulative (e.g. in the daemon)
+type Run struct {
+	start      time.Time
+	ingested   map[string]float64
+	analyzed   map[string]float64
+	lookups    map[string]float64
+	strategies map[string]histogramSeries
+	queries    map[string]histogramSeries
+}
+
+// StartRun snapshots the metrics and resets the peak queue depths
+func StartRun() *Run {
+	AnalysisQueueDepth.resetPeak()
+	IngestionQueueDepth.resetPeak()
+
+	return &Run{
+		start:      time.Now(),
+		ingested:   StocksIngested.snapshot(),
+		analyzed:   StocksAnalyzed.snapshot(),
+		lookups:    StoreLookups.snapshot(),
+		strategies: StrategyDuration.totals(),
+		queries:    DBQueryDuration.totals(),
+	}
+}
+
+// durations returns a group per label value with the number, mean and total duration of
+// the observations during the run; label values without observations are left out
+func durations(current, start map[string]histogramSeries) []any {
+	attrs := make([]any, 0, len(current))
+	for _, k := range sortedKeys(current) {
+		s := current[k]
+		calls := s.count - start[k].count
+		if calls == 0 {
+			continue
+		}
+
+		total := s.sum - start[k].sum
+		attrs = append(attrs, slog.Group(
+			k,
+			slog.Uint64("calls", calls),
+			slog.Duration("avg", time.Duration(total/float64(calls)*float64(time.Second))),
+			slog.Duration("total", time.Duration(total*float64(time.Second))),
+		))
+	}
+	return attrs
+}
+
+// LogValue summarizes the pipeline metrics recorded since StartRun, e.g.
+// slog.Info("run summary", "metrics", run)
+func (r *Run) LogValue() slog.Value {
+	var (
+		ingested = StocksIngested.snapshot()
+		analyzed = StocksAnalyzed.snapshot()
+		lookups  = StoreLookups.snapshot()
+	)
+
+	delta := func(current, start map[string]float64, values ...string) float64 {
+		k := key(values)
+		return current[k] - start[k]
+	}
+
+	return slog.GroupValue(
+		slog.Duration("elapsed", time.Since(r.start)),
+		slog.Float64("stocks_ingested", delta(ingested, r.ingested, "ok")),
+		slog.Float64("stocks_failed_ingestion", delta(ingested, r.ingested, "failed")),
+		slog.Float64("stocks_analyzed", delta(analyzed, r.analyzed)),
+		slog.Float64("memory_hits", delta(lookups, r.lookups, "memory", "hit")),
+		slog.Float64("memory_misses", delta(lookups, r.lookups, "memory", "miss")),
+		slog.Float64("disk_hits", delta(lookups, r.lookups, "disk", "hit")),
+		slog.Float64("disk_misses", delta(lookups, r.lookups, "disk", "miss")),
+		slog.Float64("peak_ingestion_queue_depth", IngestionQueueDepth.Peak()),
+		slog.Float64("peak_analysis_queue_depth", AnalysisQueueDepth.Peak()),
+		slog.Group("strategies", durations(StrategyDuration.totals(), r.strategies)...),
+		slog.Group("queries", durations(DBQueryDuration.totals(), r.queries)...),
+	)
+}
@@ -0,0 +1,106 @@
+package models
+
+import (
+	"fmt"
+	"time"
+)
+
+// Conditions of an alert
+const (
+	// AlertPrice triggers when the close crosses a fixed price level
+	AlertPrice = "price"
+
+	// AlertEMA triggers when the close crosses the exponential moving average of Period sessions
+	AlertEMA = "ema"
+
+	// AlertSupport triggers when the close crosses one of the support levels of the stock
+	AlertSupport = "support"
+
+	// AlertResistance triggers when the close crosses one of the resistance levels of the stock
+	AlertResistance = "resistance"
+)
+
+// Directions of the cross of an alert
+const (
+	// CrossAbove triggers when the close moves from below the level to or above it
+	CrossAbove = "above"
+
+	// CrossBelow triggers when the close moves from above the level to or below it
+	CrossBelow = "below"
+)
+
+// Statuses of an alert
+const (
+	// AlertActive marks an alert which is still evaluated after every ingestion
+	AlertActive = "active"
+
+	// AlertTriggered marks an alert whose condition was met; it is not evaluated anymore
+	AlertTriggered = "triggered"
+
+	// AlertExpired marks an alert which expired without triggering
+	AlertExpired = "expired"
+)
+
+// Alert watches a stock for its close crossing a price, an EMA or a support/resistance level
+type Alert struct {
+	// ID identifies the alert
+	ID int64
+
+	// Symbol is the watched stock
+	Symbol string
+
+	// Condition is the kind of level watched: AlertPrice, AlertEMA, AlertSupport or AlertResistance
+	Condition string
+
+	// Level is the price watched by AlertPrice alerts
+	Level float64
+
+	// Period is the number of sessions of the EMA watched by AlertEMA alerts
+	Period int
+
+	// Direction is CrossAbove or CrossBelow
+	Direction string
+
+	// ExpiresOn is the last trading day the alert is evaluated on, nil if it never expires
+	ExpiresOn *time.Time
+
+	// CreatedAt is when the alert was created
+	CreatedAt time.Time
+
+	// EvaluatedOn is the trading day of the latest candle the alert was evaluated on, nil
+	// if it was not evaluated yet
+	EvaluatedOn *time.Time
+
+	// TriggeredOn is the trading day of the candle which triggered the alert, nil if it did not trigger
+	TriggeredOn *time.Time
+
+	// TriggeredLevel is the level crossed when the alert triggered
+	TriggeredLevel float64
+
+	// TriggeredClose is the close of the candle which triggered the alert
+	TriggeredClose float64
+}
+
+// Spec describes the watched level, e.g. "price 1500", "EMA(50)" or "support"
+func (a *Alert) Spec() string {
+	switch a.Condition {
+	case AlertPrice:
+		return fmt.Sprintf("price %v", a.Level)
+	case AlertEMA:
+		return fmt.Sprintf("EMA(%d)", a.Period)
+	default:
+		return a.Condition
+	}
+}
+
+// Status returns the status of the alert on the given trading day
+func (a *Alert) Status(day time.Time) string {
+	switch {
+	case a.TriggeredOn != nil:
+		return AlertTriggered
+	case a.ExpiresOn != nil && a.ExpiresOn.Before(day):
+		return AlertExpired
+	default:
+		return AlertActive
+	}
+}
@@ -0,0 +1,67 @@
+package models
+
+import "time"
+
+// BacktestSignal is a stock selected by a strategy on a past session, together with
+// the close of the session it was selected on and the close HoldDays sessions later
+type BacktestSignal struct {
+	// Strategy is the name of the strategy which selected the stock
+	Strategy string
+
+	// Symbol is the selected stock
+	Symbol string
+
+	// Date is the session the stock was selected on
+	Date time.Time
+
+	// Entry is the close of the session the stock was selected on
+	Entry float64
+
+	// Exit is the close of the session the position is closed on
+	Exit float64
+}
+
+// Return returns the return of the signal in percent
+func (s BacktestSignal) Return() float64 {
+	if s.Entry == 0 {
+		return 0
+	}
+	return (s.Exit - s.Entry) / s.Entry * 100
+}
+
+// BacktestResult holds the signals of a strategy over the backtested sessions
+type BacktestResult struct {
+	// Strategy is the name of the strategy
+	Strategy string
+
+	// Signals are ordered by date and symbol
+	Signals []BacktestSignal
+}
+
+// WinRate returns the percentage of signals with a positive return
+func (r BacktestResult) WinRate() float64 {
+	if len(r.Signals) == 0 {
+		return 0
+	}
+
+	wins := 0
+	for i := range r.Signals {
+		if r.Signals[i].Return() > 0 {
+			wins++
+		}
+	}
+	return float64(wins) / float64(len(r.Signals)) * 100
+}
+
+// AverageReturn returns the mean return of the signals in percent
+func (r BacktestResult) AverageReturn() float64 {
+	if len(r.Signals) == 0 {
+		return 0
+	}
+
+	total := 0.0
+	for i := range r.Signals {
+		total += r.Signals[i].Return()
+	}
+	return total / float64(len(r.Signals))
+}
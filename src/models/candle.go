@@ -27,6 +27,27 @@ type Candle struct {
 
 	// Volume is the trading volume during this period
 	Volume uint64
+
+	// DeliveryQty is the number of traded shares marked for delivery (0 if unknown)
+	DeliveryQty uint64
+
+	// DeliveryPct is the percentage of traded shares marked for delivery (0 if unknown)
+	DeliveryPct float64
+
+	// Turnover is the traded value in rupees during this period (0 if unknown)
+	Turnover float64
+}
+
+// CandleRange summarizes the stored candles of a stock
+type CandleRange struct {
+	// Count is the number of stored candles
+	Count int
+
+	// First is the time of the oldest candle
+	First time.Time
+
+	// Last is the time of the latest candle
+	Last time.Time
 }
 
 // RawCandle is a type alias for raw candlestick data received from the API,
@@ -57,3 +78,12 @@ type CandlesResponse struct {
 	// Payload contains the actual candle data
 	Payload CandlePayload `json:"payload"`
 }
+
+// DelistedStock is a stored stock without recent candles, i.e. a candidate of the delisted stock cleanup
+type DelistedStock struct {
+	// Symbol is the delisted stock
+	Symbol string
+
+	// Range summarizes the stored candles of the stock
+	Range CandleRange
+}
@@ -0,0 +1,84 @@
+package models
+
+import "time"
+
+// Statuses of a listing
+const (
+	// ListingActive marks a stock trading in the EQ series
+	ListingActive = "active"
+
+	// ListingRestricted marks a stock moved to a trade for trade series (BE, BZ), e.g. for
+	// surveillance or non compliance with the listing regulations. It still trades daily.
+	ListingRestricted = "restricted"
+
+	// ListingSuspended marks a stock missing from the latest bhavcopy, which has not been
+	// delisted (yet)
+	ListingSuspended = "suspended"
+
+	// ListingDelisted marks a stock whose candles have been archived by the cleanup
+	ListingDelisted = "delisted"
+)
+
+// Events of a listing
+const (
+	// EventRenamed is recorded when the symbol of an ISIN changes (e.g. ZOMATO to ETERNAL)
+	EventRenamed = "renamed"
+
+	// EventISINChanged is recorded when a symbol moves to a new ISIN (e.g. after a
+	// change of the face value), on the listing of the new ISIN
+	EventISINChanged = "isin_changed"
+
+	// EventSeriesChanged is recorded when a stock moves to another series (e.g. EQ to BE)
+	EventSeriesChanged = "series_changed"
+
+	// EventSuspended is recorded when a stock disappears from the bhavcopy
+	EventSuspended = "suspended"
+
+	// EventResumed is recorded when a suspended stock trades again
+	EventResumed = "resumed"
+)
+
+// Listing tracks a stock listed on NSE by its ISIN, which unlike the symbol
+// does not change on renames.
+type Listing struct {
+	// ISIN is the International Securities Identification Number of the stock
+	ISIN string
+
+	// Symbol is the current ticker symbol of the stock
+	Symbol string
+
+	// Name is the company's full name
+	Name string
+
+	// Series is the series the stock last traded in (e.g. EQ, BE)
+	Series string
+
+	// Status is one of the Listing* statuses
+	Status string
+
+	// FirstSeen is the first trading day the stock was found in a bhavcopy
+	FirstSeen time.Time
+
+	// LastSeen is the last trading day the stock was found in a bhavcopy
+	LastSeen time.Time
+}
+
+// ListingEvent is a change in the lifecycle of a listing
+type ListingEvent struct {
+	// ISIN identifies the listing
+	ISIN string
+
+	// Symbol is the current symbol of the listing
+	Symbol string
+
+	// Day is the trading day of the bhavcopy the change was detected in
+	Day time.Time
+
+	// Event is one of the Event* names
+	Event string
+
+	// Old and New are the values before and after the change (symbols for EventRenamed,
+	// ISINs for EventISINChanged, series for EventSeriesChanged, statuses otherwise)
+	Old string
+	New string
+}
@@ -0,0 +1,52 @@
+package models
+
+import "time"
+
+// Severity levels of a candle data quality issue
+const (
+	// SeverityError marks a candle that cannot be trusted and is quarantined
+	SeverityError = "error"
+
+	// SeverityWarning marks a suspicious candle that is still ingested
+	SeverityWarning = "warning"
+)
+
+// CandleIssue is a data quality problem detected on an ingested candle.
+type CandleIssue struct {
+	// Symbol identifies the stock the issue belongs to
+	Symbol string
+
+	// Timestamp is the trading day of the offending (or missing) candle
+	Timestamp time.Time
+
+	// Check is the name of the validation that failed (e.g. "high_below_low")
+	Check string
+
+	// Severity is either SeverityError or SeverityWarning
+	Severity string
+
+	// Detail is a human readable description of the problem
+	Detail string
+
+	// Quarantined is true if the candle was kept out of stock_prices
+	Quarantined bool
+
+	// Candle holds the raw OHLCV values as received from the provider
+	Candle Candle
+
+	// DetectedAt is when the issue was recorded
+	DetectedAt time.Time
+}
+
+// CorporateAction is a split, bonus or other action which moves the price of a stock
+// on its ex-date without being a real price move.
+type CorporateAction struct {
+	// Symbol identifies the stock
+	Symbol string
+
+	// ExDate is the trading day from which prices are adjusted for the action
+	ExDate time.Time
+
+	// Action describes the action
+	Action string
+}
@@ -0,0 +1,9 @@
+package models
+
+// CandleSource provides the historical candles of a stock to screening steps.
+// The analysis pipeline uses the in-memory cache of the store package; tests can
+// use any other implementation.
+type CandleSource interface {
+	// Get returns the candles of the stock, oldest first
+	Get(stock *Stock) ([]Candle, error)
+}
@@ -1,6 +1,9 @@
 package models
 
-import "log"
+import (
+	"fmt"
+	"log/slog"
+)
 
 // Step defines the interface that each screen step should implement.
 // All step implementations must embed StepBaseImpl to satisfy this interface.
@@ -11,6 +14,9 @@ type Step interface {
 	// Screen returns whether the stock passed the screening test for the strategy
 	Screen(strategy string, stock *Stock) bool
 
+	// SetSource sets the source the step reads candles from
+	SetSource(source CandleSource)
+
 	// mustEmbedStepBaseImpl is a marker method to force consumers to compose StepBaseImpl.
 	// This ensures all Step implementations have access to shared helper methods.
 	mustEmbedStepBaseImpl()
@@ -18,7 +24,10 @@ type Step interface {
 
 // StepBaseImpl provides base implementation and helper methods for all Step implementations.
 // All step types must embed this struct to satisfy the Step interface.
-type StepBaseImpl struct{}
+type StepBaseImpl struct {
+	// source provides the candles of the screened stock
+	source CandleSource
+}
 
 // mustEmbedStepBaseImpl is a marker method that forces all Step implementations
 // to embed StepBaseImpl, ensuring consistent behavior across all steps.
@@ -26,6 +35,20 @@ type StepBaseImpl struct{}
 //nolint:unused
 func (s *StepBaseImpl) mustEmbedStepBaseImpl() {}
 
+// SetSource sets the source the step reads candles from
+func (s *StepBaseImpl) SetSource(source CandleSource) {
+	s.source = source
+}
+
+// GetCandles returns the candles of the stock from the step's source
+func (s *StepBaseImpl) GetCandles(stock *Stock) ([]Candle, error) {
+	if s.source == nil {
+		return nil, fmt.Errorf("no candle source for %v", stock.Symbol)
+	}
+
+	return s.source.Get(stock)
+}
+
 // TruthyCheck is a helper method that executes the provided assertion function,
 // logs a failure message if the test fails, and returns the test result.
 // This reduces code duplication across step implementations by centralizing
@@ -38,7 +61,7 @@ func (s *StepBaseImpl) TruthyCheck(
 ) bool {
 	test := assert()
 	if !test {
-		log.Printf("[%v - %v] test failed: %v\n", strategy, step, stock.Symbol)
+		slog.Debug("test failed", "strategy", strategy, "step", step, "symbol", stock.Symbol)
 	}
 	return test
 }
@@ -36,4 +36,47 @@ type NSEStockData struct {
 
 	// FinInstrmTp is the financial instrument type (e.g., STK for stock)
 	InstrumentType string `csv:"FinInstrmTp"`
+
+	// TradDt is the trading day the row belongs to (YYYY-MM-DD)
+	TradeDate string `csv:"TradDt"`
+
+	// OpnPric is the opening price of the session
+	Open float64 `csv:"OpnPric"`
+
+	// HghPric is the highest price of the session
+	High float64 `csv:"HghPric"`
+
+	// LwPric is the lowest price of the session
+	Low float64 `csv:"LwPric"`
+
+	// ClsPric is the closing price of the session
+	Close float64 `csv:"ClsPric"`
+
+	// PrvsClsgPric is the closing price of the previous session
+	PrevClose float64 `csv:"PrvsClsgPric"`
+
+	// TtlTradgVol is the number of shares traded in the session
+	Volume uint64 `csv:"TtlTradgVol"`
+
+	// TtlTrfVal is the traded value (turnover) of the session in rupees
+	Turnover float64 `csv:"TtlTrfVal"`
+}
+
+// NSEDeliveryData represents a row of the NSE security-wise delivery file
+// (sec_bhavdata_full_DDMMYYYY.csv).
+type NSEDeliveryData struct {
+	// Symbol is the unique ticker symbol for the stock
+	Symbol string
+
+	// Series indicates the type of stock (e.g., EQ, BE etc.)
+	Series string
+
+	// DeliveryQty is the number of traded shares marked for delivery
+	DeliveryQty uint64
+
+	// DeliveryPct is the percentage of traded shares marked for delivery
+	DeliveryPct float64
+
+	// Turnover is the traded value of the session in rupees
+	Turnover float64
 }
@@ -0,0 +1,25 @@
+package models
+
+import "time"
+
+// ChunkStats describes the size of a stock_prices chunk before and after compression
+type ChunkStats struct {
+	Name        string
+	RangeStart  time.Time
+	RangeEnd    time.Time
+	Compressed  bool
+	BeforeBytes int64
+	AfterBytes  int64
+}
+
+// StoragePolicy is a TimescaleDB background job (compression, retention or
+// continuous aggregate refresh) with the outcome of its last run
+type StoragePolicy struct {
+	JobID         int
+	Kind          string
+	Target        string
+	Schedule      string
+	Config        string
+	NextStart     time.Time
+	LastRunStatus string
+}
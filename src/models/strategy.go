@@ -1,6 +1,10 @@
 package models
 
-import "eeye/src/constants"
+import (
+	"eeye/src/constants"
+	"slices"
+	"time"
+)
 
 // Strategy defines the interface that all trading strategies must implement.
 type Strategy interface {
@@ -13,6 +17,19 @@ type Strategy interface {
 	// GetSink returns the output channel for the strategy.
 	GetSink() chan *Stock
 
+	// SetSinkSize sets the buffer size of the output channel; it must be called before GetSink.
+	SetSinkSize(size int)
+
+	// Lookback returns the number of latest candles the strategy needs (0 for the full history).
+	// It bounds the candles loaded for analysis when trimming is enabled.
+	Lookback() int
+
+	// SetSource sets the source the strategy's steps read candles from.
+	SetSource(source CandleSource)
+
+	// GetSource returns the source the strategy's steps read candles from.
+	GetSource() CandleSource
+
 	// mustEmbedStrategyBaseImpl is a marker function to ensure that
 	// StrategyBaseImpl is embedded in all strategies which helps in code re-using.
 	mustEmbedStrategyBaseImpl()
@@ -22,6 +39,12 @@ type Strategy interface {
 type StrategyBaseImpl struct {
 	// sink is the output channel for the strategy.
 	sink chan *Stock
+
+	// sinkSize is the buffer size of sink (constants.DefaultStrategySinkSize if unset).
+	sinkSize int
+
+	// source provides the candles of the analyzed stocks.
+	source CandleSource
 }
 
 // mustEmbedStrategyBaseImpl is intentionally left blank to enforce embedding.
@@ -32,12 +55,30 @@ func (s *StrategyBaseImpl) mustEmbedStrategyBaseImpl() {}
 // GetSink returns the output channel for the strategy, initializing it if necessary.
 func (s *StrategyBaseImpl) GetSink() chan *Stock {
 	if s.sink == nil {
-		s.sink = make(chan *Stock, constants.AggregatorBufferSize)
+		if s.sinkSize == 0 {
+			s.sinkSize = constants.DefaultStrategySinkSize
+		}
+		s.sink = make(chan *Stock, s.sinkSize)
 	}
 
 	return s.sink
 }
 
+// SetSinkSize sets the buffer size of the output channel; it has no effect once the channel exists.
+func (s *StrategyBaseImpl) SetSinkSize(size int) {
+	s.sinkSize = size
+}
+
+// SetSource sets the source the strategy's steps read candles from.
+func (s *StrategyBaseImpl) SetSource(source CandleSource) {
+	s.source = source
+}
+
+// GetSource returns the source the strategy's steps read candles from.
+func (s *StrategyBaseImpl) GetSource() CandleSource {
+	return s.source
+}
+
 // StrategyResult combines the strategy and the the result satisfying the strategy
 type StrategyResult struct {
 	// Strategy config
@@ -45,4 +86,32 @@ type StrategyResult struct {
 
 	// Stocks is list of stocks satisfying the strategy
 	Stocks []*Stock
+
+	// Diff compares Stocks with the previous run of the strategy; it is nil until
+	// the result has been compared
+	Diff *SignalDiff
+}
+
+// SignalDiff labels the stocks selected by a strategy against its previous run
+type SignalDiff struct {
+	// Day is the trading day of the compared run
+	Day time.Time
+
+	// Previous is the trading day of the previous run, zero if the strategy never ran before
+	// (every stock is then new)
+	Previous time.Time
+
+	// New are the symbols selected now but not in the previous run
+	New []string
+
+	// Continuing are the symbols selected in both runs
+	Continuing []string
+
+	// Dropped are the symbols selected in the previous run but not anymore
+	Dropped []string
+}
+
+// IsNew reports whether the symbol is a new signal
+func (d *SignalDiff) IsNew(symbol string) bool {
+	return slices.Contains(d.New, symbol)
 }
@@ -0,0 +1,93 @@
+package notify
+
+import (
+	"context"
+	"eeye/src/models"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// AlertsName is the name the notifications of triggered alerts are routed by, like the
+// name of a strategy: sinks limited to some strategies only receive them if they list it
+const AlertsName = "Price Alerts"
+
+// Alert is a triggered alert
+type Alert struct {
+	// ID identifies the alert
+	ID int64 `json:"id"`
+
+	// Symbol is the watched stock
+	Symbol string `json:"symbol"`
+
+	// Condition describes the watched level, e.g. "price 1500" or "EMA(50)"
+	Condition string `json:"condition"`
+
+	// Direction is "above" or "below"
+	Direction string `json:"direction"`
+
+	// Level is the level crossed by the close
+	Level float64 `json:"level"`
+
+	// Close is the close of the candle which triggered the alert
+	Close float64 `json:"close"`
+
+	// Day is the trading day of the candle which triggered the alert
+	Day string `json:"day"`
+}
+
+// alertMessage returns the message of the alerts triggered by the candles up to the given
+// trading day; the alerts triggered by an earlier candle mention its day
+func alertMessage(day time.Time, triggered []models.Alert) *Message {
+	msg := &Message{
+		Strategy: AlertsName,
+		Day:      day.Format("2006-01-02"),
+		Signals:  make([]Signal, 0),
+		Alerts:   make([]Alert, 0, len(triggered)),
+	}
+	msg.Subject = fmt.Sprintf("eeye: %d price alerts triggered on %v", len(triggered), msg.Day)
+
+	var b strings.Builder
+	b.WriteString(msg.Subject)
+	b.WriteString("\n")
+	for i := range triggered {
+		alert := Alert{
+			ID:        triggered[i].ID,
+			Symbol:    triggered[i].Symbol,
+			Condition: triggered[i].Spec(),
+			Direction: triggered[i].Direction,
+			Level:     triggered[i].TriggeredLevel,
+			Close:     triggered[i].TriggeredClose,
+			Day:       msg.Day,
+		}
+		if triggered[i].TriggeredOn != nil {
+			alert.Day = triggered[i].TriggeredOn.Format("2006-01-02")
+		}
+		msg.Alerts = append(msg.Alerts, alert)
+
+		closed := fmt.Sprintf("closed at %v", alert.Close)
+		if alert.Day != msg.Day {
+			closed = fmt.Sprintf("closed at %v on %v", alert.Close, alert.Day)
+		}
+
+		level := alert.Condition
+		if triggered[i].Condition != models.AlertPrice {
+			level = fmt.Sprintf("%v at %v", alert.Condition, alert.Level)
+		}
+		fmt.Fprintf(&b, "\n%v %v, %v %v (alert #%d)", alert.Symbol, closed, alert.Direction, level, alert.ID)
+	}
+	b.WriteString("\n")
+	msg.Text = b.String()
+
+	return msg
+}
+
+// SendAlerts notifies the sinks accepting AlertsName of the alerts triggered by the candles
+// up to the given trading day, in a single message. Every sink is tried even if another one fails; the
+// failed deliveries are returned.
+func (d *Dispatcher) SendAlerts(ctx context.Context, day time.Time, triggered []models.Alert) error {
+	if !d.Enabled() || len(triggered) == 0 {
+		return nil
+	}
+	return d.deliver(ctx, []*Message{alertMessage(day, triggered)})
+}
@@ -0,0 +1,283 @@
+// Package notify sends the signals of a screening run and the triggered price alerts
+// to notification sinks: generic HTTP webhooks, email (SMTP) and chat bots (Telegram).
+// Every sink can be limited to a subset of the strategies.
+package notify
+
+import (
+	"context"
+	"eeye/src/config"
+	"eeye/src/db"
+	"eeye/src/models"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"slices"
+	"strings"
+	"text/template"
+)
+
+// Notifier delivers notifications to a sink
+type Notifier interface {
+	// Name identifies the sink in logs
+	Name() string
+
+	// Notify delivers the message, or returns why it could not be delivered
+	Notify(ctx context.Context, msg *Message) error
+}
+
+// Message is the notification of the signals of a strategy
+type Message struct {
+	// Strategy is the name of the strategy which selected the stocks
+	Strategy string `json:"strategy"`
+
+	// Day is the trading day of the signals (YYYY-MM-DD)
+	Day string `json:"day"`
+
+	// Subject is the one line summary of the message
+	Subject string `json:"subject"`
+
+	// Text is the message rendered with the notification template
+	Text string `json:"text"`
+
+	// Signals are the selected stocks, ordered by symbol
+	Signals []Signal `json:"signals"`
+
+	// Previous is the trading day of the previous run of the strategy (YYYY-MM-DD), if
+	// the signals were compared with it
+	Previous string `json:"previous,omitempty"`
+
+	// Dropped are the symbols selected by the previous run but not anymore
+	Dropped []string `json:"dropped,omitempty"`
+
+	// Alerts are the triggered alerts of an AlertsName message
+	Alerts []Alert `json:"alerts,omitempty"`
+}
+
+// unwrapURLError drops the URL from the error of a request, since the URL of a sink
+// may hold a secret (e.g. a bot token or a Slack webhook)
+func unwrapURLError(err error) error {
+	var urlErr *url.Error
+	if errors.As(err, &urlErr) {
+		return urlErr.Err
+	}
+	return err
+}
+
+// route sends the messages of some strategies to a sink
+type route struct {
+	notifier Notifier
+
+	// strategies are the lower cased names of the strategies notified (all if empty)
+	strategies map[string]struct{}
+}
+
+// accepts reports whether the messages of the strategy are sent through the route
+func (r *route) accepts(strategy string) bool {
+	if len(r.strategies) == 0 {
+		return true
+	}
+	_, ok := r.strategies[strings.ToLower(strategy)]
+	return ok
+}
+
+// parseStrategies parses a comma separated list of strategy names, which must be known
+func parseStrategies(env string, list string, known []string) (map[string]struct{}, error) {
+	names := make(map[string]struct{}, len(known))
+	for _, name := range known {
+		names[strings.ToLower(name)] = struct{}{}
+	}
+
+	var (
+		res     = make(map[string]struct{})
+		unknown = make([]string, 0)
+	)
+	for name := range strings.SplitSeq(list, ",") {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" {
+			continue
+		}
+		if _, ok := names[name]; !ok {
+			unknown = append(unknown, name)
+			continue
+		}
+		res[name] = struct{}{}
+	}
+
+	if len(unknown) > 0 {
+		return nil, fmt.Errorf("%v: unknown strategies %q, available: %v", env, unknown, strings.Join(known, ", "))
+	}
+	return res, nil
+}
+
+// Dispatcher sends the signals of every strategy to the sinks enabled in the configuration
+type Dispatcher struct {
+	routes   []route
+	template *template.Template
+}
+
+// New returns a Dispatcher for the sinks enabled in the configuration. known are the
+// names of the available strategies, which the strategies of every sink are checked
+// against (along with AlertsName).
+func New(known []string) (*Dispatcher, error) {
+	tmpl, err := loadTemplate(config.Notify.Template)
+	if err != nil {
+		return nil, err
+	}
+	known = append(slices.Clone(known), AlertsName)
+
+	sinks := []struct {
+		env        string
+		strategies string
+		enabled    bool
+		notifier   func() (Notifier, error)
+	}{
+		{
+			env:        "EEYE_WEBHOOK_STRATEGIES",
+			strategies: config.Webhook.Strategies,
+			enabled:    config.Webhook.URL != "",
+			notifier: func() (Notifier, error) {
+				return newWebhook(config.Webhook.URL), nil
+			},
+		},
+		{
+			env:        "EEYE_SMTP_STRATEGIES",
+			strategies: config.SMTP.Strategies,
+			enabled:    config.SMTP.Host != "",
+			notifier: func() (Notifier, error) {
+				return newSMTP(
+					config.SMTP.Host,
+					config.SMTP.Port,
+					config.SMTP.User,
+					config.SMTP.Password,
+					config.SMTP.From,
+					config.SMTP.To,
+				)
+			},
+		},
+		{
+			env:        "TELEGRAM_STRATEGIES",
+			strategies: config.Telegram.Strategies,
+			enabled:    config.Telegram.Token != "",
+			notifier: func() (Notifier, error) {
+				return newTelegram(config.Telegram.BaseURL, config.Telegram.Token, config.Telegram.ChatID), nil
+			},
+		},
+	}
+
+	d := &Dispatcher{template: tmpl}
+	for _, sink := range sinks {
+		if !sink.enabled {
+			continue
+		}
+
+		strategies, err := parseStrategies(sink.env, sink.strategies, known)
+		if err != nil {
+			return nil, err
+		}
+
+		notifier, err := sink.notifier()
+		if err != nil {
+			return nil, err
+		}
+		d.routes = append(d.routes, route{notifier: notifier, strategies: strategies})
+	}
+
+	return d, nil
+}
+
+// Enabled reports whether any sink is configured
+func (d *Dispatcher) Enabled() bool {
+	return len(d.routes) > 0
+}
+
+// Send notifies the sinks of the signals of every strategy which selected stocks (only the
+// new ones with config.Notify.NewOnly if the results were compared with the previous run), with
+// the latest candles and indicators of the selected stocks read from the database.
+// Every sink is tried even if another one fails; the failed deliveries are returned.
+func (d *Dispatcher) Send(ctx context.Context, results []*models.StrategyResult) error {
+	if !d.Enabled() {
+		return nil
+	}
+
+	var (
+		symbols = make([]string, 0)
+		dropped = false
+	)
+	for _, result := range results {
+		for i := range result.Stocks {
+			symbols = append(symbols, result.Stocks[i].Symbol)
+		}
+		dropped = dropped || hasDropped(result)
+	}
+	if len(symbols) == 0 && !dropped {
+		slog.Info("no signals to notify")
+		return nil
+	}
+
+	candles := make(map[string][]models.Candle)
+	if len(symbols) > 0 {
+		var err error
+		candles, err = db.FetchCandlesBatch(ctx, symbols, signalLookback)
+		if err != nil {
+			return fmt.Errorf("failed to fetch the candles of the signals: %w", err)
+		}
+	}
+
+	messages, err := d.messages(results, candles)
+	if err != nil {
+		return err
+	}
+	return d.deliver(ctx, messages)
+}
+
+// hasDropped reports whether the result was compared with a previous run which selected
+// stocks it no longer selects
+func hasDropped(result *models.StrategyResult) bool {
+	return result.Diff != nil && !result.Diff.Previous.IsZero() && len(result.Diff.Dropped) > 0
+}
+
+// messages renders the message of every strategy which selected stocks (new stocks
+// with config.Notify.NewOnly) or dropped stocks since its previous run
+func (d *Dispatcher) messages(results []*models.StrategyResult, candles map[string][]models.Candle) ([]*Message, error) {
+	res := make([]*Message, 0, len(results))
+	for _, result := range results {
+		signals := newSignals(result, candles, config.Notify.NewOnly)
+		if len(signals) == 0 && !hasDropped(result) {
+			continue
+		}
+
+		msg, err := d.render(result.Strategy.Name(), signals, result.Diff)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, msg)
+	}
+	return res, nil
+}
+
+// deliver sends every message through the routes accepting its strategy, each
+// delivery bounded by config.Notify.Timeout
+func (d *Dispatcher) deliver(ctx context.Context, messages []*Message) error {
+	errs := make([]error, 0)
+	for _, msg := range messages {
+		for i := range d.routes {
+			r := &d.routes[i]
+			if !r.accepts(msg.Strategy) {
+				continue
+			}
+
+			sendCtx, cancel := context.WithTimeout(ctx, config.Notify.Timeout)
+			err := r.notifier.Notify(sendCtx, msg)
+			cancel()
+
+			if err != nil {
+				slog.Error("notification failed", "sink", r.notifier.Name(), "strategy", msg.Strategy, "err", err)
+				errs = append(errs, fmt.Errorf("%v: %w", r.notifier.Name(), err))
+				continue
+			}
+			slog.Info("notification sent", "sink", r.notifier.Name(), "strategy", msg.Strategy, "signals", len(msg.Signals))
+		}
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,295 @@
+package notify
+
+import (
+	"context"
+	"eeye/src/config"
+	"eeye/src/models"
+	"eeye/src/testutil"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fakeStrategy is a strategy with a name only
+type fakeStrategy struct {
+	models.StrategyBaseImpl
+	name string
+}
+
+func (f *fakeStrategy) Name() string            { return f.name }
+func (f *fakeStrategy) Lookback() int           { return 0 }
+func (f *fakeStrategy) Execute(_ *models.Stock) {}
+
+// fakeNotifier records the delivered messages
+type fakeNotifier struct {
+	name     string
+	err      error
+	messages []*Message
+}
+
+func (f *fakeNotifier) Name() string { return f.name }
+
+func (f *fakeNotifier) Notify(_ context.Context, msg *Message) error {
+	f.messages = append(f.messages, msg)
+	return f.err
+}
+
+func result(strategy string, symbols ...string) *models.StrategyResult {
+	res := &models.StrategyResult{Strategy: &fakeStrategy{name: strategy}}
+	for _, symbol := range symbols {
+		res.Stocks = append(res.Stocks, &models.Stock{Symbol: symbol})
+	}
+	return res
+}
+
+func TestParseStrategies(t *testing.T) {
+	known := []string{"Bullish Swing", "Fake Breakdown"}
+
+	got, err := parseStrategies("EEYE_WEBHOOK_STRATEGIES", " bullish swing, ,FAKE BREAKDOWN", known)
+	if err != nil {
+		t.Fatalf("parseStrategies() error = %v", err)
+	}
+	if want := map[string]struct{}{"bullish swing": {}, "fake breakdown": {}}; !reflect.DeepEqual(got, want) {
+		t.Errorf("parseStrategies() = %v, want %v", got, want)
+	}
+
+	_, err = parseStrategies("EEYE_WEBHOOK_STRATEGIES", "Bullish Swing, Bearish Swing", known)
+	if err == nil || !strings.Contains(err.Error(), `EEYE_WEBHOOK_STRATEGIES: unknown strategies ["bearish swing"]`) {
+		t.Errorf("parseStrategies() error = %v, want the unknown strategy", err)
+	}
+}
+
+func TestNewSignal(t *testing.T) {
+	candles := testutil.NewSeries("TCS").Trend(60, 0.01).Candle(100, 112, 99, 110).Delivery(55).Candles()
+
+	signal := newSignal("TCS", candles)
+	if signal.Symbol != "TCS" || signal.Day != candles[60].Timestamp.Format("2006-01-02") {
+		t.Errorf("newSignal() = %+v, want the latest candle of TCS", signal)
+	}
+	if signal.Close != 110 || signal.DeliveryPct != 55 || signal.Volume != testutil.DefaultVolume {
+		t.Errorf("newSignal() = %+v, want close 110, delivery 55 and the default volume", signal)
+	}
+	if signal.RSI14 == 0 || signal.EMA20 == 0 || signal.EMA50 == 0 || signal.VolumeMA20 != testutil.DefaultVolume {
+		t.Errorf("newSignal() = %+v, want all indicators", signal)
+	}
+
+	// indicators without enough history are left out
+	short := newSignal("TCS", candles[:10])
+	if short.RSI14 != 0 || short.EMA50 != 0 || short.VolumeMA20 != 0 {
+		t.Errorf("newSignal() = %+v, want no indicators", short)
+	}
+}
+
+func TestLoadTemplate(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "notification.tmpl")
+	if err := os.WriteFile(path, []byte(`{{.Strategy}}:{{range .Signals}} {{.Symbol}}@{{.Close}}{{end}}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	tmpl, err := loadTemplate(path)
+	if err != nil {
+		t.Fatalf("loadTemplate() error = %v", err)
+	}
+
+	d := &Dispatcher{template: tmpl}
+	msg, err := d.render("Bullish Swing", []Signal{{Symbol: "INFY", Close: 1500.5}, {Symbol: "TCS", Close: 4000}}, nil)
+	if err != nil {
+		t.Fatalf("render() error = %v", err)
+	}
+	if want := "Bullish Swing: INFY@1500.5 TCS@4000"; msg.Text != want {
+		t.Errorf("Text = %q, want %q", msg.Text, want)
+	}
+
+	if err := os.WriteFile(path, []byte(`{{.Symbol`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := loadTemplate(path); err == nil {
+		t.Error("loadTemplate() error = nil, want an invalid template")
+	}
+}
+
+func TestDispatcher(t *testing.T) {
+	tmpl, err := loadTemplate("")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var (
+		all     = &fakeNotifier{name: "all"}
+		swing   = &fakeNotifier{name: "swing"}
+		failing = &fakeNotifier{name: "failing", err: errors.New("unavailable")}
+		d       = &Dispatcher{
+			template: tmpl,
+			routes: []route{
+				{notifier: all},
+				{notifier: swing, strategies: map[string]struct{}{"bullish swing": {}}},
+				{notifier: failing, strategies: map[string]struct{}{"fake breakdown": {}}},
+			},
+		}
+		results = []*models.StrategyResult{
+			result("Bullish Swing", "TCS", "INFY"),
+			result("Fake Breakdown", "SBIN"),
+			result("Bullish Momentum Breakout"),
+		}
+		candles = map[string][]models.Candle{
+			"TCS":  testutil.NewSeries("TCS").Trend(30, 0.01).Candles(),
+			"INFY": testutil.NewSeries("INFY").Trend(30, -0.01).Candles(),
+			"SBIN": testutil.NewSeries("SBIN").Flat(30, 800).Candles(),
+		}
+	)
+
+	messages, err := d.messages(results, candles)
+	if err != nil {
+		t.Fatalf("messages() error = %v", err)
+	}
+	if len(messages) != 2 {
+		t.Fatalf("messages() = %d messages, want one per strategy with signals", len(messages))
+	}
+
+	swingMsg := messages[0]
+	if swingMsg.Signals[0].Symbol != "INFY" || swingMsg.Signals[1].Symbol != "TCS" {
+		t.Errorf("Signals = %+v, want them ordered by symbol", swingMsg.Signals)
+	}
+	for _, want := range []string{"Bullish Swing selected 2 stocks", "INFY: close", "TCS: close", "RSI14", "EMA20"} {
+		if !strings.Contains(swingMsg.Text, want) {
+			t.Errorf("Text = %q, want it to contain %q", swingMsg.Text, want)
+		}
+	}
+
+	err = d.deliver(context.Background(), messages)
+	if err == nil || !strings.Contains(err.Error(), "failing: unavailable") {
+		t.Errorf("deliver() error = %v, want the failed sink", err)
+	}
+	if len(all.messages) != 2 || len(swing.messages) != 1 || len(failing.messages) != 1 {
+		t.Errorf("deliveries = %d, %d, %d, want 2, 1, 1", len(all.messages), len(swing.messages), len(failing.messages))
+	}
+	if swing.messages[0].Strategy != "Bullish Swing" || failing.messages[0].Strategy != "Fake Breakdown" {
+		t.Error("sinks were notified of strategies they are not subscribed to")
+	}
+}
+
+func TestDispatcherComparedSignals(t *testing.T) {
+	tmpl, err := loadTemplate("")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var (
+		d       = &Dispatcher{template: tmpl}
+		swing   = result("Bullish Swing", "TCS", "INFY")
+		steady  = result("Fake Breakdown", "SBIN")
+		emptied = result("Bullish Momentum Breakout")
+		candles = map[string][]models.Candle{
+			"TCS":  testutil.NewSeries("TCS").Trend(30, 0.01).Candles(),
+			"INFY": testutil.NewSeries("INFY").Trend(30, -0.01).Candles(),
+			"SBIN": testutil.NewSeries("SBIN").Flat(30, 800).Candles(),
+		}
+		previous = time.Date(2024, 3, 14, 0, 0, 0, 0, time.UTC)
+		day      = time.Date(2024, 3, 15, 0, 0, 0, 0, time.UTC)
+	)
+	swing.Diff = &models.SignalDiff{Previous: previous, New: []string{"INFY"}, Continuing: []string{"TCS"}, Dropped: []string{"WIPRO"}}
+	steady.Diff = &models.SignalDiff{Previous: previous, Continuing: []string{"SBIN"}}
+	emptied.Diff = &models.SignalDiff{Day: day, Previous: previous, Dropped: []string{"HDFCBANK"}}
+
+	tests := []struct {
+		name        string
+		newOnly     bool
+		wantSignals [][]string
+		wantText    []string
+		// wantDropped is the text of the message of the strategy which selects nothing anymore
+		wantDropped []string
+	}{
+		{
+			name:        "all signals",
+			wantSignals: [][]string{{"INFY:new", "TCS:continuing"}, {"SBIN:continuing"}, {}},
+			wantText:    []string{"2 stocks (1 new)", "INFY (new): close", "TCS: close", "Dropped since 2024-03-14: WIPRO"},
+			wantDropped: []string{"dropped 1 stocks on 2024-03-15", "Dropped since 2024-03-14: HDFCBANK"},
+		},
+		{
+			name:        "new only",
+			newOnly:     true,
+			wantSignals: [][]string{{"INFY:new"}, {}},
+			wantText:    []string{"selected 1 new stocks", "INFY (new): close", "Dropped since 2024-03-14: WIPRO"},
+			wantDropped: []string{"dropped 1 stocks on 2024-03-15", "Dropped since 2024-03-14: HDFCBANK"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config.Notify.NewOnly = tt.newOnly
+			defer func() { config.Notify.NewOnly = false }()
+
+			messages, err := d.messages([]*models.StrategyResult{swing, steady, emptied}, candles)
+			if err != nil {
+				t.Fatalf("messages() error = %v", err)
+			}
+
+			got := make([][]string, 0, len(messages))
+			for _, msg := range messages {
+				signals := make([]string, 0, len(msg.Signals))
+				for _, signal := range msg.Signals {
+					signals = append(signals, signal.Symbol+":"+signal.Status)
+				}
+				got = append(got, signals)
+			}
+			if !reflect.DeepEqual(got, tt.wantSignals) {
+				t.Fatalf("signals = %v, want %v", got, tt.wantSignals)
+			}
+
+			for _, want := range tt.wantText {
+				if !strings.Contains(messages[0].Text, want) {
+					t.Errorf("Text = %q, want it to contain %q", messages[0].Text, want)
+				}
+			}
+			for _, want := range tt.wantDropped {
+				if last := messages[len(messages)-1]; !strings.Contains(last.Text, want) {
+					t.Errorf("Text = %q, want it to contain %q", last.Text, want)
+				}
+			}
+		})
+	}
+}
+
+func TestSendAlerts(t *testing.T) {
+	var (
+		all   = &fakeNotifier{name: "all"}
+		swing = &fakeNotifier{name: "swing"}
+		d     = &Dispatcher{
+			routes: []route{
+				{notifier: all},
+				{notifier: swing, strategies: map[string]struct{}{"bullish swing": {}}},
+			},
+		}
+		day       = time.Date(2024, 3, 15, 0, 0, 0, 0, time.UTC)
+		earlier   = day.AddDate(0, 0, -1)
+		triggered = []models.Alert{
+			{ID: 1, Symbol: "TCS", Condition: models.AlertPrice, Level: 4200, Direction: models.CrossAbove, TriggeredOn: &day, TriggeredLevel: 4200, TriggeredClose: 4215.5},
+			{ID: 2, Symbol: "INFY", Condition: models.AlertEMA, Period: 50, Direction: models.CrossBelow, TriggeredOn: &earlier, TriggeredLevel: 1490.12, TriggeredClose: 1480},
+		}
+	)
+
+	if err := d.SendAlerts(context.Background(), day, triggered); err != nil {
+		t.Fatalf("SendAlerts() error = %v", err)
+	}
+	if len(all.messages) != 1 || len(swing.messages) != 0 {
+		t.Fatalf("deliveries = %d, %d, want the alerts only on the sink of all strategies", len(all.messages), len(swing.messages))
+	}
+
+	msg := all.messages[0]
+	if msg.Strategy != AlertsName || len(msg.Alerts) != 2 {
+		t.Errorf("message = %+v, want the 2 alerts", msg)
+	}
+	for _, want := range []string{
+		"2 price alerts triggered on 2024-03-15",
+		"TCS closed at 4215.5, above price 4200 (alert #1)",
+		"INFY closed at 1480 on 2024-03-14, below EMA(50) at 1490.12 (alert #2)",
+	} {
+		if !strings.Contains(msg.Text, want) {
+			t.Errorf("Text = %q, want it to contain %q", msg.Text, want)
+		}
+	}
+}
@@ -0,0 +1,189 @@
+package notify
+
+import (
+	"eeye/src/models"
+	"eeye/src/steps"
+	"eeye/src/utils"
+	"fmt"
+	"math"
+	"os"
+	"sort"
+	"strings"
+	"text/template"
+)
+
+// signalLookback is the number of latest candles the indicators of a signal are computed on.
+// RSI 14 uses Wilder smoothing, which needs about ten periods to settle.
+const signalLookback = 150
+
+// Signal is a stock selected by a strategy, with its latest candle and key indicators.
+// Indicators without enough history are 0.
+type Signal struct {
+	// Symbol is the ticker symbol of the stock
+	Symbol string `json:"symbol"`
+
+	// Day is the trading day of the latest candle (YYYY-MM-DD)
+	Day string `json:"day"`
+
+	// Close is the latest close and ChangePct its change from the previous close in percent
+	Close     float64 `json:"close"`
+	ChangePct float64 `json:"change_pct"`
+
+	// Volume is the latest volume and VolumeMA20 its 20 session average
+	Volume     uint64  `json:"volume"`
+	VolumeMA20 float64 `json:"volume_ma20"`
+
+	// RSI14 is the 14 period relative strength index
+	RSI14 float64 `json:"rsi14"`
+
+	// EMA20 and EMA50 are the 20 and 50 period exponential moving averages of the close
+	EMA20 float64 `json:"ema20"`
+	EMA50 float64 `json:"ema50"`
+
+	// DeliveryPct is the percentage of the latest volume marked for delivery
+	DeliveryPct float64 `json:"delivery_pct"`
+
+	// Status is "new" or "continuing" compared with the previous run of the strategy,
+	// empty if the result was not compared
+	Status string `json:"status,omitempty"`
+}
+
+// Statuses of a compared signal
+const (
+	StatusNew        = "new"
+	StatusContinuing = "continuing"
+)
+
+// last returns the latest value of an indicator rounded to 2 decimals, or 0 if it could
+// not be computed
+func last(values []float64) float64 {
+	if len(values) == 0 || math.IsNaN(values[len(values)-1]) {
+		return 0
+	}
+	return utils.Round2(values[len(values)-1])
+}
+
+// newSignal returns the signal of a stock with the given candles, oldest first
+func newSignal(symbol string, candles []models.Candle) Signal {
+	signal := Signal{Symbol: symbol}
+	if len(candles) == 0 {
+		return signal
+	}
+
+	latest := &candles[len(candles)-1]
+	signal.Day = latest.Timestamp.Format("2006-01-02")
+	signal.Close = utils.Round2(latest.Close)
+	signal.Volume = latest.Volume
+	signal.DeliveryPct = utils.Round2(latest.DeliveryPct)
+	if len(candles) > 1 && candles[len(candles)-2].Close > 0 {
+		previous := candles[len(candles)-2].Close
+		signal.ChangePct = utils.Round2((latest.Close - previous) / previous * 100)
+	}
+
+	signal.VolumeMA20 = last(steps.ComputeVolumeMA(candles, 20))
+	signal.RSI14 = last(steps.ComputeRsi(candles, 14))
+	signal.EMA20 = last(steps.ComputeEma(candles, 20))
+	signal.EMA50 = last(steps.ComputeEma(candles, 50))
+	return signal
+}
+
+// newSignals returns the signals of the stocks selected by a strategy, ordered by symbol.
+// Compared signals are labelled new or continuing; with newOnly the continuing ones are left out.
+func newSignals(result *models.StrategyResult, candles map[string][]models.Candle, newOnly bool) []Signal {
+	signals := make([]Signal, 0, len(result.Stocks))
+	for i := range result.Stocks {
+		symbol := result.Stocks[i].Symbol
+		signal := newSignal(symbol, candles[symbol])
+
+		if result.Diff != nil {
+			signal.Status = StatusContinuing
+			if result.Diff.IsNew(symbol) {
+				signal.Status = StatusNew
+			}
+		}
+		if newOnly && signal.Status == StatusContinuing {
+			continue
+		}
+
+		signals = append(signals, signal)
+	}
+
+	// workers finish stocks in any order
+	sort.Slice(signals, func(i, j int) bool {
+		return signals[i].Symbol < signals[j].Symbol
+	})
+	return signals
+}
+
+// defaultTemplate renders a message with a line per signal, followed by the dropped
+// signals. Templates are executed with the Message (without its Text).
+const defaultTemplate = `{{.Subject}}
+{{range .Signals}}
+{{.Symbol}}{{if eq .Status "new"}} (new){{end}}: close {{.Close}} ({{printf "%+.2f" .ChangePct}}%), RSI14 {{.RSI14}}, EMA20 {{.EMA20}}, EMA50 {{.EMA50}}, volume {{.Volume}} (avg {{.VolumeMA20}}){{if .DeliveryPct}}, delivery {{.DeliveryPct}}%{{end}}{{end}}
+{{if .Dropped}}
+Dropped since {{.Previous}}: {{join .Dropped ", "}}
+{{end}}`
+
+// loadTemplate parses the notification template of the file at path, or the default
+// template if path is empty
+func loadTemplate(path string) (*template.Template, error) {
+	text := defaultTemplate
+	if path != "" {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read notification template: %w", err)
+		}
+		text = string(data)
+	}
+
+	tmpl, err := template.New("notification").
+		Option("missingkey=error").
+		Funcs(template.FuncMap{"join": strings.Join}).
+		Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("invalid notification template: %w", err)
+	}
+	return tmpl, nil
+}
+
+// render returns the message of the signals of a strategy, with the signals dropped since
+// its previous run if it was compared
+func (d *Dispatcher) render(strategy string, signals []Signal, diff *models.SignalDiff) (*Message, error) {
+	msg := &Message{Strategy: strategy, Signals: signals}
+	for i := range signals {
+		msg.Day = max(msg.Day, signals[i].Day)
+	}
+	if msg.Day == "" && diff != nil && !diff.Day.IsZero() {
+		msg.Day = diff.Day.Format("2006-01-02")
+	}
+
+	fresh := 0
+	for i := range signals {
+		if signals[i].Status == StatusNew {
+			fresh++
+		}
+	}
+
+	switch {
+	case len(signals) == 0 && diff != nil:
+		msg.Subject = fmt.Sprintf("eeye: %v dropped %d stocks on %v", strategy, len(diff.Dropped), msg.Day)
+	case diff == nil:
+		msg.Subject = fmt.Sprintf("eeye: %v selected %d stocks on %v", strategy, len(signals), msg.Day)
+	case fresh == len(signals):
+		msg.Subject = fmt.Sprintf("eeye: %v selected %d new stocks on %v", strategy, fresh, msg.Day)
+	default:
+		msg.Subject = fmt.Sprintf("eeye: %v selected %d stocks (%d new) on %v", strategy, len(signals), fresh, msg.Day)
+	}
+
+	if diff != nil && !diff.Previous.IsZero() {
+		msg.Previous = diff.Previous.Format("2006-01-02")
+		msg.Dropped = diff.Dropped
+	}
+
+	text := strings.Builder{}
+	if err := d.template.Execute(&text, msg); err != nil {
+		return nil, fmt.Errorf("failed to render notification of %v: %w", strategy, err)
+	}
+	msg.Text = text.String()
+	return msg, nil
+}
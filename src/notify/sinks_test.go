@@ -0,0 +1,188 @@
+package notify
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+var testMessage = &Message{
+	Strategy: "Bullish Swing",
+	Day:      "2025-03-20",
+	Subject:  "eeye: Bullish Swing selected 1 stocks on 2025-03-20",
+	Text:     "eeye: Bullish Swing selected 1 stocks on 2025-03-20\n\nTCS: close 4000",
+	Signals:  []Signal{{Symbol: "TCS", Day: "2025-03-20", Close: 4000, RSI14: 55.5}},
+}
+
+func TestWebhook(t *testing.T) {
+	var received Message
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.Header.Get("Content-Type") != "application/json" {
+			t.Errorf("request = %v %v, want a JSON POST", r.Method, r.Header.Get("Content-Type"))
+		}
+		if err := json.NewDecoder(r.Body).Decode(&received); err != nil {
+			t.Errorf("invalid body: %v", err)
+		}
+		if r.URL.Path == "/fail" {
+			w.WriteHeader(http.StatusBadGateway)
+		}
+	}))
+	defer server.Close()
+
+	if err := newWebhook(server.URL+"/hook").Notify(context.Background(), testMessage); err != nil {
+		t.Fatalf("Notify() error = %v", err)
+	}
+	if received.Strategy != "Bullish Swing" || len(received.Signals) != 1 || received.Signals[0].RSI14 != 55.5 {
+		t.Errorf("received %+v, want the message", received)
+	}
+
+	err := newWebhook(server.URL+"/fail").Notify(context.Background(), testMessage)
+	if err == nil || !strings.Contains(err.Error(), "502") {
+		t.Errorf("Notify() error = %v, want the response status", err)
+	}
+}
+
+func TestTelegram(t *testing.T) {
+	var received map[string]string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/botsecret/sendMessage" {
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{"ok":false,"description":"Not Found"}`))
+			return
+		}
+		if err := json.NewDecoder(r.Body).Decode(&received); err != nil {
+			t.Errorf("invalid body: %v", err)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"ok":true}`))
+	}))
+	defer server.Close()
+
+	if err := newTelegram(server.URL+"/", "secret", "-100").Notify(context.Background(), testMessage); err != nil {
+		t.Fatalf("Notify() error = %v", err)
+	}
+	if received["chat_id"] != "-100" || received["text"] != testMessage.Text {
+		t.Errorf("received %v, want the chat and text of the message", received)
+	}
+
+	err := newTelegram(server.URL, "wrong", "-100").Notify(context.Background(), testMessage)
+	if err == nil || !strings.Contains(err.Error(), "Not Found") || strings.Contains(err.Error(), "wrong") {
+		t.Errorf("Notify() error = %v, want the description without the token", err)
+	}
+}
+
+func TestTruncate(t *testing.T) {
+	if got := truncate("héllo", 5); got != "héllo" {
+		t.Errorf("truncate() = %q, want it unchanged", got)
+	}
+	if got := truncate("héllo world", 5); got != "héll…" {
+		t.Errorf("truncate() = %q, want héll…", got)
+	}
+}
+
+// serveSMTP answers a single SMTP session on l and returns the commands and data received
+func serveSMTP(t *testing.T, l net.Listener) <-chan []string {
+	t.Helper()
+
+	received := make(chan []string, 1)
+	go func() {
+		defer close(received)
+
+		conn, err := l.Accept()
+		if err != nil {
+			return
+		}
+		defer func() {
+			_ = conn.Close()
+		}()
+
+		var (
+			lines  = make([]string, 0)
+			reader = bufio.NewReader(conn)
+			reply  = func(s string) {
+				_, _ = conn.Write([]byte(s + "\r\n"))
+			}
+			data = false
+		)
+
+		reply("220 localhost ESMTP")
+		for {
+			line, err := reader.ReadString('\n')
+			if err != nil {
+				received <- lines
+				return
+			}
+			line = strings.TrimRight(line, "\r\n")
+			lines = append(lines, line)
+
+			switch {
+			case data && line == ".":
+				data = false
+				reply("250 queued")
+			case data:
+			case strings.HasPrefix(line, "EHLO"):
+				reply("250-localhost")
+				reply("250 8BITMIME")
+			case line == "DATA":
+				data = true
+				reply("354 go ahead")
+			case line == "QUIT":
+				reply("221 bye")
+				received <- lines
+				return
+			default:
+				reply("250 ok")
+			}
+		}
+	}()
+	return received
+}
+
+func TestSMTP(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		_ = l.Close()
+	}()
+	received := serveSMTP(t, l)
+
+	host, port, _ := net.SplitHostPort(l.Addr().String())
+	notifier, err := newSMTP(host, port, "", "", "eeye <eeye@example.com>", "a@example.com, B <b@example.com>")
+	if err != nil {
+		t.Fatalf("newSMTP() error = %v", err)
+	}
+
+	if err := notifier.Notify(context.Background(), testMessage); err != nil {
+		t.Fatalf("Notify() error = %v", err)
+	}
+
+	session := strings.Join(<-received, "\n")
+	for _, want := range []string{
+		"MAIL FROM:<eeye@example.com>",
+		"RCPT TO:<a@example.com>",
+		"RCPT TO:<b@example.com>",
+		"Subject: eeye: Bullish Swing selected 1 stocks on 2025-03-20",
+		`To: <a@example.com>, "B" <b@example.com>`,
+		"TCS: close 4000",
+	} {
+		if !strings.Contains(session, want) {
+			t.Errorf("session = %q, want it to contain %q", session, want)
+		}
+	}
+}
+
+func TestNewSMTPInvalidAddress(t *testing.T) {
+	if _, err := newSMTP("localhost", "25", "", "", "eeye", "a@example.com"); err == nil {
+		t.Error("newSMTP() error = nil, want an invalid sender")
+	}
+	if _, err := newSMTP("localhost", "25", "", "", "eeye@example.com", "a@example.com, b"); err == nil {
+		t.Error("newSMTP() error = nil, want an invalid recipient list")
+	}
+}
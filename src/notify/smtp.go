@@ -0,0 +1,126 @@
+package notify
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"mime"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"strings"
+	"time"
+)
+
+// smtpNotifier emails every message as plain text
+type smtpNotifier struct {
+	host     string
+	port     string
+	user     string
+	password string
+	from     *mail.Address
+	to       []*mail.Address
+}
+
+// newSMTP returns a Notifier sending emails through the SMTP server at host:port.
+// to is a comma separated list of addresses.
+func newSMTP(host string, port string, user string, password string, from string, to string) (*smtpNotifier, error) {
+	sender, err := mail.ParseAddress(from)
+	if err != nil {
+		return nil, fmt.Errorf("invalid EEYE_SMTP_FROM %q: %w", from, err)
+	}
+
+	recipients, err := mail.ParseAddressList(to)
+	if err != nil {
+		return nil, fmt.Errorf("invalid EEYE_SMTP_TO %q: %w", to, err)
+	}
+
+	return &smtpNotifier{
+		host:     host,
+		port:     port,
+		user:     user,
+		password: password,
+		from:     sender,
+		to:       recipients,
+	}, nil
+}
+
+// Name identifies the sink in logs
+func (s *smtpNotifier) Name() string {
+	return "smtp"
+}
+
+// email returns the message as an RFC 5322 email
+func (s *smtpNotifier) email(msg *Message, date time.Time) string {
+	to := make([]string, 0, len(s.to))
+	for _, addr := range s.to {
+		to = append(to, addr.String())
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "From: %v\r\n", s.from)
+	fmt.Fprintf(&b, "To: %v\r\n", strings.Join(to, ", "))
+	fmt.Fprintf(&b, "Subject: %v\r\n", mime.QEncoding.Encode("utf-8", msg.Subject))
+	fmt.Fprintf(&b, "Date: %v\r\n", date.Format(time.RFC1123Z))
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+	b.WriteString("\r\n")
+	b.WriteString(msg.Text)
+	return b.String()
+}
+
+// Notify sends the message to all recipients. STARTTLS is used whenever the server
+// offers it; without a user the server is not authenticated with.
+func (s *smtpNotifier) Notify(ctx context.Context, msg *Message) error {
+	dialer := net.Dialer{}
+	conn, err := dialer.DialContext(ctx, "tcp", net.JoinHostPort(s.host, s.port))
+	if err != nil {
+		return fmt.Errorf("dial failed: %w", err)
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = conn.SetDeadline(deadline)
+	}
+
+	client, err := smtp.NewClient(conn, s.host)
+	if err != nil {
+		_ = conn.Close()
+		return fmt.Errorf("handshake failed: %w", err)
+	}
+	defer func() {
+		_ = client.Close()
+	}()
+
+	if ok, _ := client.Extension("STARTTLS"); ok {
+		if err := client.StartTLS(&tls.Config{ServerName: s.host}); err != nil {
+			return fmt.Errorf("starttls failed: %w", err)
+		}
+	}
+
+	if s.user != "" {
+		if err := client.Auth(smtp.PlainAuth("", s.user, s.password, s.host)); err != nil {
+			return fmt.Errorf("auth failed: %w", err)
+		}
+	}
+
+	if err := client.Mail(s.from.Address); err != nil {
+		return fmt.Errorf("mail failed: %w", err)
+	}
+	for _, addr := range s.to {
+		if err := client.Rcpt(addr.Address); err != nil {
+			return fmt.Errorf("rcpt %v failed: %w", addr.Address, err)
+		}
+	}
+
+	w, err := client.Data()
+	if err != nil {
+		return fmt.Errorf("data failed: %w", err)
+	}
+	if _, err := w.Write([]byte(s.email(msg, time.Now()))); err != nil {
+		return fmt.Errorf("data failed: %w", err)
+	}
+	if err := w.Close(); err != nil {
+		return fmt.Errorf("data failed: %w", err)
+	}
+
+	return client.Quit()
+}
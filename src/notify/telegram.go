@@ -0,0 +1,67 @@
+package notify
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/go-resty/resty/v2"
+)
+
+// telegramMaxLength is the longest message accepted by the Telegram bot API, in characters
+const telegramMaxLength = 4096
+
+// telegram posts every message to a chat through the Telegram bot API
+type telegram struct {
+	client *resty.Client
+	chatID string
+}
+
+// newTelegram returns a Notifier posting to the chat with the bot of token
+func newTelegram(baseURL string, token string, chatID string) *telegram {
+	client := resty.New()
+	client.SetBaseURL(fmt.Sprintf("%v/bot%v", strings.TrimSuffix(baseURL, "/"), token))
+
+	return &telegram{client: client, chatID: chatID}
+}
+
+// Name identifies the sink in logs
+func (t *telegram) Name() string {
+	return "telegram"
+}
+
+// truncate shortens text to at most n characters
+func truncate(text string, n int) string {
+	if utf8.RuneCountInString(text) <= n {
+		return text
+	}
+	runes := []rune(text)
+	return string(runes[:n-1]) + "…"
+}
+
+// Notify sends the text of the message, truncated to the longest message Telegram accepts
+func (t *telegram) Notify(ctx context.Context, msg *Message) error {
+	var result struct {
+		OK          bool   `json:"ok"`
+		Description string `json:"description"`
+	}
+
+	resp, err := t.client.R().
+		SetContext(ctx).
+		SetBody(map[string]string{
+			"chat_id": t.chatID,
+			"text":    truncate(msg.Text, telegramMaxLength),
+		}).
+		SetResult(&result).
+		SetError(&result).
+		Post("/sendMessage")
+	if err != nil {
+		return fmt.Errorf("request failed: %w", unwrapURLError(err))
+	}
+
+	if resp.IsError() || !result.OK {
+		return fmt.Errorf("unexpected response %v: %v", resp.Status(), result.Description)
+	}
+	return nil
+}
@@ -0,0 +1,41 @@
+package notify
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/go-resty/resty/v2"
+)
+
+// webhook POSTs every message as JSON to a generic HTTP endpoint
+type webhook struct {
+	client *resty.Client
+	url    string
+}
+
+// newWebhook returns a Notifier posting to url
+func newWebhook(url string) *webhook {
+	return &webhook{client: resty.New(), url: url}
+}
+
+// Name identifies the sink in logs
+func (w *webhook) Name() string {
+	return "webhook"
+}
+
+// Notify POSTs the message; any status other than 2xx is an error
+func (w *webhook) Notify(ctx context.Context, msg *Message) error {
+	resp, err := w.client.R().
+		SetContext(ctx).
+		SetHeader("Content-Type", "application/json").
+		SetBody(msg).
+		Post(w.url)
+	if err != nil {
+		return fmt.Errorf("request failed: %w", unwrapURLError(err))
+	}
+
+	if resp.IsError() {
+		return fmt.Errorf("unexpected response %v", resp.Status())
+	}
+	return nil
+}
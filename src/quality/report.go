@@ -0,0 +1,91 @@
+package quality
+
+import (
+	"context"
+	"eeye/src/db"
+	"eeye/src/utils"
+	"fmt"
+	"io"
+	"sort"
+	"text/tabwriter"
+	"time"
+)
+
+// Report prints a summary of the data quality issues detected since the given
+// time, followed by every issue, to w.
+func Report(ctx context.Context, w io.Writer, since time.Time) error {
+	issues, err := db.FetchCandleIssues(ctx, since)
+	if err != nil {
+		return fmt.Errorf("failed to fetch candle issues: %w", err)
+	}
+
+	if len(issues) == 0 {
+		_, err := fmt.Fprintf(w, "no candle issues detected since %v\n", since.Format("2006-01-02"))
+		return err
+	}
+
+	type summaryKey struct {
+		check    string
+		severity string
+	}
+
+	var (
+		counts      = make(map[summaryKey]int)
+		quarantined = 0
+		tw          = tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	)
+
+	for i := range issues {
+		counts[summaryKey{check: issues[i].Check, severity: issues[i].Severity}]++
+		if issues[i].Quarantined {
+			quarantined++
+		}
+	}
+
+	keys := make([]summaryKey, 0, len(counts))
+	for k := range counts {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].severity != keys[j].severity {
+			return keys[i].severity < keys[j].severity
+		}
+		return keys[i].check < keys[j].check
+	})
+
+	_, _ = fmt.Fprintf(tw, "Candle issues since %v: %d (%d quarantined)\n\n", since.Format("2006-01-02"), len(issues), quarantined)
+	_, _ = fmt.Fprintln(tw, "SEVERITY\tCHECK\tCOUNT")
+	for _, k := range keys {
+		_, _ = fmt.Fprintf(tw, "%v\t%v\t%d\n", k.severity, k.check, counts[k])
+	}
+
+	_, _ = fmt.Fprintln(tw, "\nDATE\tSYMBOL\tSEVERITY\tCHECK\tQUARANTINED\tOHLCV\tDETAIL")
+	for i := range issues {
+		issue := &issues[i]
+		ohlcv := "-"
+		if issue.Check != CheckMissingTradingDay {
+			ohlcv = fmt.Sprintf(
+				"%v/%v/%v/%v/%v",
+				utils.Round2(issue.Candle.Open),
+				utils.Round2(issue.Candle.High),
+				utils.Round2(issue.Candle.Low),
+				utils.Round2(issue.Candle.Close),
+				issue.Candle.Volume,
+			)
+		}
+
+		_, _ = fmt.Fprintf(
+			tw,
+			"%v\t%v\t%v\t%v\t%v\t%v\t%v\n",
+			issue.Timestamp.Format("2006-01-02"),
+			issue.Symbol,
+			issue.Severity,
+			issue.Check,
+			issue.Quarantined,
+			ohlcv,
+			issue.Detail,
+		)
+	}
+
+	return tw.Flush()
+}
@@ -0,0 +1,216 @@
+// Package quality validates ingested candles before they reach the database.
+// Candles which cannot be trusted are quarantined, suspicious ones are flagged,
+// and every finding is recorded so that strategies are not triggered by bad ticks.
+package quality
+
+import (
+	"eeye/src/calendar"
+	"eeye/src/models"
+	"fmt"
+	"math"
+	"time"
+)
+
+const (
+	// MaxGapRatio is the largest move from the previous close (40%) accepted
+	// without a corporate action explaining it
+	MaxGapRatio = 0.4
+
+	// ZeroVolumeStreak is the number of consecutive zero volume candles that gets flagged
+	ZeroVolumeStreak = 5
+)
+
+// Names of the validation checks
+const (
+	// CheckDuplicateDate flags a second candle for the same trading day
+	CheckDuplicateDate = "duplicate_date"
+
+	// CheckNonPositivePrice flags zero or negative prices
+	CheckNonPositivePrice = "non_positive_price"
+
+	// CheckHighBelowLow flags candles whose high is below their low
+	CheckHighBelowLow = "high_below_low"
+
+	// CheckOpenOutsideRange flags candles whose open is outside [low, high]
+	CheckOpenOutsideRange = "open_outside_range"
+
+	// CheckCloseOutsideRange flags candles whose close is outside [low, high]
+	CheckCloseOutsideRange = "close_outside_range"
+
+	// CheckPriceGap flags large moves from the previous close without a corporate action
+	CheckPriceGap = "price_gap"
+
+	// CheckZeroVolumeStreak flags a run of consecutive zero volume candles
+	CheckZeroVolumeStreak = "zero_volume_streak"
+
+	// CheckMissingTradingDay flags trading days without a candle
+	CheckMissingTradingDay = "missing_trading_day"
+)
+
+// sessionDate returns the trading day of a candle timestamp. Candles read from the
+// database carry the wall clock of EEYE_TZ in UTC, so only the date part is used.
+func sessionDate(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, calendar.NSE.Location())
+}
+
+// newIssue creates an issue for the given candle
+func newIssue(candle *models.Candle, check string, severity string, detail string) models.CandleIssue {
+	return models.CandleIssue{
+		Symbol:      candle.Symbol,
+		Timestamp:   candle.Timestamp,
+		Check:       check,
+		Severity:    severity,
+		Detail:      detail,
+		Quarantined: severity == models.SeverityError,
+		Candle:      *candle,
+	}
+}
+
+// structuralIssues returns the errors which make a single candle unusable
+func structuralIssues(candle *models.Candle) []models.CandleIssue {
+	issues := make([]models.CandleIssue, 0)
+
+	if candle.Open <= 0 || candle.High <= 0 || candle.Low <= 0 || candle.Close <= 0 {
+		issues = append(issues, newIssue(
+			candle,
+			CheckNonPositivePrice,
+			models.SeverityError,
+			fmt.Sprintf("o=%v h=%v l=%v c=%v", candle.Open, candle.High, candle.Low, candle.Close),
+		))
+	}
+
+	if candle.High < candle.Low {
+		issues = append(issues, newIssue(
+			candle,
+			CheckHighBelowLow,
+			models.SeverityError,
+			fmt.Sprintf("high %v < low %v", candle.High, candle.Low),
+		))
+		// range checks are meaningless without a valid range
+		return issues
+	}
+
+	if candle.Open < candle.Low || candle.Open > candle.High {
+		issues = append(issues, newIssue(
+			candle,
+			CheckOpenOutsideRange,
+			models.SeverityError,
+			fmt.Sprintf("open %v outside [%v, %v]", candle.Open, candle.Low, candle.High),
+		))
+	}
+
+	if candle.Close < candle.Low || candle.Close > candle.High {
+		issues = append(issues, newIssue(
+			candle,
+			CheckCloseOutsideRange,
+			models.SeverityError,
+			fmt.Sprintf("close %v outside [%v, %v]", candle.Close, candle.Low, candle.High),
+		))
+	}
+
+	return issues
+}
+
+// Validate checks a batch of new candles (sorted by time) against each other, the
+// previously stored candle and the trading calendar.
+//
+// Errors (duplicate dates, non-positive prices, high below low, open/close outside
+// the range) quarantine the candle. Warnings (large gaps without a corporate action,
+// zero volume streaks, missing trading days) are recorded but the candle is kept.
+//
+// Parameters:
+//   - previous: Latest candle already stored, prices are zero if there is none
+//   - candles: New candles to validate
+//   - actions: Corporate actions of the stock keyed by ex-date (YYYY-MM-DD)
+//
+// Returns:
+//   - Candles that passed validation and can be ingested
+//   - All issues found, including those of quarantined candles
+func Validate(
+	previous *models.Candle,
+	candles []models.Candle,
+	actions map[string]string,
+) ([]models.Candle, []models.CandleIssue) {
+	var (
+		clean      = make([]models.Candle, 0, len(candles))
+		issues     = make([]models.CandleIssue, 0)
+		seen       = make(map[string]struct{})
+		hasLast    = previous != nil && previous.Close > 0
+		lastClose  float64
+		lastDay    time.Time
+		zeroStreak = 0
+	)
+
+	if hasLast {
+		lastClose = previous.Close
+		lastDay = sessionDate(previous.Timestamp)
+	}
+
+	for i := range candles {
+		var (
+			candle = &candles[i]
+			day    = sessionDate(candle.Timestamp)
+			key    = day.Format("2006-01-02")
+		)
+
+		if _, ok := seen[key]; ok {
+			issues = append(issues, newIssue(candle, CheckDuplicateDate, models.SeverityError, "more than one candle for "+key))
+			continue
+		}
+		seen[key] = struct{}{}
+
+		if errs := structuralIssues(candle); len(errs) > 0 {
+			issues = append(issues, errs...)
+			continue
+		}
+
+		if hasLast {
+			// Trading days between the last good candle and this one without data
+			if day.After(lastDay) {
+				for _, missing := range calendar.NSE.TradingDaysBetween(lastDay.AddDate(0, 0, 1), day.AddDate(0, 0, -1)) {
+					placeholder := models.Candle{Symbol: candle.Symbol, Timestamp: missing}
+					issues = append(issues, newIssue(
+						&placeholder,
+						CheckMissingTradingDay,
+						models.SeverityWarning,
+						"no candle for "+missing.Format("2006-01-02"),
+					))
+				}
+			}
+
+			gap := math.Max(
+				math.Abs(candle.Open-lastClose),
+				math.Abs(candle.Close-lastClose),
+			) / lastClose
+			if _, ok := actions[key]; !ok && gap > MaxGapRatio {
+				issues = append(issues, newIssue(
+					candle,
+					CheckPriceGap,
+					models.SeverityWarning,
+					fmt.Sprintf("moved %.1f%% from previous close %v", gap*100, lastClose),
+				))
+			}
+		}
+
+		if candle.Volume == 0 {
+			zeroStreak++
+			if zeroStreak == ZeroVolumeStreak {
+				issues = append(issues, newIssue(
+					candle,
+					CheckZeroVolumeStreak,
+					models.SeverityWarning,
+					fmt.Sprintf("%d consecutive candles with zero volume", zeroStreak),
+				))
+			}
+		} else {
+			zeroStreak = 0
+		}
+
+		clean = append(clean, *candle)
+		hasLast = true
+		lastClose = candle.Close
+		lastDay = day
+	}
+
+	return clean, issues
+}
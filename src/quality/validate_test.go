@@ -0,0 +1,184 @@
+package quality
+
+import (
+	"eeye/src/models"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// candle returns a candle of the given day of January 2024 (the 1st is a Monday)
+func candle(day int, open float64, high float64, low float64, closePrice float64) models.Candle {
+	return models.Candle{
+		Symbol:    "TCS",
+		Open:      open,
+		High:      high,
+		Low:       low,
+		Close:     closePrice,
+		Timestamp: time.Date(2024, time.January, day, 0, 0, 0, 0, time.UTC),
+		Volume:    1000,
+	}
+}
+
+// flat returns a candle of the given day of January 2024 trading at price
+func flat(day int, price float64) models.Candle {
+	return candle(day, price, price, price, price)
+}
+
+// idle returns a flat candle without volume
+func idle(day int, price float64) models.Candle {
+	c := flat(day, price)
+	c.Volume = 0
+	return c
+}
+
+func TestValidate(t *testing.T) {
+	var (
+		previous = flat(1, 100)
+		friday   = flat(5, 100)
+	)
+
+	tests := []struct {
+		name     string
+		previous *models.Candle
+		candles  []models.Candle
+		actions  map[string]string
+		// wantClean holds the days of the accepted candles
+		wantClean []int
+		// wantIssues holds the checks of the issues found, in order
+		wantIssues []string
+		// wantQuarantined holds the quarantined flag of the issues found, in order
+		wantQuarantined []bool
+	}{
+		{
+			name:      "valid candles",
+			previous:  &previous,
+			candles:   []models.Candle{flat(2, 101), candle(3, 101, 103, 100, 102)},
+			wantClean: []int{2, 3},
+		},
+		{
+			name:            "non positive price",
+			candles:         []models.Candle{flat(2, 100), candle(3, 0, 101, 99, 100)},
+			wantClean:       []int{2},
+			wantIssues:      []string{CheckNonPositivePrice, CheckOpenOutsideRange},
+			wantQuarantined: []bool{true, true},
+		},
+		{
+			name:            "high below low skips the range checks",
+			candles:         []models.Candle{candle(2, 150, 99, 101, 50)},
+			wantClean:       []int{},
+			wantIssues:      []string{CheckHighBelowLow},
+			wantQuarantined: []bool{true},
+		},
+		{
+			name:            "open and close outside the range",
+			candles:         []models.Candle{candle(2, 105, 103, 99, 98)},
+			wantClean:       []int{},
+			wantIssues:      []string{CheckOpenOutsideRange, CheckCloseOutsideRange},
+			wantQuarantined: []bool{true, true},
+		},
+		{
+			name:            "duplicate date keeps the first candle",
+			candles:         []models.Candle{flat(2, 100), flat(2, 101), flat(3, 102)},
+			wantClean:       []int{2, 3},
+			wantIssues:      []string{CheckDuplicateDate},
+			wantQuarantined: []bool{true},
+		},
+		{
+			name:            "missing trading days",
+			previous:        &previous,
+			candles:         []models.Candle{flat(4, 100)},
+			wantClean:       []int{4},
+			wantIssues:      []string{CheckMissingTradingDay, CheckMissingTradingDay},
+			wantQuarantined: []bool{false, false},
+		},
+		{
+			name:      "weekend is not missing",
+			previous:  &friday,
+			candles:   []models.Candle{flat(8, 100)},
+			wantClean: []int{8},
+		},
+		{
+			name:            "missing day after a quarantined candle",
+			candles:         []models.Candle{flat(2, 100), flat(3, -1), flat(4, 100)},
+			wantClean:       []int{2, 4},
+			wantIssues:      []string{CheckNonPositivePrice, CheckMissingTradingDay},
+			wantQuarantined: []bool{true, false},
+		},
+		{
+			name:            "price jump from the stored close",
+			previous:        &previous,
+			candles:         []models.Candle{candle(2, 100, 150, 100, 150)},
+			wantClean:       []int{2},
+			wantIssues:      []string{CheckPriceGap},
+			wantQuarantined: []bool{false},
+		},
+		{
+			name:            "price gap within the batch",
+			candles:         []models.Candle{flat(2, 100), flat(3, 50)},
+			wantClean:       []int{2, 3},
+			wantIssues:      []string{CheckPriceGap},
+			wantQuarantined: []bool{false},
+		},
+		{
+			name:      "move below the threshold",
+			previous:  &previous,
+			candles:   []models.Candle{candle(2, 100, 139, 100, 139)},
+			wantClean: []int{2},
+		},
+		{
+			name:      "price gap on the ex-date of a corporate action",
+			previous:  &previous,
+			candles:   []models.Candle{flat(2, 50), flat(3, 51)},
+			actions:   map[string]string{"2024-01-02": "split 1:2"},
+			wantClean: []int{2, 3},
+		},
+		{
+			name:            "corporate action on another day",
+			previous:        &previous,
+			candles:         []models.Candle{flat(2, 50)},
+			actions:         map[string]string{"2024-01-03": "split 1:2"},
+			wantClean:       []int{2},
+			wantIssues:      []string{CheckPriceGap},
+			wantQuarantined: []bool{false},
+		},
+		{
+			name:            "zero volume streak is flagged once",
+			candles:         []models.Candle{idle(1, 100), idle(2, 100), idle(3, 100), idle(4, 100), idle(5, 100), idle(8, 100)},
+			wantClean:       []int{1, 2, 3, 4, 5, 8},
+			wantIssues:      []string{CheckZeroVolumeStreak},
+			wantQuarantined: []bool{false},
+		},
+		{
+			name:      "zero volume streak reset by volume",
+			candles:   []models.Candle{idle(1, 100), idle(2, 100), idle(3, 100), idle(4, 100), flat(5, 100), idle(8, 100)},
+			wantClean: []int{1, 2, 3, 4, 5, 8},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clean, issues := Validate(tt.previous, tt.candles, tt.actions)
+
+			days := make([]int, 0, len(clean))
+			for i := range clean {
+				days = append(days, clean[i].Timestamp.Day())
+			}
+			if !reflect.DeepEqual(days, tt.wantClean) {
+				t.Errorf("Validate() accepted days %v, want %v", days, tt.wantClean)
+			}
+
+			var (
+				checks      []string
+				quarantined []bool
+			)
+			for i := range issues {
+				checks = append(checks, issues[i].Check)
+				quarantined = append(quarantined, issues[i].Quarantined)
+			}
+			if !reflect.DeepEqual(checks, tt.wantIssues) || !reflect.DeepEqual(quarantined, tt.wantQuarantined) {
+				t.Errorf("Validate() issues %v quarantined %v, want %v quarantined %v", checks, quarantined, tt.wantIssues, tt.wantQuarantined)
+			}
+		})
+	}
+}
@@ -0,0 +1,195 @@
+// Package scheduler runs the screener as a long-lived daemon.
+// It parses cron-like expressions and triggers ingestion and analysis
+// once the post-market data for a trading session is published.
+package scheduler
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// field describes the valid range of a single cron field
+type field struct {
+	name string
+	min  int
+	max  int
+}
+
+var fields = []field{
+	{name: "minute", min: 0, max: 59},
+	{name: "hour", min: 0, max: 23},
+	{name: "day of month", min: 1, max: 31},
+	{name: "month", min: 1, max: 12},
+	{name: "day of week", min: 0, max: 7},
+}
+
+// Schedule is a parsed cron expression with the standard five fields:
+// minute, hour, day of month, month and day of week.
+type Schedule struct {
+	minutes  map[int]struct{}
+	hours    map[int]struct{}
+	days     map[int]struct{}
+	months   map[int]struct{}
+	weekdays map[int]struct{}
+
+	// restricted day fields follow the cron rule: when both day of month
+	// and day of week are restricted, a day matches if either one matches
+	anyDay     bool
+	anyWeekday bool
+}
+
+// Parse parses a cron expression such as "30 18 * * 1-5".
+// Each field supports "*", single values, ranges ("1-5"), lists ("1,3,5")
+// and steps ("*/15", "0-30/10", or "5/10" which starts at 5 and runs to the end of
+// the field's range). Day of week accepts both 0 and 7 for Sunday.
+func Parse(expr string) (*Schedule, error) {
+	parts := strings.Fields(expr)
+	if len(parts) != len(fields) {
+		return nil, fmt.Errorf("cron expression %q must have %d fields", expr, len(fields))
+	}
+
+	sets := make([]map[int]struct{}, 0, len(fields))
+	for i := range parts {
+		set, err := parseField(parts[i], fields[i])
+		if err != nil {
+			return nil, fmt.Errorf("cron expression %q: %w", expr, err)
+		}
+		sets = append(sets, set)
+	}
+
+	// Sunday can be written as 7
+	if _, ok := sets[4][7]; ok {
+		sets[4][0] = struct{}{}
+	}
+
+	return &Schedule{
+		minutes:    sets[0],
+		hours:      sets[1],
+		days:       sets[2],
+		months:     sets[3],
+		weekdays:   sets[4],
+		anyDay:     parts[2] == "*",
+		anyWeekday: parts[4] == "*",
+	}, nil
+}
+
+// parseField expands a single cron field into the set of values it matches
+func parseField(expr string, f field) (map[int]struct{}, error) {
+	set := make(map[int]struct{})
+
+	for item := range strings.SplitSeq(expr, ",") {
+		var (
+			rangeExpr = item
+			step      = 1
+		)
+
+		before, after, stepped := strings.Cut(item, "/")
+		if stepped {
+			v, err := strconv.Atoi(after)
+			if err != nil || v <= 0 {
+				return nil, fmt.Errorf("invalid step %q in %v field", after, f.name)
+			}
+			rangeExpr, step = before, v
+		}
+
+		var (
+			lo = f.min
+			hi = f.max
+		)
+
+		switch {
+		case rangeExpr == "*":
+		case strings.Contains(rangeExpr, "-"):
+			before, after, _ := strings.Cut(rangeExpr, "-")
+			a, errA := strconv.Atoi(before)
+			b, errB := strconv.Atoi(after)
+			if errA != nil || errB != nil {
+				return nil, fmt.Errorf("invalid range %q in %v field", rangeExpr, f.name)
+			}
+			lo, hi = a, b
+		default:
+			v, err := strconv.Atoi(rangeExpr)
+			if err != nil {
+				return nil, fmt.Errorf("invalid value %q in %v field", rangeExpr, f.name)
+			}
+			// A stepped single value runs up to the end of the range, like cron
+			lo, hi = v, v
+			if stepped {
+				hi = f.max
+			}
+		}
+
+		if lo < f.min || hi > f.max || lo > hi {
+			return nil, fmt.Errorf("%q out of range [%d, %d] in %v field", rangeExpr, f.min, f.max, f.name)
+		}
+
+		for v := lo; v <= hi; v += step {
+			set[v] = struct{}{}
+		}
+	}
+
+	return set, nil
+}
+
+// matchesDay reports whether the schedule fires on the day of t
+func (s *Schedule) matchesDay(t time.Time) bool {
+	if _, ok := s.months[int(t.Month())]; !ok {
+		return false
+	}
+
+	_, dayOk := s.days[t.Day()]
+	_, weekdayOk := s.weekdays[int(t.Weekday())]
+
+	switch {
+	case s.anyDay && s.anyWeekday:
+		return true
+	case s.anyDay:
+		return weekdayOk
+	case s.anyWeekday:
+		return dayOk
+	default:
+		return dayOk || weekdayOk
+	}
+}
+
+// Next returns the first time strictly after t at which the schedule fires.
+// The returned time is in the same location as t. A zero time is returned
+// if the schedule never fires within the next five years (e.g. "0 0 31 2 *").
+func (s *Schedule) Next(t time.Time) time.Time {
+	const (
+		MaxDays = 5 * 366
+	)
+
+	var (
+		loc   = t.Location()
+		start = t.Truncate(time.Minute).Add(time.Minute)
+		day   = time.Date(start.Year(), start.Month(), start.Day(), 0, 0, 0, 0, loc)
+	)
+
+	for range MaxDays {
+		if s.matchesDay(day) {
+			for hour := range 24 {
+				if _, ok := s.hours[hour]; !ok {
+					continue
+				}
+
+				for minute := range 60 {
+					if _, ok := s.minutes[minute]; !ok {
+						continue
+					}
+
+					candidate := time.Date(day.Year(), day.Month(), day.Day(), hour, minute, 0, 0, loc)
+					if !candidate.Before(start) {
+						return candidate
+					}
+				}
+			}
+		}
+
+		day = day.AddDate(0, 0, 1)
+	}
+
+	return time.Time{}
+}
@@ -0,0 +1,135 @@
+package scheduler
+
+import (
+	"reflect"
+	"slices"
+	"testing"
+	"time"
+)
+
+// ist is the location the daemon runs in by default; it has no daylight saving time
+var ist = time.FixedZone("IST", 5*3600+1800)
+
+// at returns the given time in IST
+func at(year int, month time.Month, day int, hour int, minute int) time.Time {
+	return time.Date(year, month, day, hour, minute, 0, 0, ist)
+}
+
+// values returns the sorted values of a parsed field
+func values(set map[int]struct{}) []int {
+	res := make([]int, 0, len(set))
+	for v := range set {
+		res = append(res, v)
+	}
+	slices.Sort(res)
+	return res
+}
+
+func TestParseField(t *testing.T) {
+	var (
+		minute  = fields[0]
+		month   = fields[3]
+		weekday = fields[4]
+	)
+
+	tests := []struct {
+		name  string
+		expr  string
+		field field
+		want  []int
+	}{
+		{name: "any", expr: "*", field: month, want: []int{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12}},
+		{name: "single value", expr: "30", field: minute, want: []int{30}},
+		{name: "range", expr: "1-5", field: weekday, want: []int{1, 2, 3, 4, 5}},
+		{name: "list", expr: "1,3,5", field: weekday, want: []int{1, 3, 5}},
+		{name: "list of ranges", expr: "1-2,10-11", field: month, want: []int{1, 2, 10, 11}},
+		{name: "step over any", expr: "*/15", field: minute, want: []int{0, 15, 30, 45}},
+		{name: "step over a range", expr: "0-30/10", field: minute, want: []int{0, 10, 20, 30}},
+		{name: "step from a value", expr: "5/20", field: minute, want: []int{5, 25, 45}},
+		{name: "step from a value within a list", expr: "1,6/3", field: month, want: []int{1, 6, 9, 12}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseField(tt.expr, tt.field)
+			if err != nil {
+				t.Fatalf("parseField(%q) error = %v", tt.expr, err)
+			}
+			if !reflect.DeepEqual(values(got), tt.want) {
+				t.Errorf("parseField(%q) = %v, want %v", tt.expr, values(got), tt.want)
+			}
+		})
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		expr string
+	}{
+		{name: "missing field", expr: "30 18 * *"},
+		{name: "extra field", expr: "30 18 * * * *"},
+		{name: "minute out of range", expr: "60 18 * * *"},
+		{name: "day of month below range", expr: "0 0 0 * *"},
+		{name: "reversed range", expr: "0 0 * * 5-1"},
+		{name: "invalid value", expr: "0 x * * *"},
+		{name: "invalid range", expr: "0 1-x * * *"},
+		{name: "zero step", expr: "*/0 * * * *"},
+		{name: "invalid step", expr: "*/x * * * *"},
+		{name: "stepped value out of range", expr: "0 24/2 * * *"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := Parse(tt.expr); err == nil {
+				t.Errorf("Parse(%q) error = nil, want an error", tt.expr)
+			}
+		})
+	}
+}
+
+func TestNext(t *testing.T) {
+	tests := []struct {
+		name string
+		expr string
+		t    time.Time
+		want time.Time
+	}{
+		{name: "later the same day", expr: "30 18 * * 1-5", t: at(2024, time.March, 22, 10, 0), want: at(2024, time.March, 22, 18, 30)},
+		{name: "strictly after t", expr: "30 18 * * 1-5", t: at(2024, time.March, 22, 18, 30), want: at(2024, time.March, 25, 18, 30)},
+		{name: "seconds are ignored", expr: "30 18 * * 1-5", t: at(2024, time.March, 22, 18, 29).Add(59 * time.Second), want: at(2024, time.March, 22, 18, 30)},
+		{name: "skips the weekend", expr: "30 18 * * 1-5", t: at(2024, time.March, 22, 19, 0), want: at(2024, time.March, 25, 18, 30)},
+		{name: "sunday as 7", expr: "0 10 * * 7", t: at(2024, time.March, 22, 10, 0), want: at(2024, time.March, 24, 10, 0)},
+		{name: "step within the hour", expr: "*/15 * * * *", t: at(2024, time.March, 22, 10, 7), want: at(2024, time.March, 22, 10, 15)},
+		{name: "step over the hour", expr: "*/15 * * * *", t: at(2024, time.March, 22, 10, 45), want: at(2024, time.March, 22, 11, 0)},
+		{name: "month rollover", expr: "0 9 1 * *", t: at(2024, time.January, 31, 10, 0), want: at(2024, time.February, 1, 9, 0)},
+		{name: "year rollover", expr: "0 0 1 1 *", t: at(2024, time.December, 31, 23, 59), want: at(2025, time.January, 1, 0, 0)},
+		{name: "leap day", expr: "0 0 29 2 *", t: at(2024, time.March, 1, 0, 0), want: at(2028, time.February, 29, 0, 0)},
+		{name: "day of month or day of week", expr: "0 12 15 * 1", t: at(2024, time.March, 12, 13, 0), want: at(2024, time.March, 15, 12, 0)},
+		{name: "never", expr: "0 0 31 2 *", t: at(2024, time.March, 1, 0, 0), want: time.Time{}},
+		{
+			name: "midnight in IST is evening in UTC",
+			expr: "0 0 * * *",
+			t:    time.Date(2024, time.March, 22, 18, 29, 0, 0, time.UTC).In(ist),
+			want: at(2024, time.March, 23, 0, 0),
+		},
+		{
+			name: "close of the session in UTC",
+			expr: "30 18 * * 1-5",
+			t:    time.Date(2024, time.March, 22, 12, 0, 0, 0, time.UTC),
+			want: time.Date(2024, time.March, 22, 18, 30, 0, 0, time.UTC),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := Parse(tt.expr)
+			if err != nil {
+				t.Fatalf("Parse(%q) error = %v", tt.expr, err)
+			}
+			if got := s.Next(tt.t); !got.Equal(tt.want) {
+				t.Errorf("Next(%v) = %v, want %v", tt.t, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,120 @@
+package scheduler
+
+import (
+	"context"
+	"eeye/src/api"
+	"eeye/src/calendar"
+	"eeye/src/config"
+	"eeye/src/strategy"
+	"fmt"
+	"log/slog"
+	"time"
+)
+
+// sleep pauses for d and returns false if ctx is cancelled in the meantime
+func sleep(ctx context.Context, d time.Duration) bool {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return false
+	case <-timer.C:
+		return true
+	}
+}
+
+// waitForBhavcopy polls NSE until the bhavcopy of the given session is published.
+// DownloadLatestBhavcopy falls back to older files while today's is missing, so
+// the session is only considered ready once the reported trading day matches it.
+func waitForBhavcopy(ctx context.Context, session string) error {
+	for attempt := 0; attempt <= config.Daemon.MaxRetries; attempt++ {
+		_, lastTradingDay, err := api.DownloadLatestBhavcopy(ctx)
+		if err == nil && lastTradingDay == session {
+			return nil
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		slog.Info(
+			"bhavcopy not published yet, retrying",
+			"session", session,
+			"attempt", attempt+1,
+			"attempts", config.Daemon.MaxRetries+1,
+			"wait", config.Daemon.RetryInterval,
+		)
+
+		if attempt == config.Daemon.MaxRetries {
+			break
+		}
+
+		if !sleep(ctx, config.Daemon.RetryInterval) {
+			return ctx.Err()
+		}
+	}
+
+	return fmt.Errorf("bhavcopy for %v not available after %d attempts", session, config.Daemon.MaxRetries+1)
+}
+
+// runSession ingests the latest data and runs all strategies for the given session
+func runSession(ctx context.Context, now time.Time) {
+	session := now.Format("2006-01-02")
+	if !calendar.NSE.IsTradingDay(now) {
+		slog.Info("skipping session: not a trading day", "session", session)
+		return
+	}
+
+	if err := waitForBhavcopy(ctx, session); err != nil {
+		if ctx.Err() == nil {
+			slog.Warn("skipping session", "session", session, "err", err)
+		}
+		return
+	}
+
+	slog.Info("starting scheduled run", "session", session)
+	switch err := <-strategy.Analyze(ctx); {
+	case ctx.Err() != nil:
+		slog.Info("scheduled run interrupted", "session", session)
+	case err != nil:
+		slog.Error("scheduled run failed", "session", session, "err", err)
+	default:
+		slog.Info("scheduled run completed", "session", session)
+	}
+}
+
+// Run blocks and executes a screening run every time config.Daemon.Schedule fires,
+// until ctx is cancelled. An in-flight run is cancelled along with ctx and its
+// partial results are logged. Runs on days without a session according to the NSE
+// trading calendar (weekends, holidays) are skipped.
+func Run(ctx context.Context) error {
+	schedule, err := Parse(config.Daemon.Schedule)
+	if err != nil {
+		return err
+	}
+
+	loc, err := time.LoadLocation(config.DB.Tz)
+	if err != nil {
+		return fmt.Errorf("unable to load location: %w", err)
+	}
+
+	for {
+		next := schedule.Next(time.Now().In(loc))
+		if next.IsZero() {
+			return fmt.Errorf("schedule %q never fires", config.Daemon.Schedule)
+		}
+
+		slog.Info("next scheduled run", "at", next)
+		if !sleep(ctx, time.Until(next)) {
+			break
+		}
+
+		runSession(ctx, next)
+		if ctx.Err() != nil {
+			break
+		}
+	}
+
+	slog.Info("shutting down daemon")
+	return nil
+}
@@ -5,8 +5,8 @@ package steps
 
 import (
 	"eeye/src/models"
-	"eeye/src/store"
-	"log"
+	"eeye/src/utils"
+	"log/slog"
 	"math"
 )
 
@@ -41,55 +41,81 @@ func (b *BollingerBands) Screen(strategy string, stock *models.Stock) bool {
 
 	step := b.Name()
 
-	candles, err := store.Get(stock)
+	candles, err := b.GetCandles(stock)
 	if err != nil {
 		return false
 	}
 
 	length := len(candles)
 	if length < MinPoints {
-		log.Printf("[%v - %v] insufficient candles: %v\n", strategy, step, stock.Symbol)
+		slog.Debug("insufficient candles", "strategy", strategy, "step", step, "symbol", stock.Symbol)
 		return false
 	}
 
-	// Calculate Bollinger Bands using a rolling window approach
+	sma, lbb, ubb := ComputeBollingerBands(candles, Period, K)
+
+	return b.TruthyCheck(
+		strategy,
+		step,
+		stock,
+		func() bool {
+			return b.Test(candles, sma, lbb, ubb)
+		},
+	)
+}
+
+// ComputeBollingerBands calculates the Bollinger Bands using a rolling window.
+// Algorithm:
+//  1. Middle band is the Simple Moving Average of closes over 'period' candles
+//  2. Standard deviation is computed over the same window (population variance)
+//  3. Lower/upper bands are the middle band -/+ k standard deviations
+//
+// Parameters:
+//   - candles: Historical price data
+//   - period: Number of candles in the window (standard is 20)
+//   - k: Number of standard deviations for the band width (standard is 2)
+//
+// Returns:
+//   - sma: Simple Moving Average values (middle band)
+//   - lbb: Lower Bollinger Band values
+//   - ubb: Upper Bollinger Band values
+//
+// All slices are empty if there are fewer than 'period' candles.
+func ComputeBollingerBands(candles []models.Candle, period int, k float64) ([]float64, []float64, []float64) {
+	if len(candles) < period {
+		return utils.EmptySlice[float64](), utils.EmptySlice[float64](), utils.EmptySlice[float64]()
+	}
+
 	sum := 0.0
-	lbb := make([]float64, 0, len(candles)-Period+1) // Lower Bollinger Band
-	ubb := make([]float64, 0, len(candles)-Period+1) // Upper Bollinger Band
-	sma := make([]float64, 0, len(candles)-Period+1) // Simple Moving Average (middle band)
+	lbb := make([]float64, 0, len(candles)-period+1) // Lower Bollinger Band
+	ubb := make([]float64, 0, len(candles)-period+1) // Upper Bollinger Band
+	sma := make([]float64, 0, len(candles)-period+1) // Simple Moving Average (middle band)
 
 	for i := range candles {
 		sum += candles[i].Close
 
 		// Once we have enough data points, calculate the bands
-		if i+1 >= Period {
+		if i+1 >= period {
 			// Calculate SMA (middle band)
-			avg := sum / float64(Period)
+			avg := sum / float64(period)
 			sma = append(sma, avg)
 
 			// Calculate variance for standard deviation
 			variance := 0.0
-			for j := i + 1 - Period; j <= i; j++ {
+			for j := i + 1 - period; j <= i; j++ {
 				diff := candles[j].Close - avg
 				variance = variance + diff*diff
 			}
-			stdDev := math.Sqrt(variance / float64(Period))
+			stdDev := math.Sqrt(variance / float64(period))
 
-			// Calculate lower and upper bands (K standard deviations from SMA)
-			lbb = append(lbb, avg-K*stdDev)
-			ubb = append(ubb, avg+K*stdDev)
+			// Calculate lower and upper bands (k standard deviations from SMA)
+			lbb = append(lbb, avg-k*stdDev)
+			ubb = append(ubb, avg+k*stdDev)
 
 			// Remove oldest value from rolling sum to maintain window size
-			sum -= candles[i+1-Period].Close
+			sum -= candles[i+1-period].Close
 		}
 	}
 
-	return b.TruthyCheck(
-		strategy,
-		step,
-		stock,
-		func() bool {
-			return b.Test(candles, sma, lbb, ubb)
-		},
-	)
+	return sma, lbb, ubb
 }
@@ -2,8 +2,7 @@ package steps
 
 import (
 	"eeye/src/models"
-	"eeye/src/store"
-	"log"
+	"log/slog"
 )
 
 // isSolid checks if a candle is a solid bullish candle.
@@ -37,7 +36,7 @@ func isSolid(candle *models.Candle) bool {
 	if body >= 0.6*total &&
 		upper <= 0.25*body &&
 		lower <= 0.25*body {
-		log.Printf("Solid Bullish candle: %v\n", candle.Symbol)
+		slog.Debug("solid bullish candle", "symbol", candle.Symbol)
 		return true
 	}
 
@@ -75,7 +74,7 @@ func isHammer(candle *models.Candle) bool {
 		return false
 	}
 
-	log.Printf("Hammer candle: %v\n", candle.Symbol)
+	slog.Debug("hammer candle", "symbol", candle.Symbol)
 	return true
 }
 
@@ -101,7 +100,7 @@ func isEngulfing(candle1 *models.Candle, candle2 *models.Candle) bool {
 
 	// candle2 must engulf candle1 completely
 	if openPrice2 <= closePrice1 && closePrice2 >= openPrice1 {
-		log.Printf("Engulfing pattern: %v\n", candle1.Symbol)
+		slog.Debug("engulfing pattern", "symbol", candle1.Symbol)
 		return true
 	}
 
@@ -133,7 +132,7 @@ func isPiercing(candle1 *models.Candle, candle2 *models.Candle) bool {
 	midpoint := (openPrice1 + closePrice1) / 2
 	// candle2 opens below candle1's close and closes above midpoint
 	if openPrice2 < closePrice1 && closePrice2 > midpoint {
-		log.Printf("Piercing pattern: %v\n", candle1.Symbol)
+		slog.Debug("piercing pattern", "symbol", candle1.Symbol)
 		return true
 	}
 
@@ -163,14 +162,14 @@ func (b *BullishCandle) Screen(strategy string, stock *models.Stock) bool {
 
 	step := b.Name()
 
-	candles, err := store.Get(stock)
+	candles, err := b.GetCandles(stock)
 	if err != nil {
 		return false
 	}
 
 	length := len(candles)
 	if length < MinPoints {
-		log.Printf("[%v - %v] insufficient candles: %v\n", strategy, step, stock.Symbol)
+		slog.Debug("insufficient candles", "strategy", strategy, "step", step, "symbol", stock.Symbol)
 		return false
 	}
 
@@ -0,0 +1,55 @@
+package steps
+
+import (
+	"eeye/src/models"
+	"eeye/src/testutil"
+	"testing"
+)
+
+func TestBullishCandlePatterns(t *testing.T) {
+	base := func() *testutil.Series {
+		return testutil.NewSeries("X").Flat(5, 100)
+	}
+
+	tests := []struct {
+		name      string
+		candles   []models.Candle
+		solid     bool
+		hammer    bool
+		engulfing bool
+		piercing  bool
+	}{
+		{name: "solid", candles: base().Solid(0.03).Candles(), solid: true},
+		{name: "hammer", candles: base().Hammer().Candles(), hammer: true},
+		{name: "engulfing", candles: base().Engulfing().Candles(), solid: true, engulfing: true, piercing: true},
+		{name: "piercing", candles: base().Piercing().Candles(), solid: true, piercing: true},
+		{name: "bearish", candles: base().Bearish(0.03).Candles()},
+		{name: "doji", candles: base().Flat(1, 100).Candles()},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var (
+				length = len(tt.candles)
+				prev   = &tt.candles[length-2]
+				last   = &tt.candles[length-1]
+			)
+
+			if got := isSolid(last); got != tt.solid {
+				t.Errorf("isSolid() = %v, want %v", got, tt.solid)
+			}
+
+			if got := isHammer(last); got != tt.hammer {
+				t.Errorf("isHammer() = %v, want %v", got, tt.hammer)
+			}
+
+			if got := isEngulfing(prev, last); got != tt.engulfing {
+				t.Errorf("isEngulfing() = %v, want %v", got, tt.engulfing)
+			}
+
+			if got := isPiercing(prev, last); got != tt.piercing {
+				t.Errorf("isPiercing() = %v, want %v", got, tt.piercing)
+			}
+		})
+	}
+}
@@ -0,0 +1,112 @@
+package steps
+
+import (
+	"eeye/src/models"
+	"eeye/src/utils"
+	"log/slog"
+)
+
+// Delivery screens stocks based on the share of traded volume marked for delivery.
+// A delivery percentage above its average suggests that buyers are accumulating
+// shares instead of trading intraday, which adds conviction to a price move.
+type Delivery struct {
+	models.StepBaseImpl
+	// Test compares current delivery percentage vs its average to determine screening criteria.
+	// Parameters:
+	//   - currentPct: Latest candle's delivery percentage
+	//   - averagePct: 20-period simple moving average of delivery percentage
+	// Returns true if the stock passes the delivery screening test.
+	Test func(currentPct float64, averagePct float64) bool
+}
+
+//revive:disable-next-line exported
+func (d *Delivery) Name() string {
+	return "Delivery screener"
+}
+
+//revive:disable-next-line exported
+func (d *Delivery) Screen(strategy string, stock *models.Stock) bool {
+	const (
+		Period = 20 // Standard period for delivery moving average
+	)
+
+	step := d.Name()
+
+	candles, err := d.GetCandles(stock)
+	if err != nil {
+		return false
+	}
+
+	length := len(candles)
+	if length < Period {
+		slog.Debug("insufficient candles", "strategy", strategy, "step", step, "symbol", stock.Symbol)
+		return false
+	}
+
+	// Delivery data is published separately and may be missing for some days
+	recent := candles[length-Period:]
+	if recent[Period-1].DeliveryPct == 0 {
+		slog.Debug("no delivery data", "strategy", strategy, "step", step, "symbol", stock.Symbol)
+		return false
+	}
+
+	deliveryMA := ComputeDeliveryMA(recent, Period)
+	if len(deliveryMA) == 0 {
+		slog.Debug("insufficient delivery data", "strategy", strategy, "step", step, "symbol", stock.Symbol)
+		return false
+	}
+
+	return d.TruthyCheck(
+		strategy,
+		step,
+		stock,
+		func() bool {
+			return d.Test(recent[Period-1].DeliveryPct, utils.Last(deliveryMA, 0.0))
+		},
+	)
+}
+
+// ComputeDeliveryMA calculates the Simple Moving Average of delivery percentages.
+// Candles without delivery data are treated as missing, so no average is produced
+// for a window that contains one.
+//
+// Parameters:
+//   - candles: Historical price/volume data
+//   - period: Number of candles for average calculation (standard is 20)
+//
+// Returns:
+//   - Slice of delivery MA values (empty if insufficient data)
+func ComputeDeliveryMA(candles []models.Candle, period int) []float64 {
+	if len(candles) < period {
+		return utils.EmptySlice[float64]()
+	}
+
+	var (
+		sum        = 0.0
+		missing    = 0
+		deliveryMA = make([]float64, 0, len(candles)-period+1)
+	)
+
+	for index := range candles {
+		candle := &candles[index]
+		sum += candle.DeliveryPct
+		if candle.DeliveryPct == 0 {
+			missing++
+		}
+
+		if index+1 >= period {
+			if missing == 0 {
+				deliveryMA = append(deliveryMA, sum/float64(period))
+			}
+
+			// Remove oldest value from rolling sum to maintain window size
+			oldest := &candles[index-period+1]
+			sum -= oldest.DeliveryPct
+			if oldest.DeliveryPct == 0 {
+				missing--
+			}
+		}
+	}
+
+	return deliveryMA
+}
@@ -2,10 +2,9 @@ package steps
 
 import (
 	"eeye/src/models"
-	"eeye/src/store"
 	"eeye/src/utils"
 	"fmt"
-	"log"
+	"log/slog"
 )
 
 // Ema screens stocks based on Exponential Moving Average (EMA) analysis.
@@ -35,7 +34,7 @@ func (e *Ema) Screen(strategy string, stock *models.Stock) bool {
 
 	step := e.Name()
 
-	candles, err := store.Get(stock)
+	candles, err := e.GetCandles(stock)
 	if err != nil {
 		return false
 	}
@@ -46,7 +45,7 @@ func (e *Ema) Screen(strategy string, stock *models.Stock) bool {
 	)
 
 	if emaLength < MinEMAPoints {
-		log.Printf("[%v - %v] insufficient candles: %v\n", strategy, step, stock.Symbol)
+		slog.Debug("insufficient candles", "strategy", strategy, "step", step, "symbol", stock.Symbol)
 		return false
 	}
 
@@ -2,8 +2,7 @@ package steps
 
 import (
 	"eeye/src/models"
-	"eeye/src/store"
-	"log"
+	"log/slog"
 )
 
 // EmaCrossover screens for EMA crossover signals between multiple periods.
@@ -32,7 +31,7 @@ func (e *EmaCrossover) Screen(strategy string, stock *models.Stock) bool {
 
 	step := e.Name()
 
-	candles, err := store.Get(stock)
+	candles, err := e.GetCandles(stock)
 	if err != nil {
 		return false
 	}
@@ -41,7 +40,7 @@ func (e *EmaCrossover) Screen(strategy string, stock *models.Stock) bool {
 	for i, period := range e.Periods {
 		emas = append(emas, ComputeEma(candles, period))
 		if len(emas[i]) == 0 {
-			log.Printf("[%v - %v] insufficient candles for EMA %v: %v\n", strategy, step, period, stock.Symbol)
+			slog.Debug("insufficient candles", "strategy", strategy, "step", step, "symbol", stock.Symbol, "period", period)
 			return false
 		}
 	}
@@ -11,6 +11,7 @@ import (
 // Parameters:
 //   - strategy: Name of the trading strategy being executed
 //   - stock: Stock to be screened
+//   - source: Source the screeners read the candles of the stock from
 //   - screeners: Ordered list of Step implementations to execute
 //
 // Returns:
@@ -18,7 +19,7 @@ import (
 //   - false if ANY screener returns false
 //
 // Note: Steps are executed concurrently for performance, but the result requires all to pass.
-func Execute(strategy string, stock *models.Stock, screeners []models.Step) bool {
+func Execute(strategy string, stock *models.Stock, source models.CandleSource, screeners []models.Step) bool {
 	var (
 		wg  = sync.WaitGroup{}
 		out = make(chan bool)
@@ -26,6 +27,7 @@ func Execute(strategy string, stock *models.Stock, screeners []models.Step) bool
 
 	// Execute all screeners concurrently
 	for i := range screeners {
+		screeners[i].SetSource(source)
 		wg.Go(func() {
 			v := screeners[i].Screen(strategy, stock)
 			out <- v
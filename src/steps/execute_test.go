@@ -0,0 +1,86 @@
+package steps
+
+import (
+	"eeye/src/models"
+	"eeye/src/store"
+	"eeye/src/testutil"
+	"testing"
+)
+
+func TestExecute(t *testing.T) {
+	var (
+		series = testutil.NewSeries("X").Flat(30, 100).Solid(0.03)
+		stock  = series.Stock()
+		source = store.NewMemory()
+	)
+	source.Set(stock.Symbol, series.Candles())
+
+	short := testutil.NewSeries("SHORT").Flat(10, 100)
+	source.Set("SHORT", short.Candles())
+
+	aboveAverage := func(current float64, average float64) bool { return current >= average }
+
+	tests := []struct {
+		name      string
+		source    models.CandleSource
+		stock     *models.Stock
+		screeners func() []models.Step
+		want      bool
+	}{
+		{
+			name:      "all steps pass",
+			source:    source,
+			stock:     stock,
+			screeners: func() []models.Step { return []models.Step{&BullishCandle{}, &Volume{Test: aboveAverage}} },
+			want:      true,
+		},
+		{
+			name:   "one step fails",
+			source: source,
+			stock:  stock,
+			screeners: func() []models.Step {
+				return []models.Step{
+					&BullishCandle{},
+					&Volume{Test: func(current float64, average float64) bool { return current > 2*average }},
+				}
+			},
+			want: false,
+		},
+		{
+			name:      "missing stock",
+			source:    source,
+			stock:     &models.Stock{Symbol: "MISSING"},
+			screeners: func() []models.Step { return []models.Step{&BullishCandle{}} },
+			want:      false,
+		},
+		{
+			name:      "no source",
+			source:    nil,
+			stock:     stock,
+			screeners: func() []models.Step { return []models.Step{&BullishCandle{}} },
+			want:      false,
+		},
+		{
+			name:      "delivery at its average",
+			source:    source,
+			stock:     stock,
+			screeners: func() []models.Step { return []models.Step{&Delivery{Test: aboveAverage}} },
+			want:      true,
+		},
+		{
+			name:      "insufficient candles for delivery",
+			source:    source,
+			stock:     short.Stock(),
+			screeners: func() []models.Step { return []models.Step{&Delivery{Test: aboveAverage}} },
+			want:      false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Execute("test", tt.stock, tt.source, tt.screeners()); got != tt.want {
+				t.Errorf("Execute() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,281 @@
+package steps
+
+import (
+	"eeye/src/models"
+	"eeye/src/testutil"
+	"math"
+	"testing"
+)
+
+// closes builds candles with the given closing prices
+func closes(values ...float64) []models.Candle {
+	candles := make([]models.Candle, 0, len(values))
+	for _, v := range values {
+		candles = append(candles, models.Candle{Open: v, High: v, Low: v, Close: v})
+	}
+	return candles
+}
+
+// almostEqual compares two slices of floats with a small tolerance
+func almostEqual(a []float64, b []float64) bool {
+	if len(a) != len(b) {
+		return false
+	}
+
+	for i := range a {
+		if math.Abs(a[i]-b[i]) > 1e-9 {
+			return false
+		}
+	}
+	return true
+}
+
+func TestComputeEma(t *testing.T) {
+	tests := []struct {
+		name    string
+		candles []models.Candle
+		period  int
+		want    []float64
+	}{
+		{name: "insufficient candles", candles: closes(1, 2), period: 3, want: []float64{}},
+		{name: "seeded with sma", candles: closes(1, 2, 3), period: 3, want: []float64{2}},
+		{name: "smoothing", candles: closes(1, 2, 3, 4, 5), period: 3, want: []float64{2, 3, 4}},
+		{name: "flat", candles: closes(7, 7, 7, 7), period: 2, want: []float64{7, 7, 7}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ComputeEma(tt.candles, tt.period); !almostEqual(got, tt.want) {
+				t.Errorf("ComputeEma() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestComputeRsi(t *testing.T) {
+	const Period = 14
+
+	tests := []struct {
+		name    string
+		candles []models.Candle
+		wantLen int
+		check   func(rsi float64) bool
+	}{
+		{
+			name:    "insufficient candles",
+			candles: testutil.NewSeries("X").Trend(Period, 0.01).Candles(),
+			wantLen: 0,
+		},
+		{
+			name:    "only gains",
+			candles: testutil.NewSeries("X").Trend(30, 0.01).Candles(),
+			wantLen: 30 - Period - 1,
+			check:   func(rsi float64) bool { return rsi == 100 },
+		},
+		{
+			name:    "only losses",
+			candles: testutil.NewSeries("X").Trend(30, -0.01).Candles(),
+			wantLen: 30 - Period - 1,
+			check:   func(rsi float64) bool { return rsi == 0 },
+		},
+		{
+			name:    "balanced moves",
+			candles: closes(10, 11, 10, 11, 10, 11, 10, 11, 10, 11, 10, 11, 10, 11, 10, 11, 10, 11, 10, 11),
+			wantLen: 20 - Period - 1,
+			check:   func(rsi float64) bool { return rsi > 40 && rsi < 60 },
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rsi := ComputeRsi(tt.candles, Period)
+			if len(rsi) != tt.wantLen {
+				t.Fatalf("len(ComputeRsi()) = %v, want %v", len(rsi), tt.wantLen)
+			}
+
+			for i := range rsi {
+				if !tt.check(rsi[i]) {
+					t.Errorf("ComputeRsi()[%d] = %v out of expected range", i, rsi[i])
+				}
+			}
+		})
+	}
+}
+
+func TestComputeVolumeMA(t *testing.T) {
+	candles := []models.Candle{{Volume: 10}, {Volume: 20}, {Volume: 30}, {Volume: 40}}
+
+	tests := []struct {
+		name   string
+		period int
+		want   []float64
+	}{
+		{name: "insufficient candles", period: 5, want: []float64{}},
+		{name: "rolling window", period: 2, want: []float64{15, 25, 35}},
+		{name: "whole range", period: 4, want: []float64{25}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ComputeVolumeMA(candles, tt.period); !almostEqual(got, tt.want) {
+				t.Errorf("ComputeVolumeMA() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestComputeDeliveryMA(t *testing.T) {
+	tests := []struct {
+		name    string
+		candles []models.Candle
+		period  int
+		want    []float64
+	}{
+		{
+			name:    "insufficient candles",
+			candles: []models.Candle{{DeliveryPct: 10}},
+			period:  2,
+			want:    []float64{},
+		},
+		{
+			name:    "rolling window",
+			candles: []models.Candle{{DeliveryPct: 10}, {DeliveryPct: 30}, {DeliveryPct: 50}},
+			period:  2,
+			want:    []float64{20, 40},
+		},
+		{
+			name:    "windows with missing data are skipped",
+			candles: []models.Candle{{DeliveryPct: 10}, {DeliveryPct: 0}, {DeliveryPct: 50}, {DeliveryPct: 70}},
+			period:  2,
+			want:    []float64{60},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ComputeDeliveryMA(tt.candles, tt.period); !almostEqual(got, tt.want) {
+				t.Errorf("ComputeDeliveryMA() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestComputeBollingerBands(t *testing.T) {
+	tests := []struct {
+		name    string
+		candles []models.Candle
+		period  int
+		wantSma []float64
+		wantLbb []float64
+		wantUbb []float64
+	}{
+		{
+			name:    "insufficient candles",
+			candles: closes(1),
+			period:  2,
+			wantSma: []float64{},
+			wantLbb: []float64{},
+			wantUbb: []float64{},
+		},
+		{
+			name:    "flat prices collapse the bands",
+			candles: closes(5, 5, 5),
+			period:  2,
+			wantSma: []float64{5, 5},
+			wantLbb: []float64{5, 5},
+			wantUbb: []float64{5, 5},
+		},
+		{
+			name:    "two standard deviations",
+			candles: closes(1, 3, 1),
+			period:  2,
+			wantSma: []float64{2, 2},
+			wantLbb: []float64{0, 0},
+			wantUbb: []float64{4, 4},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sma, lbb, ubb := ComputeBollingerBands(tt.candles, tt.period, 2)
+			if !almostEqual(sma, tt.wantSma) || !almostEqual(lbb, tt.wantLbb) || !almostEqual(ubb, tt.wantUbb) {
+				t.Errorf("ComputeBollingerBands() = %v, %v, %v, want %v, %v, %v", sma, lbb, ubb, tt.wantSma, tt.wantLbb, tt.wantUbb)
+			}
+		})
+	}
+}
+
+func TestGetLiquidityLevels(t *testing.T) {
+	tests := []struct {
+		name            string
+		candles         []models.Candle
+		strength        int
+		wantSupports    int
+		wantResistances int
+	}{
+		{
+			name:            "range has one support and one resistance",
+			candles:         testutil.NewSeries("X").Range(40, 95, 105).Candles(),
+			strength:        3,
+			wantSupports:    1,
+			wantResistances: 1,
+		},
+		{
+			name:            "trend has no repeated levels",
+			candles:         testutil.NewSeries("X").Trend(40, 0.02).Candles(),
+			strength:        3,
+			wantSupports:    0,
+			wantResistances: 0,
+		},
+		{
+			name:            "not enough touches",
+			candles:         testutil.NewSeries("X").Range(12, 95, 105).Candles(),
+			strength:        10,
+			wantSupports:    0,
+			wantResistances: 0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			supports, resistances := GetLiquidityLevels(tt.candles, 5, 0.01, tt.strength)
+			if len(supports) != tt.wantSupports || len(resistances) != tt.wantResistances {
+				t.Fatalf("GetLiquidityLevels() = %v, %v, want %d supports and %d resistances", supports, resistances, tt.wantSupports, tt.wantResistances)
+			}
+
+			for _, level := range supports {
+				if math.Abs(level-95) > 1 {
+					t.Errorf("support %v is not near the range low", level)
+				}
+			}
+
+			for _, level := range resistances {
+				if math.Abs(level-105) > 1 {
+					t.Errorf("resistance %v is not near the range high", level)
+				}
+			}
+		})
+	}
+}
+
+func TestGetLevels(t *testing.T) {
+	tests := []struct {
+		name     string
+		prices   []float64
+		strength int
+		want     []float64
+	}{
+		{name: "empty", prices: []float64{}, strength: 1, want: []float64{}},
+		{name: "single cluster", prices: []float64{99, 100, 101}, strength: 3, want: []float64{100}},
+		{name: "weak cluster dropped", prices: []float64{50, 99, 100, 101}, strength: 2, want: []float64{100}},
+		{name: "two clusters", prices: []float64{50, 50.2, 100, 100.5}, strength: 2, want: []float64{50.1, 100.25}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := getLevels(tt.prices, 0.02, tt.strength); !almostEqual(got, tt.want) {
+				t.Errorf("getLevels() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
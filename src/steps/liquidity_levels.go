@@ -2,9 +2,8 @@ package steps
 
 import (
 	"eeye/src/models"
-	"eeye/src/store"
 	"eeye/src/utils"
-	"log"
+	"log/slog"
 	"math"
 	"slices"
 )
@@ -38,23 +37,23 @@ func (s *LiquidityLevels) Name() string {
 func (s *LiquidityLevels) Screen(strategy string, stock *models.Stock) bool {
 	step := s.Name()
 
-	candles, err := store.Get(stock)
+	candles, err := s.GetCandles(stock)
 	if err != nil {
 		return false
 	}
 
 	if s.Window <= 0 {
-		log.Printf("[LiquidityLevels] window size %v is not valid, should be > 0\n", s.Window)
+		slog.Error("invalid window size, should be > 0", "step", "LiquidityLevels", "window", s.Window)
 		return false
 	}
 
 	if s.Strength <= 0 {
-		log.Printf("[LiquidityLevels] strength %v is not valid, should be > 0\n", s.Strength)
+		slog.Error("invalid strength, should be > 0", "step", "LiquidityLevels", "strength", s.Strength)
 		return false
 	}
 
 	if s.Tolerance <= 0 {
-		log.Printf("[LiquidityLevels] tolerance %v is not valid, should be > 0\n", s.Tolerance)
+		slog.Error("invalid tolerance, should be > 0", "step", "LiquidityLevels", "tolerance", s.Tolerance)
 		return false
 	}
 
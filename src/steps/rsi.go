@@ -2,9 +2,8 @@ package steps
 
 import (
 	"eeye/src/models"
-	"eeye/src/store"
 	"eeye/src/utils"
-	"log"
+	"log/slog"
 	"math"
 )
 
@@ -35,7 +34,7 @@ func (r *Rsi) Screen(strategy string, stock *models.Stock) bool {
 
 	step := r.Name()
 
-	candles, err := store.Get(stock)
+	candles, err := r.GetCandles(stock)
 	if err != nil {
 		return false
 	}
@@ -46,7 +45,7 @@ func (r *Rsi) Screen(strategy string, stock *models.Stock) bool {
 	)
 
 	if rsiLength == 0 {
-		log.Printf("[%v - %v] insufficient candles: %v\n", strategy, step, stock.Symbol)
+		slog.Debug("insufficient candles", "strategy", strategy, "step", step, "symbol", stock.Symbol)
 		return false
 	}
 
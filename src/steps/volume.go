@@ -3,9 +3,8 @@ package steps
 import (
 	"eeye/src/constants"
 	"eeye/src/models"
-	"eeye/src/store"
 	"eeye/src/utils"
-	"log"
+	"log/slog"
 )
 
 // Volume screens stocks based on trading volume analysis.
@@ -34,7 +33,7 @@ func (v *Volume) Screen(strategy string, stock *models.Stock) bool {
 
 	step := v.Name()
 
-	candles, err := store.Get(stock)
+	candles, err := v.GetCandles(stock)
 	if err != nil {
 		return false
 	}
@@ -42,7 +41,7 @@ func (v *Volume) Screen(strategy string, stock *models.Stock) bool {
 	length := len(candles)
 	volumeMA := ComputeVolumeMA(candles, Period)
 	if length < Period {
-		log.Printf("[%v - %v] insufficient candles: %v\n", strategy, step, stock.Symbol)
+		slog.Debug("insufficient candles", "strategy", strategy, "step", step, "symbol", stock.Symbol)
 		return false
 	}
 
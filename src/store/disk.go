@@ -0,0 +1,444 @@
+package store
+
+import (
+	"bufio"
+	"context"
+	"eeye/src/db"
+	"eeye/src/metrics"
+	"eeye/src/models"
+	"eeye/src/utils"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"log/slog"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+const (
+	// diskMagic identifies a candle cache file
+	diskMagic = "EEYC"
+
+	// diskVersion is bumped whenever the file layout changes; older files are discarded
+	diskVersion uint16 = 1
+
+	// diskMaxCandles guards against reading a corrupted count
+	diskMaxCandles = 1 << 20
+
+	// manifestFile is the name of the manifest inside the cache directory
+	manifestFile = "manifest.json"
+)
+
+// diskEntry describes the cached candles of a symbol
+type diskEntry struct {
+	Count     int       `json:"count"`
+	First     time.Time `json:"first"`
+	Last      time.Time `json:"last"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// diskManifest maps every cached symbol to the count and the first and last times of
+// its cached candles
+type diskManifest struct {
+	Version uint16               `json:"version"`
+	Symbols map[string]diskEntry `json:"symbols"`
+}
+
+// Disk is a persistent candle cache stored in a directory: one columnar binary file
+// per symbol plus a manifest with the range of the cached candles.
+//
+// Cached symbols are refreshed incrementally: only candles of the last refreshDays days
+// (which may still be corrected or receive delivery data) are re-read from the database,
+// and a symbol is reloaded in full if its first or last stored candle no longer matches
+// the cache. Changes older than refreshDays which keep both are not detected.
+type Disk struct {
+	dir         string
+	refreshDays int
+
+	mu       sync.Mutex
+	manifest diskManifest
+}
+
+// OpenDisk opens (creating if needed) the candle cache in dir
+func OpenDisk(dir string, refreshDays int) (*Disk, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create candle cache dir: %w", err)
+	}
+
+	d := &Disk{
+		dir:         dir,
+		refreshDays: refreshDays,
+		manifest:    diskManifest{Version: diskVersion, Symbols: make(map[string]diskEntry)},
+	}
+
+	data, err := os.ReadFile(filepath.Join(dir, manifestFile))
+	if errors.Is(err, fs.ErrNotExist) {
+		return d, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read candle cache manifest: %w", err)
+	}
+
+	manifest := diskManifest{}
+	if err := json.Unmarshal(data, &manifest); err != nil || manifest.Version != diskVersion || manifest.Symbols == nil {
+		slog.Warn("discarding invalid or outdated candle cache manifest", "dir", dir)
+		return d, nil
+	}
+
+	d.manifest = manifest
+	return d, nil
+}
+
+// path returns the cache file of a symbol
+func (d *Disk) path(symbol string) string {
+	return filepath.Join(d.dir, url.PathEscape(symbol)+".bin")
+}
+
+// entry returns the manifest entry of a symbol
+func (d *Disk) entry(symbol string) (diskEntry, bool) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	entry, ok := d.manifest.Symbols[symbol]
+	return entry, ok
+}
+
+// load reads the cached candles of a symbol
+func (d *Disk) load(symbol string) ([]models.Candle, error) {
+	file, err := os.Open(d.path(symbol))
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = file.Close()
+	}()
+
+	return decodeCandles(bufio.NewReader(file), symbol)
+}
+
+// save writes the candles of a symbol and records them in the manifest.
+// The file is written to a temporary name and renamed so readers never see partial files.
+func (d *Disk) save(symbol string, candles []models.Candle) error {
+	tmp, err := os.CreateTemp(d.dir, "candles-*.tmp")
+	if err != nil {
+		return fmt.Errorf("failed to create cache file: %w", err)
+	}
+	defer func() {
+		_ = os.Remove(tmp.Name())
+	}()
+
+	w := bufio.NewWriter(tmp)
+	if err := encodeCandles(w, candles); err != nil {
+		_ = tmp.Close()
+		return fmt.Errorf("failed to encode candles of %v: %w", symbol, err)
+	}
+	if err := w.Flush(); err != nil {
+		_ = tmp.Close()
+		return fmt.Errorf("failed to write candles of %v: %w", symbol, err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write candles of %v: %w", symbol, err)
+	}
+	if err := os.Rename(tmp.Name(), d.path(symbol)); err != nil {
+		return fmt.Errorf("failed to replace cache file of %v: %w", symbol, err)
+	}
+
+	entry := diskEntry{Count: len(candles), UpdatedAt: utils.Now()}
+	if len(candles) > 0 {
+		entry.First = candles[0].Timestamp
+		entry.Last = candles[len(candles)-1].Timestamp
+	}
+
+	d.mu.Lock()
+	d.manifest.Symbols[symbol] = entry
+	d.mu.Unlock()
+	return nil
+}
+
+// forget removes a symbol which no longer has candles from the cache
+func (d *Disk) forget(symbol string) {
+	d.mu.Lock()
+	_, ok := d.manifest.Symbols[symbol]
+	delete(d.manifest.Symbols, symbol)
+	d.mu.Unlock()
+
+	if ok {
+		_ = os.Remove(d.path(symbol))
+	}
+}
+
+// saveManifest writes the manifest atomically
+func (d *Disk) saveManifest() error {
+	d.mu.Lock()
+	data, err := json.MarshalIndent(d.manifest, "", "  ")
+	d.mu.Unlock()
+	if err != nil {
+		return fmt.Errorf("failed to encode candle cache manifest: %w", err)
+	}
+
+	tmp := filepath.Join(d.dir, manifestFile+".tmp")
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return fmt.Errorf("failed to write candle cache manifest: %w", err)
+	}
+	return os.Rename(tmp, filepath.Join(d.dir, manifestFile))
+}
+
+// Fetch returns the candles of the given symbols, keyed by symbol.
+//
+// With refresh, the cache is synced with the database first: the latest refreshDays
+// days of every cached symbol are re-read and merged, and symbols which are missing,
+// whose first candle moved (e.g. history was backfilled) or whose last candle differs
+// after merging are reloaded in full. Only the first and last stored candle of each
+// symbol are looked up, so a refresh costs about as much as reading the recent days.
+// Without refresh, cached symbols are served from disk only and the database is
+// queried for missing symbols.
+// Symbols without stored candles are absent from the result.
+func (d *Disk) Fetch(ctx context.Context, symbols []string, refresh bool) (map[string][]models.Candle, error) {
+	var (
+		res     = make(map[string][]models.Candle, len(symbols))
+		pending = symbols
+	)
+
+	if !refresh {
+		pending = make([]string, 0)
+		for _, symbol := range symbols {
+			if _, ok := d.entry(symbol); ok {
+				if candles, err := d.load(symbol); err == nil {
+					res[symbol] = candles
+					metrics.StoreLookups.Inc("disk", "hit")
+					continue
+				}
+			}
+			pending = append(pending, symbol)
+		}
+
+		if len(pending) == 0 {
+			return res, nil
+		}
+	}
+
+	ranges, err := db.FetchCandleBounds(ctx, pending)
+	if err != nil {
+		return res, err
+	}
+
+	var (
+		since  = make([]time.Time, len(pending))
+		cached = make(map[string][]models.Candle, len(pending))
+	)
+
+	for i, symbol := range pending {
+		stored, ok := ranges[symbol]
+		if !ok {
+			d.forget(symbol)
+			continue
+		}
+
+		entry, ok := d.entry(symbol)
+		if !ok || !entry.First.Equal(stored.First) {
+			continue
+		}
+
+		candles, err := d.load(symbol)
+		if err != nil {
+			slog.Warn("candle cache is unreadable, reloading", "symbol", symbol, "err", err)
+			continue
+		}
+
+		cached[symbol] = candles
+		since[i] = entry.Last.AddDate(0, 0, -d.refreshDays)
+	}
+
+	fresh, err := db.FetchCandlesSince(ctx, pending, since)
+	if err != nil {
+		return res, err
+	}
+
+	reload := make([]string, 0)
+	for i, symbol := range pending {
+		stored, ok := ranges[symbol]
+		if !ok {
+			continue
+		}
+
+		candles := mergeCandles(cached[symbol], fresh[symbol], since[i])
+		if len(candles) == 0 || !candles[len(candles)-1].Timestamp.Equal(stored.Last) {
+			reload = append(reload, symbol)
+			continue
+		}
+
+		res[symbol] = candles
+		if _, ok := cached[symbol]; ok {
+			metrics.StoreLookups.Inc("disk", "hit")
+		} else {
+			metrics.StoreLookups.Inc("disk", "miss")
+		}
+
+		if !sameCandles(cached[symbol], candles) {
+			if err := d.save(symbol, candles); err != nil {
+				slog.Warn("failed to cache candles", "symbol", symbol, "err", err)
+			}
+		}
+	}
+
+	if len(reload) > 0 {
+		full, err := db.FetchCandlesSince(ctx, reload, make([]time.Time, len(reload)))
+		if err != nil {
+			return res, err
+		}
+
+		for _, symbol := range reload {
+			candles := full[symbol]
+			res[symbol] = candles
+			metrics.StoreLookups.Inc("disk", "miss")
+			if err := d.save(symbol, candles); err != nil {
+				slog.Warn("failed to cache candles", "symbol", symbol, "err", err)
+			}
+		}
+	}
+
+	return res, d.saveManifest()
+}
+
+// mergeCandles replaces the cached candles from since onwards with the fresh ones
+func mergeCandles(cached []models.Candle, fresh []models.Candle, since time.Time) []models.Candle {
+	merged := make([]models.Candle, 0, len(cached)+len(fresh))
+	for i := range cached {
+		if cached[i].Timestamp.Before(since) {
+			merged = append(merged, cached[i])
+		}
+	}
+	return append(merged, fresh...)
+}
+
+// sameCandles reports whether both slices hold the same candles
+func sameCandles(a []models.Candle, b []models.Candle) bool {
+	if len(a) != len(b) {
+		return false
+	}
+
+	for i := range a {
+		x, y := a[i], b[i]
+		if !x.Timestamp.Equal(y.Timestamp) {
+			return false
+		}
+
+		x.Timestamp, y.Timestamp = time.Time{}, time.Time{}
+		if x != y {
+			return false
+		}
+	}
+	return true
+}
+
+// encodeCandles writes candles in a columnar little endian layout:
+// magic, version, count, then one column per field (times as unix seconds).
+func encodeCandles(w io.Writer, candles []models.Candle) error {
+	var (
+		count       = len(candles)
+		timestamps  = make([]int64, count)
+		opens       = make([]float64, count)
+		highs       = make([]float64, count)
+		lows        = make([]float64, count)
+		closes      = make([]float64, count)
+		volumes     = make([]uint64, count)
+		deliveryQty = make([]uint64, count)
+		deliveryPct = make([]float64, count)
+		turnovers   = make([]float64, count)
+	)
+
+	for i := range candles {
+		candle := &candles[i]
+		timestamps[i] = candle.Timestamp.Unix()
+		opens[i] = candle.Open
+		highs[i] = candle.High
+		lows[i] = candle.Low
+		closes[i] = candle.Close
+		volumes[i] = candle.Volume
+		deliveryQty[i] = candle.DeliveryQty
+		deliveryPct[i] = candle.DeliveryPct
+		turnovers[i] = candle.Turnover
+	}
+
+	if _, err := io.WriteString(w, diskMagic); err != nil {
+		return err
+	}
+
+	columns := []any{diskVersion, uint32(count), timestamps, opens, highs, lows, closes, volumes, deliveryQty, deliveryPct, turnovers}
+	for _, column := range columns {
+		if err := binary.Write(w, binary.LittleEndian, column); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// decodeCandles reads candles written by encodeCandles
+func decodeCandles(r io.Reader, symbol string) ([]models.Candle, error) {
+	magic := make([]byte, len(diskMagic))
+	if _, err := io.ReadFull(r, magic); err != nil {
+		return nil, fmt.Errorf("failed to read header: %w", err)
+	}
+	if string(magic) != diskMagic {
+		return nil, fmt.Errorf("not a candle cache file")
+	}
+
+	var (
+		version uint16
+		count   uint32
+	)
+	if err := binary.Read(r, binary.LittleEndian, &version); err != nil {
+		return nil, fmt.Errorf("failed to read header: %w", err)
+	}
+	if version != diskVersion {
+		return nil, fmt.Errorf("unsupported candle cache version %d", version)
+	}
+	if err := binary.Read(r, binary.LittleEndian, &count); err != nil {
+		return nil, fmt.Errorf("failed to read header: %w", err)
+	}
+	if count > diskMaxCandles {
+		return nil, fmt.Errorf("invalid candle count %d", count)
+	}
+
+	var (
+		timestamps  = make([]int64, count)
+		opens       = make([]float64, count)
+		highs       = make([]float64, count)
+		lows        = make([]float64, count)
+		closes      = make([]float64, count)
+		volumes     = make([]uint64, count)
+		deliveryQty = make([]uint64, count)
+		deliveryPct = make([]float64, count)
+		turnovers   = make([]float64, count)
+	)
+
+	columns := []any{timestamps, opens, highs, lows, closes, volumes, deliveryQty, deliveryPct, turnovers}
+	for _, column := range columns {
+		if err := binary.Read(r, binary.LittleEndian, column); err != nil {
+			return nil, fmt.Errorf("failed to read candles: %w", err)
+		}
+	}
+
+	candles := make([]models.Candle, count)
+	for i := range candles {
+		candles[i] = models.Candle{
+			Symbol:      symbol,
+			Open:        opens[i],
+			High:        highs[i],
+			Low:         lows[i],
+			Close:       closes[i],
+			Timestamp:   time.Unix(timestamps[i], 0).UTC(),
+			Volume:      volumes[i],
+			DeliveryQty: deliveryQty[i],
+			DeliveryPct: deliveryPct[i],
+			Turnover:    turnovers[i],
+		}
+	}
+	return candles, nil
+}
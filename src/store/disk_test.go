@@ -0,0 +1,193 @@
+package store
+
+import (
+	"bytes"
+	"eeye/src/models"
+	"eeye/src/testutil"
+	"testing"
+	"time"
+)
+
+func TestEncodeDecodeCandles(t *testing.T) {
+	tests := []struct {
+		name    string
+		candles []models.Candle
+	}{
+		{name: "empty", candles: []models.Candle{}},
+		{name: "series", candles: testutil.NewSeries("ABC").Range(10, 95, 105).Solid(0.02).Volume(5000).Delivery(55).Candles()},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			buf := bytes.Buffer{}
+			if err := encodeCandles(&buf, tt.candles); err != nil {
+				t.Fatalf("encodeCandles() error = %v", err)
+			}
+
+			got, err := decodeCandles(&buf, "ABC")
+			if err != nil {
+				t.Fatalf("decodeCandles() error = %v", err)
+			}
+
+			if !sameCandles(got, tt.candles) {
+				t.Errorf("decodeCandles() = %v, want %v", got, tt.candles)
+			}
+		})
+	}
+}
+
+func TestDecodeCandlesRejectsInvalidData(t *testing.T) {
+	valid := bytes.Buffer{}
+	if err := encodeCandles(&valid, testutil.NewSeries("ABC").Flat(3, 100).Candles()); err != nil {
+		t.Fatalf("encodeCandles() error = %v", err)
+	}
+
+	tests := []struct {
+		name string
+		data []byte
+	}{
+		{name: "empty", data: []byte{}},
+		{name: "wrong magic", data: append([]byte("NOPE"), valid.Bytes()[4:]...)},
+		{name: "wrong version", data: append([]byte(diskMagic+"\x09\x00"), valid.Bytes()[6:]...)},
+		{name: "truncated", data: valid.Bytes()[:valid.Len()-1]},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := decodeCandles(bytes.NewReader(tt.data), "ABC"); err == nil {
+				t.Errorf("decodeCandles() succeeded on invalid data")
+			}
+		})
+	}
+}
+
+func TestMergeCandles(t *testing.T) {
+	var (
+		cached = testutil.NewSeries("ABC").Flat(5, 100).Candles()
+		fresh  = testutil.NewSeries("ABC").Flat(7, 110).Candles()[3:]
+		since  = testutil.Start.AddDate(0, 0, 3)
+	)
+
+	merged := mergeCandles(cached, fresh, since)
+	if len(merged) != 7 {
+		t.Fatalf("len(mergeCandles()) = %v, want 7", len(merged))
+	}
+
+	for i := range merged {
+		want := 100.0
+		if i >= 3 {
+			want = 110
+		}
+
+		if merged[i].Close != want {
+			t.Errorf("merged[%d].Close = %v, want %v", i, merged[i].Close, want)
+		}
+
+		if !merged[i].Timestamp.Equal(testutil.Start.AddDate(0, 0, i)) {
+			t.Errorf("merged[%d].Timestamp = %v, want day %d", i, merged[i].Timestamp, i)
+		}
+	}
+
+	if got := mergeCandles(nil, fresh, time.Time{}); !sameCandles(got, fresh) {
+		t.Errorf("mergeCandles() without cache = %v, want %v", got, fresh)
+	}
+}
+
+func TestSameCandles(t *testing.T) {
+	var (
+		candles = testutil.NewSeries("ABC").Flat(3, 100).Candles()
+		moved   = testutil.NewSeries("ABC").Flat(3, 100).Candles()
+		changed = testutil.NewSeries("ABC").Flat(3, 100).Delivery(70).Candles()
+	)
+	moved[1].Timestamp = moved[1].Timestamp.In(time.FixedZone("IST", 19800))
+
+	tests := []struct {
+		name string
+		a    []models.Candle
+		b    []models.Candle
+		want bool
+	}{
+		{name: "identical", a: candles, b: testutil.NewSeries("ABC").Flat(3, 100).Candles(), want: true},
+		{name: "same instant in another zone", a: candles, b: moved, want: true},
+		{name: "different delivery", a: candles, b: changed, want: false},
+		{name: "different length", a: candles, b: candles[:2], want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := sameCandles(tt.a, tt.b); got != tt.want {
+				t.Errorf("sameCandles() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDiskPersistsCandlesAndManifest(t *testing.T) {
+	dir := t.TempDir()
+	candles := testutil.NewSeries("M&M").Trend(20, 0.01).Candles()
+
+	disk, err := OpenDisk(dir, 7)
+	if err != nil {
+		t.Fatalf("OpenDisk() error = %v", err)
+	}
+
+	if err := disk.save("M&M", candles); err != nil {
+		t.Fatalf("save() error = %v", err)
+	}
+	if err := disk.saveManifest(); err != nil {
+		t.Fatalf("saveManifest() error = %v", err)
+	}
+
+	reopened, err := OpenDisk(dir, 7)
+	if err != nil {
+		t.Fatalf("OpenDisk() error = %v", err)
+	}
+
+	entry, ok := reopened.entry("M&M")
+	if !ok {
+		t.Fatalf("manifest entry of M&M is missing")
+	}
+	if entry.Count != len(candles) || !entry.Last.Equal(candles[len(candles)-1].Timestamp) {
+		t.Errorf("entry = %+v, want %d candles ending at %v", entry, len(candles), candles[len(candles)-1].Timestamp)
+	}
+
+	got, err := reopened.load("M&M")
+	if err != nil {
+		t.Fatalf("load() error = %v", err)
+	}
+	if !sameCandles(got, candles) {
+		t.Errorf("load() = %v, want %v", got, candles)
+	}
+
+	reopened.forget("M&M")
+	if _, err := reopened.load("M&M"); err == nil {
+		t.Errorf("load() after forget() succeeded")
+	}
+}
+
+func TestTrim(t *testing.T) {
+	candles := testutil.NewSeries("ABC").Trend(10, 0.01).Candles()
+
+	tests := []struct {
+		name  string
+		limit int
+		want  int
+	}{
+		{name: "no limit", limit: 0, want: 10},
+		{name: "limit above length", limit: 20, want: 10},
+		{name: "latest candles", limit: 4, want: 4},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := trim(candles, tt.limit)
+			if len(got) != tt.want {
+				t.Fatalf("len(trim()) = %v, want %v", len(got), tt.want)
+			}
+
+			if !got[len(got)-1].Timestamp.Equal(candles[len(candles)-1].Timestamp) {
+				t.Errorf("trim() dropped the latest candle")
+			}
+		})
+	}
+}
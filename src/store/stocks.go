@@ -2,26 +2,34 @@
 package store
 
 import (
+	"context"
+	"eeye/src/config"
+	"eeye/src/constants"
 	"eeye/src/db"
 	"eeye/src/models"
 	"fmt"
-	"log"
+	"log/slog"
 	"sync"
 )
 
-var cache map[string][]models.Candle
-var mu sync.RWMutex
+// Memory is an in-memory models.CandleSource keyed by stock symbol.
+// It is safe for concurrent use.
+type Memory struct {
+	mu      sync.RWMutex
+	candles map[string][]models.Candle
+}
 
-func init() {
-	cache = make(map[string][]models.Candle)
+// NewMemory creates an empty in-memory candle source
+func NewMemory() *Memory {
+	return &Memory{candles: make(map[string][]models.Candle)}
 }
 
-// Get returns the candles of the given stock
-func Get(stock *models.Stock) ([]models.Candle, error) {
-	mu.RLock()
-	defer mu.RUnlock()
+//revive:disable-next-line exported
+func (m *Memory) Get(stock *models.Stock) ([]models.Candle, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
 
-	value, ok := cache[stock.Symbol]
+	value, ok := m.candles[stock.Symbol]
 	if !ok {
 		return value, fmt.Errorf("unexpected cache miss: %v", stock.Symbol)
 	}
@@ -29,29 +37,111 @@ func Get(stock *models.Stock) ([]models.Candle, error) {
 	return value, nil
 }
 
+// Set stores the candles of the given symbol, replacing any previous ones
+func (m *Memory) Set(symbol string, candles []models.Candle) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.candles[symbol] = candles
+}
+
+// Has reports whether the candles of the given symbol are stored
+func (m *Memory) Has(symbol string) bool {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	_, ok := m.candles[symbol]
+	return ok
+}
+
+// Delete removes the candles of the given symbol and reports whether they were present
+func (m *Memory) Delete(symbol string) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	_, ok := m.candles[symbol]
+	delete(m.candles, symbol)
+	return ok
+}
+
+// Cache is the candle source used by the analysis pipeline
+var Cache = NewMemory()
+
+// Get returns the candles of the given stock
+func Get(stock *models.Stock) ([]models.Candle, error) {
+	return Cache.Get(stock)
+}
+
 // Add retrieves candlestick data for a stock from the database and
 // caches it in memory for faster access by other analysis functions. This helps
 // prevent repeated database queries for the same data.
-func Add(stock *models.Stock) error {
-	candles, err := db.FetchAllCandles(stock)
+func Add(ctx context.Context, stock *models.Stock) error {
+	candles, err := db.FetchAllCandles(ctx, stock)
 	if err != nil {
 		return fmt.Errorf("failed to fetch candles for %v: %w", stock.Symbol, err)
 	}
 
-	mu.Lock()
-	defer mu.Unlock()
-	cache[stock.Symbol] = candles
+	Cache.Set(stock.Symbol, candles)
 	return nil
 }
 
+// diskCache opens the persistent candle cache once; it is nil when no cache directory is configured
+var diskCache = sync.OnceValues(func() (*Disk, error) {
+	if config.Analysis.CacheDir == "" {
+		return nil, nil
+	}
+
+	// Delivery data of recent days is filled in after the candles are stored
+	refreshDays := max(config.Ingestion.DeliveryDays, constants.MinCandleCacheRefreshDays)
+	return OpenDisk(config.Analysis.CacheDir, refreshDays)
+})
+
+// AddBatch retrieves the candles of several stocks and caches them in memory, reading
+// them from the persistent candle cache if it is enabled and from the database with a
+// single query otherwise. If limit is positive only the latest limit candles are kept.
+// Stocks without stored candles are cached with no candles, like Add would.
+func AddBatch(ctx context.Context, stocks []models.Stock, limit int) error {
+	symbols := make([]string, 0, len(stocks))
+	for i := range stocks {
+		symbols = append(symbols, stocks[i].Symbol)
+	}
+
+	disk, err := diskCache()
+	if err != nil {
+		slog.Warn("candle cache unavailable, reading the database", "err", err)
+	}
+
+	var candles map[string][]models.Candle
+	if disk != nil {
+		candles, err = disk.Fetch(ctx, symbols, config.Analysis.CacheRefresh)
+	} else {
+		candles, err = db.FetchCandlesBatch(ctx, symbols, limit)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to fetch candles of %d stocks: %w", len(symbols), err)
+	}
+
+	for _, symbol := range symbols {
+		value, ok := candles[symbol]
+		if !ok {
+			value = make([]models.Candle, 0)
+		}
+		Cache.Set(symbol, trim(value, limit))
+	}
+	return nil
+}
+
+// trim returns the latest limit candles, or all of them if limit is not positive
+func trim(candles []models.Candle, limit int) []models.Candle {
+	if limit <= 0 || len(candles) <= limit {
+		return candles
+	}
+	return candles[len(candles)-limit:]
+}
+
 // Purge removes the cached candlestick data for a specific stock.
 func Purge(stock *models.Stock) {
-	mu.Lock()
-	defer mu.Unlock()
-
-	_, ok := cache[stock.Symbol]
-	if ok {
-		log.Printf("purged %v from cache\n", stock.Symbol)
-		delete(cache, stock.Symbol)
+	if Cache.Delete(stock.Symbol) {
+		slog.Debug("purged from cache", "symbol", stock.Symbol)
 	}
 }
@@ -1,13 +1,17 @@
 package strategy
 
 import (
+	"context"
+	"eeye/src/alerts"
+	"eeye/src/config"
 	"eeye/src/constants"
 	"eeye/src/dataflow"
+	"eeye/src/metrics"
 	"eeye/src/models"
+	"eeye/src/notify"
 	"eeye/src/store"
 	"eeye/src/utils"
-	"log"
-	"strings"
+	"log/slog"
 	"sync"
 	"time"
 
@@ -18,33 +22,57 @@ import (
 // and sends the results to their respective sinks.
 //
 // For each stock received from the source channel:
-//  1. Fetch and cache historical data in the store
+//  1. Fetch and cache historical data in the store, unless the feeder already loaded it
 //  2. Execute all strategies concurrently (each in its own goroutine)
 //  3. Wait for all strategies to complete
 //  4. Clean up the stock data from the store
 //
+// Once ctx is cancelled, the remaining stocks are drained without being analyzed
+// (their batch candles are still purged from the store).
+//
 // This design allows multiple strategies to analyze the same stock simultaneously,
 // maximizing throughput while ensuring proper cleanup after analysis.
 //
 // Parameters:
+//   - ctx: Cancels the analysis of the remaining stocks
 //   - strategies: List of strategies to apply to each stock
 //   - source: Channel providing stocks to analyze
-func executor(strategies []models.Strategy, source <-chan *models.Stock, bar *progressbar.ProgressBar) {
+func executor(
+	ctx context.Context,
+	strategies []models.Strategy,
+	source chan *models.Stock,
+	bar *progressbar.ProgressBar,
+) {
 	// Process each stock from the source channel until it's closed
 	for stock := range source {
-		// Fetch and cache historical data for this stock
-		if err := store.Add(stock); err != nil {
-			log.Printf("historical data extraction failed for %v: %v\n", stock.Symbol, err)
-			_ = bar.Add(1)
+		metrics.AnalysisQueueDepth.Set(float64(len(source)))
+
+		if ctx.Err() != nil {
+			store.Purge(stock)
 			continue
 		}
 
+		// Stocks are normally loaded in batches by the feeder; fetch them one by one
+		// only if their batch could not be loaded
+		if store.Cache.Has(stock.Symbol) {
+			metrics.StoreLookups.Inc("memory", "hit")
+		} else {
+			metrics.StoreLookups.Inc("memory", "miss")
+			if err := store.Add(ctx, stock); err != nil {
+				slog.Error("historical data extraction failed", "symbol", stock.Symbol, "err", err)
+				_ = bar.Add(1)
+				continue
+			}
+		}
+
 		// Execute all strategies concurrently for this stock
 		wg := sync.WaitGroup{}
 		for i := range strategies {
 			wg.Go(func() {
 				// Each strategy runs independently on the same stock data
+				start := time.Now()
 				strategies[i].Execute(stock)
+				metrics.StrategyDuration.ObserveSince(start, strategies[i].Name())
 			})
 		}
 
@@ -53,6 +81,7 @@ func executor(strategies []models.Strategy, source <-chan *models.Stock, bar *pr
 
 		// Clean up cached data for this stock to free memory
 		store.Purge(stock)
+		metrics.StocksAnalyzed.Inc()
 		_ = bar.Add(1)
 	}
 }
@@ -63,7 +92,7 @@ func executor(strategies []models.Strategy, source <-chan *models.Stock, bar *pr
 //
 // Architecture:
 //   - Creates a buffered input channel for stocks (source)
-//   - Spawns N worker goroutines (defined by constants.NumOfStrategyWorkers)
+//   - Spawns N worker goroutines (defined by config.Workers.StrategyWorkers)
 //   - Each worker runs the executor function, pulling stocks from the source channel
 //   - Returns a done channel that signals when all workers have finished
 //
@@ -74,15 +103,16 @@ func executor(strategies []models.Strategy, source <-chan *models.Stock, bar *pr
 //   - Enables graceful shutdown of downstream components (aggregators)
 //
 // Parameters:
+//   - ctx: Cancels the analysis of the remaining stocks
 //   - strategies: List of strategies that each worker will execute on stocks
 //
 // Returns:
 //   - source: Buffered channel to send stocks for processing
 //   - done: Signal channel that closes when all workers have finished
-func spawnStrategyWorkers(strategies []models.Strategy, numOfStocks int) (chan *models.Stock, chan any) {
+func spawnStrategyWorkers(ctx context.Context, strategies []models.Strategy, numOfStocks int) (chan *models.Stock, chan any) {
 	var (
 		// Buffered channel to prevent blocking when sending stocks
-		source = make(chan *models.Stock, constants.StrategyWorkerInputBufferSize)
+		source = make(chan *models.Stock, config.Workers.StrategyQueueSize)
 		// Signal channel for completion notification
 		done = make(chan any)
 	)
@@ -92,10 +122,10 @@ func spawnStrategyWorkers(strategies []models.Strategy, numOfStocks int) (chan *
 		bar := utils.GetProgressTracker(numOfStocks, "Analyzing stocks...")
 
 		// Spawn N worker goroutines to process stocks in parallel
-		for range constants.NumOfStrategyWorkers {
+		for range config.Workers.StrategyWorkers {
 			wg.Go(func() {
 				// Each worker runs the executor, pulling from the shared source channel
-				executor(strategies, source, bar)
+				executor(ctx, strategies, source, bar)
 			})
 		}
 
@@ -115,35 +145,81 @@ func spawnStrategyWorkers(strategies []models.Strategy, numOfStocks int) (chan *
 	return source, done
 }
 
-// feeder sends stocks to the source channel for processing and closes the channel when done.
+// feeder loads the candles of stocks in batches, sends the stocks to the source channel
+// for processing and closes the channel when done.
 // This function acts as a producer in the producer-consumer pattern, feeding stocks to the
 // worker pool for parallel analysis.
 //
 // Process:
-//  1. Iterates through all stocks in the provided slice
-//  2. Sends each stock to the source channel (consumed by workers)
-//  3. Closes the source channel when all stocks have been sent
+//  1. Splits the stocks into batches of config.Analysis.BatchSize
+//  2. Loads the candles of a whole batch into the store with a single query
+//  3. Sends each stock of the batch to the source channel (consumed by workers)
+//  4. Closes the source channel when all stocks have been sent
+//
+// The next batch is only loaded once the previous one has been handed to the workers,
+// so the cached candles are bounded by a batch plus the source channel buffer and the
+// stocks being analyzed. If a batch fails to load, the executors fetch its stocks one by one.
 //
 // Closing the channel signals to workers that no more stocks will be sent,
-// allowing them to finish processing and exit gracefully.
+// allowing them to finish processing and exit gracefully. Once ctx is cancelled,
+// no further batches are loaded and the channel is closed early.
 //
 // Parameters:
+//   - ctx: Stops feeding stocks when cancelled
 //   - stocks: Slice of stocks to feed to the worker pool
 //   - source: Send-only channel where stocks are sent for processing
-func feeder(stocks []models.Stock, source chan<- *models.Stock) {
+//   - limit: Number of latest candles loaded per stock (0 for the full history)
+func feeder(ctx context.Context, stocks []models.Stock, source chan<- *models.Stock, limit int) {
 	go func() {
-		// Send each stock to the worker pool
-		for i := range stocks {
-			source <- &stocks[i]
+		defer close(source)
+
+		batchSize := config.Analysis.BatchSize
+		for start := 0; start < len(stocks) && ctx.Err() == nil; start += batchSize {
+			end := min(start+batchSize, len(stocks))
+
+			if err := store.AddBatch(ctx, stocks[start:end], limit); err != nil {
+				slog.Warn("batch candle loading failed", "err", err)
+			}
+
+			// Send each stock of the batch to the worker pool; stocks of the batch
+			// which are not handed over are purged from the store
+			for i := start; i < end; i++ {
+				select {
+				case source <- &stocks[i]:
+				case <-ctx.Done():
+					for j := i; j < end; j++ {
+						store.Purge(&stocks[j])
+					}
+					return
+				}
+			}
 		}
 
 		// Close the channel to signal no more stocks will be sent
 		// This allows workers to exit their range loops and shutdown gracefully
-		close(source)
 	}()
 }
 
-// aggregator collects results from all strategies and logs them once processing is complete.
+// lookback returns the number of latest candles loaded per stock for the given strategies.
+// Without trimming, or if any strategy needs the full history, all candles are loaded (0).
+func lookback(strategies []models.Strategy) int {
+	if !config.Analysis.Trim {
+		return 0
+	}
+
+	limit := 0
+	for i := range strategies {
+		candles := strategies[i].Lookback()
+		if candles <= 0 {
+			return 0
+		}
+		limit = max(limit, candles)
+	}
+	return limit
+}
+
+// aggregator collects results from all strategies, logs them once processing is complete
+// and returns them in the order of the strategies.
 // This function implements a fan-in pattern, collecting results from multiple strategy sinks
 // into a single aggregation point for reporting.
 //
@@ -151,7 +227,7 @@ func feeder(stocks []models.Stock, source chan<- *models.Stock) {
 //  1. For each strategy, spawn a goroutine to collect stocks from its sink
 //  2. Wait for all strategy workers to finish (via done channel)
 //  3. Close all strategy sinks to signal aggregators to finish
-//  4. Collect all strategy results and log them
+//  4. Collect all strategy results, log and return them
 //
 // Shutdown Sequence:
 //   - done channel closes → all workers finished processing
@@ -163,10 +239,11 @@ func feeder(stocks []models.Stock, source chan<- *models.Stock) {
 // Parameters:
 //   - strategies: List of strategies whose results need to be collected
 //   - done: Signal channel indicating when strategy workers have finished
-func aggregator(strategies []models.Strategy, done <-chan any) {
+func aggregator(strategies []models.Strategy, done <-chan any) []*models.StrategyResult {
 	var (
-		wg  = sync.WaitGroup{}
-		agg = make(chan *models.StrategyResult, len(strategies))
+		wg      = sync.WaitGroup{}
+		agg     = make(chan int, len(strategies))
+		results = make([]*models.StrategyResult, len(strategies))
 	)
 
 	// Spawn a goroutine for each strategy to collect its results
@@ -178,8 +255,9 @@ func aggregator(strategies []models.Strategy, done <-chan any) {
 				res = append(res, stock)
 			}
 
-			// Send the complete result set to the aggregation channel
-			agg <- &models.StrategyResult{Strategy: strategies[i], Stocks: res}
+			// Store the complete result set and notify the aggregation loop
+			results[i] = &models.StrategyResult{Strategy: strategies[i], Stocks: res}
+			agg <- i
 		})
 	}
 
@@ -206,7 +284,8 @@ func aggregator(strategies []models.Strategy, done <-chan any) {
 	}()
 
 	// Collect and log results from all strategies
-	for result := range agg {
+	for i := range agg {
+		result := results[i]
 		strategyName := result.Strategy.Name()
 		symbols := utils.EmptySlice[string]()
 
@@ -217,86 +296,125 @@ func aggregator(strategies []models.Strategy, done <-chan any) {
 
 		// Log results
 		if len(symbols) > 0 {
-			log.Printf("%v result: \n%v\n", strategyName, strings.Join(symbols, "\n"))
+			slog.Info("strategy result", "strategy", strategyName, "stocks", len(symbols), "symbols", symbols)
 		} else {
-			log.Printf("no stocks satisfy %v\n", strategyName)
+			slog.Info("no stocks satisfy strategy", "strategy", strategyName)
 		}
 	}
+
+	return results
 }
 
-// Analyze orchestrates the execution of all trading strategies on the stock universe.
-// This is the main entry point for strategy analysis, coordinating the entire pipeline:
-//  1. Fetch all stocks from the data source
-//  2. Initialize and configure all trading strategies
-//  3. Spawn worker pool to process stocks concurrently
-//  4. Feed stocks to the worker pool
-//  5. Aggregate and log results from all strategies
-//  6. Report total execution time
+// Screen runs the given strategies on the stocks with the candles already stored in the
+// database, without ingesting anything:
+//  1. Configure the strategies to read the candles cached by the executors
+//  2. Spawn worker pool to process stocks concurrently
+//  3. Feed stocks to the worker pool
+//  4. Aggregate and log results from all strategies
 //
 // Concurrency Model:
 //   - Multiple worker goroutines process stocks in parallel
 //   - Each stock is analyzed by all strategies concurrently
 //   - Results are collected via a fan-in aggregation pattern
 //
-// Active Strategies:
-//   - BullishSwing: RSI 40-60 with Bollinger Band support
-//   - LowerBollingerBandBullish: Flat or V-shaped lower band patterns
-//   - EmaFakeBreakdown: Fake breakdown below 50-day EMA
-//   - FakeBreakdown: Fake breakdown below support levels (5-day window, 1% tolerance)
-//   - RsiEntersBullishSwingZone: RSI crossing into 40-60 range
-//   - BullishMomentumBreakout: Strong momentum with EMA alignment
+// Cancelling ctx aborts in-flight queries, drains the worker pool and still returns
+// (and logs) the partial results of the stocks analyzed so far together with ctx.Err().
 //
 // Returns:
-//   - Signal channel that closes when analysis is complete
-//     This allows callers to wait for completion if needed
-func Analyze() <-chan any {
-	done := make(chan any)
+//   - The result of every strategy, in the order of strategies
+//   - ctx.Err() if the screening was cancelled
+func Screen(ctx context.Context, stocks []models.Stock, strategies []models.Strategy) ([]*models.StrategyResult, error) {
+	start := time.Now()
+
+	// Strategies read the candles cached by the executors
+	for i := range strategies {
+		strategies[i].SetSource(store.Cache)
+		strategies[i].SetSinkSize(config.Workers.StrategySinkSize)
+	}
+
+	// Set up concurrent processing pipeline
+	source, isWorkDone := spawnStrategyWorkers(ctx, strategies, len(stocks))
+	feeder(ctx, stocks, source, lookback(strategies))
+	results := aggregator(strategies, isWorkDone)
+
+	if err := ctx.Err(); err != nil {
+		slog.Warn("analysis cancelled, results are partial", "elapsed", time.Since(start))
+		return results, err
+	}
+
+	// Log performance metrics
+	slog.Info("analysis completed", "elapsed", time.Since(start))
+	return results, nil
+}
+
+// Analyze orchestrates a full run: it ingests the latest data, evaluates the price alerts,
+// screens the whole stock universe with all active strategies (see All and Screen), labels
+// the signals against the previous run, stores them as the run of the last session and
+// notifies the configured sinks. Failing to evaluate the alerts or to store or notify the
+// signals is logged without failing the run.
+// Cancelling ctx aborts in-flight requests and queries, drains the worker pool and
+// still logs the partial results of the stocks analyzed so far.
+//
+// Returns:
+//   - Channel that receives the outcome of the run and then closes
+//     A nil value means ingestion and analysis completed successfully,
+//     ctx.Err() is sent if the run was cancelled
+func Analyze(ctx context.Context) <-chan error {
+	done := make(chan error, 1)
 
 	go func() {
 		defer close(done)
 
-		start := time.Now()
+		// Summarize the ingestion and analysis metrics of this run, even if it fails
+		run := metrics.StartRun()
+		defer func() {
+			slog.Info("run summary", "metrics", run)
+		}()
+
+		// Invalid sinks are reported before the long ingestion
+		dispatcher, err := notify.New(Names())
+		if err != nil {
+			done <- err
+			return
+		}
 
 		// Fetch all stocks from the data source
-		stocks := dataflow.GetStocks()
-
-		// Initialize all trading strategies with their configurations
-		strategies := []models.Strategy{
-			// Swing trading strategy looking for balanced momentum
-			&BullishSwing{},
-
-			// Simple Bollinger Band reversal strategy
-			&LowerBollingerBandBullish{},
-
-			// Fake breakdown at 50-day EMA (dynamic support)
-			&EmaFakeBreakdown{period: 50},
-
-			// Fake breakdown at static support levels
-			// Window: 5 periods for recent support identification
-			// Tolerance: 1% price clustering for level formation
-			// Strength: 3 minimum touches to confirm level validity
-			&FakeBreakdown{
-				Window:    5,
-				Tolerance: 0.01,
-				Strength:  3,
-			},
-
-			// RSI momentum shift detection
-			// baseLine: 40 (minimum RSI to enter swing zone)
-			// upperBound: 60 (maximum RSI to avoid overbought)
-			&RsiEntersBullishSwingZone{baseLine: 40, upperBound: 60},
-
-			// Aggressive breakout strategy with multiple confirmations
-			&BullishMomentumBreakout{},
+		stocks, err := dataflow.GetStocks(ctx)
+		if err != nil {
+			slog.Error("failed to get stocks", "err", err)
+			done <- err
+			return
+		}
+
+		// Alerts are evaluated against the candles just ingested
+		if err := alerts.Evaluate(ctx, dispatcher); err != nil {
+			slog.Error("failed to evaluate alerts", "err", err)
+		}
+
+		results, err := Screen(ctx, stocks, All())
+		if err != nil {
+			done <- err
+			return
 		}
 
-		// Set up concurrent processing pipeline
-		source, isWorkDone := spawnStrategyWorkers(strategies, len(stocks))
-		feeder(stocks, source)
-		aggregator(strategies, isWorkDone)
+		// Signals are compared before the run is stored, so that a re-run on the same
+		// day is still compared with the previous day
+		day, err := dataflow.LatestSession(ctx)
+		if err != nil {
+			slog.Error("failed to find the ingested trading day", "err", err)
+		} else {
+			if err := CompareSignals(ctx, day, results, nil); err != nil {
+				slog.Error("failed to compare signals with the previous run", "err", err)
+			}
+			if err := SaveSignals(ctx, day, results); err != nil {
+				slog.Error("failed to save signals", "err", err)
+			}
+		}
 
-		// Log performance metrics
-		log.Printf("time taken to complete analysis %s\n", time.Since(start))
+		if err := dispatcher.Send(ctx, results); err != nil {
+			slog.Error("failed to notify signals", "err", err)
+		}
+		done <- nil
 	}()
 
 	return done
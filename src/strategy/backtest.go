@@ -0,0 +1,176 @@
+package strategy
+
+import (
+	"context"
+	"eeye/src/config"
+	"eeye/src/db"
+	"eeye/src/models"
+	"eeye/src/utils"
+	"log/slog"
+	"sort"
+	"sync"
+	"time"
+)
+
+// BacktestOptions selects the sessions replayed by Backtest
+type BacktestOptions struct {
+	// From and To bound the sessions (inclusive) on which signals are looked for
+	From time.Time
+	To   time.Time
+
+	// Hold is the number of sessions a selected stock is held; signals without
+	// Hold later sessions are skipped
+	Hold int
+}
+
+// historySource serves the candles of a stock up to a session, as if that session were
+// the latest one. With a lookback, only the latest lookback candles are served.
+type historySource struct {
+	candles  []models.Candle
+	end      int
+	lookback int
+}
+
+//revive:disable-next-line exported
+func (h *historySource) Get(_ *models.Stock) ([]models.Candle, error) {
+	start := 0
+	if h.lookback > 0 {
+		start = max(0, h.end-h.lookback)
+	}
+	return h.candles[start:h.end], nil
+}
+
+// backtestStock replays every session of the stock within the options and returns the
+// signals of the strategies. Each strategy must read its candles from the matching source.
+func backtestStock(
+	stock *models.Stock,
+	candles []models.Candle,
+	strategies []models.Strategy,
+	sources []*historySource,
+	opts BacktestOptions,
+) []models.BacktestSignal {
+	var (
+		from    = opts.From.Format("2006-01-02")
+		to      = opts.To.Format("2006-01-02")
+		signals = make([]models.BacktestSignal, 0)
+	)
+
+	for i := 0; i+opts.Hold < len(candles); i++ {
+		day := candles[i].Timestamp.Format("2006-01-02")
+		if day < from {
+			continue
+		}
+		if day > to {
+			break
+		}
+
+		for j := range strategies {
+			sources[j].candles = candles
+			sources[j].end = i + 1
+			strategies[j].Execute(stock)
+
+			select {
+			case <-strategies[j].GetSink():
+				signals = append(signals, models.BacktestSignal{
+					Strategy: strategies[j].Name(),
+					Symbol:   stock.Symbol,
+					Date:     candles[i].Timestamp,
+					Entry:    candles[i].Close,
+					Exit:     candles[i+opts.Hold].Close,
+				})
+			default:
+			}
+		}
+	}
+
+	return signals
+}
+
+// Backtest replays the sessions between opts.From and opts.To for every stock and
+// collects the stocks the named strategies (all active ones without names) would have
+// selected on each session, with their return after opts.Hold sessions.
+// Stocks are replayed concurrently by config.Workers.StrategyWorkers workers, each with
+// its own strategy instances. Cancelling ctx stops the replay and returns the partial
+// results together with ctx.Err().
+func Backtest(
+	ctx context.Context,
+	stocks []models.Stock,
+	names []string,
+	opts BacktestOptions,
+) ([]models.BacktestResult, error) {
+	strategies, err := Select(names)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		in      = make(chan *models.Stock, config.Workers.StrategyQueueSize)
+		out     = make(chan []models.BacktestSignal, config.Workers.StrategyWorkers)
+		wg      = sync.WaitGroup{}
+		bar     = utils.GetProgressTracker(len(stocks), "Backtesting stocks...")
+		signals = make(map[string][]models.BacktestSignal)
+	)
+
+	for range config.Workers.StrategyWorkers {
+		wg.Go(func() {
+			// Strategies hold their source and sink, so every worker replays with its own
+			workerStrategies, _ := Select(names)
+			sources := make([]*historySource, len(workerStrategies))
+			for i := range workerStrategies {
+				sources[i] = &historySource{lookback: workerStrategies[i].Lookback()}
+				workerStrategies[i].SetSource(sources[i])
+			}
+
+			for stock := range in {
+				if ctx.Err() != nil {
+					continue
+				}
+
+				candles, err := db.FetchAllCandles(ctx, stock)
+				if err != nil {
+					slog.Error("historical data extraction failed", "symbol", stock.Symbol, "err", err)
+				} else {
+					out <- backtestStock(stock, candles, workerStrategies, sources, opts)
+				}
+				_ = bar.Add(1)
+			}
+		})
+	}
+
+	go func() {
+		defer close(in)
+		for i := range stocks {
+			select {
+			case in <- &stocks[i]:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	go func() {
+		wg.Wait()
+		close(out)
+	}()
+
+	for stockSignals := range out {
+		for _, signal := range stockSignals {
+			signals[signal.Strategy] = append(signals[signal.Strategy], signal)
+		}
+	}
+
+	results := make([]models.BacktestResult, 0, len(strategies))
+	for i := range strategies {
+		name := strategies[i].Name()
+		res := models.BacktestResult{Strategy: name, Signals: signals[name]}
+		sort.Slice(res.Signals, func(a, b int) bool {
+			if !res.Signals[a].Date.Equal(res.Signals[b].Date) {
+				return res.Signals[a].Date.Before(res.Signals[b].Date)
+			}
+			return res.Signals[a].Symbol < res.Signals[b].Symbol
+		})
+		results = append(results, res)
+	}
+
+	return results, ctx.Err()
+}
@@ -0,0 +1,116 @@
+package strategy
+
+import (
+	"eeye/src/models"
+	"eeye/src/testutil"
+	"testing"
+)
+
+func TestBacktestStock(t *testing.T) {
+	var (
+		// the fake breakdown candle is the 61st session (index 60)
+		series  = testutil.NewSeries("EMA").Flat(60, 100).Candle(99.6, 101.5, 99.2, 101.4).Flat(5, 103)
+		signal  = testutil.Start.AddDate(0, 0, 60)
+		exitDay = 62
+	)
+
+	tests := []struct {
+		name string
+		opts BacktestOptions
+		want int
+	}{
+		{
+			name: "signal within the range",
+			opts: BacktestOptions{From: testutil.Start, To: signal.AddDate(0, 0, 10), Hold: 2},
+			want: 1,
+		},
+		{
+			name: "range after the signal",
+			opts: BacktestOptions{From: signal.AddDate(0, 0, 1), To: signal.AddDate(0, 0, 10), Hold: 2},
+			want: 0,
+		},
+		{
+			name: "not enough sessions to hold",
+			opts: BacktestOptions{From: testutil.Start, To: signal.AddDate(0, 0, 10), Hold: 10},
+			want: 0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var (
+				strategies = []models.Strategy{&EmaFakeBreakdown{period: 50}}
+				sources    = []*historySource{{lookback: strategies[0].Lookback()}}
+				candles    = series.Candles()
+			)
+			strategies[0].SetSource(sources[0])
+
+			got := backtestStock(series.Stock(), candles, strategies, sources, tt.opts)
+			if len(got) != tt.want {
+				t.Fatalf("backtestStock() returned %d signals, want %d: %+v", len(got), tt.want, got)
+			}
+			if tt.want == 0 {
+				return
+			}
+
+			if !got[0].Date.Equal(signal) || got[0].Entry != 101.4 || got[0].Exit != candles[exitDay].Close {
+				t.Errorf("backtestStock() = %+v, want signal on %v from 101.4 to %v", got[0], signal, candles[exitDay].Close)
+			}
+		})
+	}
+}
+
+func TestBacktestResult(t *testing.T) {
+	res := models.BacktestResult{
+		Signals: []models.BacktestSignal{
+			{Entry: 100, Exit: 110},
+			{Entry: 100, Exit: 95},
+			{Entry: 200, Exit: 210},
+			{Entry: 50, Exit: 50},
+		},
+	}
+
+	if got := res.WinRate(); got != 50 {
+		t.Errorf("WinRate() = %v, want 50", got)
+	}
+	if got := res.AverageReturn(); got != 2.5 {
+		t.Errorf("AverageReturn() = %v, want 2.5", got)
+	}
+}
+
+func TestSelect(t *testing.T) {
+	tests := []struct {
+		name    string
+		names   []string
+		want    []string
+		wantErr bool
+	}{
+		{name: "all strategies", names: nil, want: Names()},
+		{name: "case insensitive", names: []string{"bullish swing", "FAKE BREAKDOWN"}, want: []string{"Bullish Swing", "Fake Breakdown"}},
+		{name: "delivery variant", names: []string{"fake breakdown delivery"}, want: []string{"Fake Breakdown Delivery"}},
+		{name: "repeated names", names: []string{"Bullish Swing", "bullish swing"}, want: []string{"Bullish Swing"}},
+		{name: "unknown strategy", names: []string{"Bullish Swing", "Moon"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			strategies, err := Select(tt.names)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Select() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			got := make([]string, 0, len(strategies))
+			for i := range strategies {
+				got = append(got, strategies[i].Name())
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("Select() = %v, want %v", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Errorf("Select() = %v, want %v", got, tt.want)
+				}
+			}
+		})
+	}
+}
@@ -38,6 +38,16 @@ func (b *BullishMomentumBreakout) Name() string {
 	return "Bullish momentum"
 }
 
+// Lookback returns the number of latest candles the strategy needs.
+//
+// Returns:
+//   - 600 candles: three periods of the slowest EMA (200) of the crossover
+//
+//revive:disable-next-line exported
+func (b *BullishMomentumBreakout) Lookback() int {
+	return 600
+}
+
 // Execute runs the BullishMomentumBreakout strategy on the given stock.
 // It applies five rigorous screening steps:
 //  1. BullishCandle: Confirms strong bullish price action
@@ -133,7 +143,7 @@ func (b *BullishMomentumBreakout) Execute(stock *models.Stock) {
 	}
 
 	// Execute all screening steps; if all five pass, send stock to output sink
-	if steps.Execute(strategyName, stock, screeners) {
+	if steps.Execute(strategyName, stock, b.GetSource(), screeners) {
 		sink <- stock
 	}
 }
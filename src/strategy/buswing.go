@@ -31,6 +31,16 @@ func (b *BullishSwing) Name() string {
 	return "Bullish Swing"
 }
 
+// Lookback returns the number of latest candles the strategy needs.
+//
+// Returns:
+//   - 150 candles: RSI 14 uses Wilder smoothing, which needs about ten periods to settle
+//
+//revive:disable-next-line exported
+func (b *BullishSwing) Lookback() int {
+	return 150
+}
+
 // Execute runs the BullishSwing strategy on the given stock.
 // It applies a series of screening steps in sequence:
 //  1. BullishCandle: Confirms a bullish candlestick pattern
@@ -86,7 +96,7 @@ func (b *BullishSwing) Execute(stock *models.Stock) {
 	}
 
 	// Execute all screening steps; if all pass, send stock to output sink
-	if steps.Execute(strategyName, stock, screeners) {
+	if steps.Execute(strategyName, stock, b.GetSource(), screeners) {
 		sink <- stock
 	}
 }
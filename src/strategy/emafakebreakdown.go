@@ -38,6 +38,16 @@ func (e *EmaFakeBreakdown) Name() string {
 	return fmt.Sprintf("EMA %v fake breakdown", e.period)
 }
 
+// Lookback returns the number of latest candles the strategy needs.
+//
+// Returns:
+//   - Three EMA periods, so the EMA seed no longer affects its latest value
+//
+//revive:disable-next-line exported
+func (e *EmaFakeBreakdown) Lookback() int {
+	return 3 * e.period
+}
+
 // Execute runs the EmaFakeBreakdown strategy on the given stock.
 // It applies two screening steps:
 //  1. BullishCandle: Confirms a bullish reversal pattern
@@ -79,7 +89,7 @@ func (e *EmaFakeBreakdown) Execute(stock *models.Stock) {
 	}
 
 	// Execute all screening steps; if both pass, send stock to output sink
-	if steps.Execute(strategyName, stock, screeners) {
+	if steps.Execute(strategyName, stock, e.GetSource(), screeners) {
 		sink <- stock
 	}
 }
@@ -4,7 +4,7 @@ import (
 	"eeye/src/models"
 	"eeye/src/steps"
 	"eeye/src/utils"
-	"log"
+	"log/slog"
 )
 
 // FakeBreakdown identifies stocks that have broken below support/liquidity levels
@@ -13,6 +13,7 @@ import (
 //   - A bullish candle pattern (indicating potential reversal)
 //   - Price breaking below a support/liquidity level but closing above it (the fake breakdown)
 //   - Above-average volume confirming the reversal
+//   - Optionally, above-average delivery percentage showing accumulation
 //
 // Trading Logic:
 //   - Support levels are identified using a clustering algorithm on historical lows
@@ -34,24 +35,41 @@ type FakeBreakdown struct {
 	Window    int     // Lookback window for identifying liquidity levels (e.g., 5 for recent levels)
 	Tolerance float64 // Price tolerance for clustering levels (e.g., 0.01 for 1%, 0.02 for 2%)
 	Strength  int     // Minimum touches required for a level to be significant (e.g., 3)
+	Delivery  bool    // Require above-average delivery percentage on the reversal candle
 }
 
 // Name returns the strategy identifier.
 //
 // Returns:
-//   - The name of this strategy ("Fake Breakdown")
+//   - The name of this strategy ("Fake Breakdown", or "Fake Breakdown Delivery" if Delivery is set)
 //
 //revive:disable-next-line exported
 func (f *FakeBreakdown) Name() string {
+	if f.Delivery {
+		return "Fake Breakdown Delivery"
+	}
 	return "Fake Breakdown"
 }
 
+// Lookback returns the number of latest candles the strategy needs.
+//
+// Returns:
+//   - 250 candles: support levels are clustered over about a year of sessions
+//
+//revive:disable-next-line exported
+func (f *FakeBreakdown) Lookback() int {
+	return 250
+}
+
 // Execute runs the FakeBreakdown strategy on the given stock.
 // It applies three screening steps:
 //  1. BullishCandle: Confirms bullish reversal pattern
 //  2. LiquidityLevels: Identifies support levels and checks for fake breakdown pattern
 //  3. Volume: Ensures above-average volume for conviction
 //
+// If Delivery is set, a fourth step (Delivery) ensures the reversal candle has an
+// above-average delivery percentage, i.e. the buying is not just intraday churn.
+//
 // If all conditions are met, the stock is sent to the strategy's output sink.
 //
 // Parameters:
@@ -99,7 +117,7 @@ func (f *FakeBreakdown) Execute(stock *models.Stock) {
 					},
 				)
 
-				log.Printf("[%v] %v did fake breakdown at levels %v", strategyName, stock.Symbol, fakeBreakdownLevels)
+				slog.Debug("fake breakdown", "strategy", strategyName, "symbol", stock.Symbol, "levels", fakeBreakdownLevels)
 				return len(fakeBreakdownLevels) > 0
 			},
 		},
@@ -115,8 +133,18 @@ func (f *FakeBreakdown) Execute(stock *models.Stock) {
 		},
 	}
 
-	// Execute all screening steps; if all three pass, send stock to output sink
-	if steps.Execute(strategyName, stock, screeners) {
+	if f.Delivery {
+		// Step 4: Verify delivery percentage is above average
+		screeners = append(screeners, &steps.Delivery{
+			Test: func(currentPct float64, averagePct float64) bool {
+				// Shares bought for delivery show accumulation rather than intraday trading
+				return currentPct >= averagePct
+			},
+		})
+	}
+
+	// Execute all screening steps; if all pass, send stock to output sink
+	if steps.Execute(strategyName, stock, f.GetSource(), screeners) {
 		sink <- stock
 	}
 }
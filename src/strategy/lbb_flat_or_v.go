@@ -35,6 +35,16 @@ func (l *LowerBollingerBandBullish) Name() string {
 	return "Lower Bollinger Band Bullish"
 }
 
+// Lookback returns the number of latest candles the strategy needs.
+//
+// Returns:
+//   - 60 candles: Bollinger Bands only depend on the last 20 closes
+//
+//revive:disable-next-line exported
+func (l *LowerBollingerBandBullish) Lookback() int {
+	return 60
+}
+
 // Execute runs the LowerBollingerBandBullish strategy on the given stock.
 // It applies two screening steps:
 //  1. BullishCandle: Confirms a bullish price reversal pattern
@@ -67,7 +77,7 @@ func (l *LowerBollingerBandBullish) Execute(stock *models.Stock) {
 	}
 
 	// Execute all screening steps; if both pass, send stock to output sink
-	if steps.Execute(strategyName, stock, screeners) {
+	if steps.Execute(strategyName, stock, l.GetSource(), screeners) {
 		sink <- stock
 	}
 }
@@ -0,0 +1,104 @@
+package strategy
+
+import (
+	"eeye/src/models"
+	"fmt"
+	"strings"
+)
+
+// All returns new instances of all active trading strategies with their configurations.
+// Strategies hold their output channel, so every run needs its own instances.
+func All() []models.Strategy {
+	return []models.Strategy{
+		// Swing trading strategy looking for balanced momentum
+		&BullishSwing{},
+
+		// Simple Bollinger Band reversal strategy
+		&LowerBollingerBandBullish{},
+
+		// Fake breakdown at 50-day EMA (dynamic support)
+		&EmaFakeBreakdown{period: 50},
+
+		// Fake breakdown at static support levels
+		// Window: 5 periods for recent support identification
+		// Tolerance: 1% price clustering for level formation
+		// Strength: 3 minimum touches to confirm level validity
+		&FakeBreakdown{
+			Window:    5,
+			Tolerance: 0.01,
+			Strength:  3,
+		},
+
+		// Fake breakdown at static support levels, confirmed by delivery
+		// Delivery: reversal candle must have above-average delivery percentage,
+		// so stocks without delivery data are never selected
+		&FakeBreakdown{
+			Window:    5,
+			Tolerance: 0.01,
+			Strength:  3,
+			Delivery:  true,
+		},
+
+		// RSI momentum shift detection
+		// baseLine: 40 (minimum RSI to enter swing zone)
+		// upperBound: 60 (maximum RSI to avoid overbought)
+		&RsiEntersBullishSwingZone{baseLine: 40, upperBound: 60},
+
+		// Aggressive breakout strategy with multiple confirmations
+		&BullishMomentumBreakout{},
+	}
+}
+
+// Names returns the names of all active strategies
+func Names() []string {
+	strategies := All()
+	names := make([]string, 0, len(strategies))
+	for i := range strategies {
+		names = append(names, strategies[i].Name())
+	}
+	return names
+}
+
+// Select returns new instances of the strategies with the given names, matched case-insensitively
+// and in the order given; repeated names are selected once. Without names, all active
+// strategies are returned.
+func Select(names []string) ([]models.Strategy, error) {
+	if len(names) == 0 {
+		return All(), nil
+	}
+
+	var (
+		strategies = All()
+		selected   = make([]models.Strategy, 0, len(names))
+		seen       = make(map[int]struct{})
+		unknown    = make([]string, 0)
+	)
+
+	for _, name := range names {
+		found := false
+		for i := range strategies {
+			if !strings.EqualFold(strategies[i].Name(), strings.TrimSpace(name)) {
+				continue
+			}
+
+			if _, ok := seen[i]; !ok {
+				selected = append(selected, strategies[i])
+				seen[i] = struct{}{}
+			}
+			found = true
+			break
+		}
+		if !found {
+			unknown = append(unknown, name)
+		}
+	}
+
+	if len(unknown) > 0 {
+		return nil, fmt.Errorf(
+			"unknown strategies %q, available: %v",
+			unknown,
+			strings.Join(Names(), ", "),
+		)
+	}
+	return selected, nil
+}
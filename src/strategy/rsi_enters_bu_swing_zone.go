@@ -3,7 +3,7 @@ package strategy
 import (
 	"eeye/src/models"
 	"eeye/src/steps"
-	"log"
+	"log/slog"
 )
 
 // RsiEntersBullishSwingZone identifies stocks whose RSI has just entered the bullish swing zone,
@@ -42,6 +42,16 @@ func (r *RsiEntersBullishSwingZone) Name() string {
 	return "RSI Enters Bullish Swing Zone"
 }
 
+// Lookback returns the number of latest candles the strategy needs.
+//
+// Returns:
+//   - 150 candles: RSI 14 uses Wilder smoothing, which needs about ten periods to settle
+//
+//revive:disable-next-line exported
+func (r *RsiEntersBullishSwingZone) Lookback() int {
+	return 150
+}
+
 // Execute runs the RsiEntersBullishSwingZone strategy on the given stock.
 // It first validates the configuration parameters, then applies two screening steps:
 //  1. BullishCandle: Confirms bullish price action
@@ -71,17 +81,17 @@ func (r *RsiEntersBullishSwingZone) Execute(stock *models.Stock) {
 
 	// Validate configuration parameters
 	if r.baseLine == 0 {
-		log.Printf("[%v] baseLine cannot be zero\n", strategyName)
+		slog.Error("baseLine cannot be zero", "strategy", strategyName)
 		return
 	}
 
 	if r.upperBound == 0 {
-		log.Printf("[%v] upperBound cannot be zero\n", strategyName)
+		slog.Error("upperBound cannot be zero", "strategy", strategyName)
 		return
 	}
 
 	if r.baseLine > r.upperBound {
-		log.Printf("[%v] baseLine > upperBound\n", strategyName)
+		slog.Error("baseLine > upperBound", "strategy", strategyName)
 		return
 	}
 
@@ -113,7 +123,7 @@ func (r *RsiEntersBullishSwingZone) Execute(stock *models.Stock) {
 	}
 
 	// Execute all screening steps; if both pass, send stock to output sink
-	if steps.Execute(strategyName, stock, screeners) {
+	if steps.Execute(strategyName, stock, r.GetSource(), screeners) {
 		sink <- stock
 	}
 }
@@ -0,0 +1,126 @@
+package strategy
+
+import (
+	"context"
+	"eeye/src/db"
+	"eeye/src/models"
+	"fmt"
+	"log/slog"
+	"sort"
+	"time"
+)
+
+// diffSignals labels the current symbols of a strategy against those of its previous
+// run; the returned lists are sorted
+func diffSignals(current []string, previous []string) *models.SignalDiff {
+	var (
+		diff = &models.SignalDiff{
+			New:        make([]string, 0),
+			Continuing: make([]string, 0),
+			Dropped:    make([]string, 0),
+		}
+		before = make(map[string]struct{}, len(previous))
+		now    = make(map[string]struct{}, len(current))
+	)
+
+	for _, symbol := range previous {
+		before[symbol] = struct{}{}
+	}
+	for _, symbol := range current {
+		if _, ok := now[symbol]; ok {
+			continue
+		}
+		now[symbol] = struct{}{}
+
+		if _, ok := before[symbol]; ok {
+			diff.Continuing = append(diff.Continuing, symbol)
+		} else {
+			diff.New = append(diff.New, symbol)
+		}
+	}
+	for symbol := range before {
+		if _, ok := now[symbol]; !ok {
+			diff.Dropped = append(diff.Dropped, symbol)
+		}
+	}
+
+	sort.Strings(diff.New)
+	sort.Strings(diff.Continuing)
+	sort.Strings(diff.Dropped)
+	return diff
+}
+
+// resultSymbols returns the symbols of the stocks selected by a strategy
+func resultSymbols(result *models.StrategyResult) []string {
+	symbols := make([]string, 0, len(result.Stocks))
+	for i := range result.Stocks {
+		symbols = append(symbols, result.Stocks[i].Symbol)
+	}
+	return symbols
+}
+
+// CompareSignals sets the Diff of every result against the latest stored run of its
+// strategy before day, and logs the new, continuing and dropped signals. If universe is
+// not nil, previous signals of stocks outside of it are ignored, so that a screening of
+// a subset of the stocks does not report the others as dropped. On error, the Diff of
+// every result is cleared so that none is left half compared.
+func CompareSignals(ctx context.Context, day time.Time, results []*models.StrategyResult, universe []models.Stock) error {
+	var screened map[string]struct{}
+	if universe != nil {
+		screened = make(map[string]struct{}, len(universe))
+		for i := range universe {
+			screened[universe[i].Symbol] = struct{}{}
+		}
+	}
+
+	for _, result := range results {
+		name := result.Strategy.Name()
+		previousDay, previous, err := db.FetchPreviousSignals(ctx, name, day)
+		if err != nil {
+			for _, r := range results {
+				r.Diff = nil
+			}
+			return fmt.Errorf("failed to fetch the previous signals of %v: %w", name, err)
+		}
+
+		if screened != nil {
+			inUniverse := previous[:0]
+			for _, symbol := range previous {
+				if _, ok := screened[symbol]; ok {
+					inUniverse = append(inUniverse, symbol)
+				}
+			}
+			previous = inUniverse
+		}
+
+		result.Diff = diffSignals(resultSymbols(result), previous)
+		result.Diff.Day = day
+		if previousDay == nil {
+			slog.Info("no previous run to compare signals with", "strategy", name)
+			continue
+		}
+
+		result.Diff.Previous = *previousDay
+		slog.Info(
+			"signals compared",
+			"strategy", name,
+			"previous", previousDay.Format("2006-01-02"),
+			"new", result.Diff.New,
+			"continuing", len(result.Diff.Continuing),
+			"dropped", result.Diff.Dropped,
+		)
+	}
+
+	return nil
+}
+
+// SaveSignals stores the results as the run of every strategy on the given trading day,
+// replacing an earlier run of the same day
+func SaveSignals(ctx context.Context, day time.Time, results []*models.StrategyResult) error {
+	for _, result := range results {
+		if err := db.SaveSignals(ctx, day, result.Strategy.Name(), resultSymbols(result)); err != nil {
+			return fmt.Errorf("failed to save the signals of %v: %w", result.Strategy.Name(), err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,63 @@
+package strategy
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDiffSignals(t *testing.T) {
+	tests := []struct {
+		name           string
+		current        []string
+		previous       []string
+		wantNew        []string
+		wantContinuing []string
+		wantDropped    []string
+	}{
+		{
+			name:           "first run",
+			current:        []string{"TCS", "INFY"},
+			wantNew:        []string{"INFY", "TCS"},
+			wantContinuing: []string{},
+			wantDropped:    []string{},
+		},
+		{
+			name:           "new, continuing and dropped",
+			current:        []string{"TCS", "SBIN", "INFY"},
+			previous:       []string{"INFY", "WIPRO", "TCS"},
+			wantNew:        []string{"SBIN"},
+			wantContinuing: []string{"INFY", "TCS"},
+			wantDropped:    []string{"WIPRO"},
+		},
+		{
+			name:           "nothing selected",
+			previous:       []string{"TCS"},
+			wantNew:        []string{},
+			wantContinuing: []string{},
+			wantDropped:    []string{"TCS"},
+		},
+		{
+			name:           "duplicated symbols",
+			current:        []string{"TCS", "TCS"},
+			previous:       []string{"TCS"},
+			wantNew:        []string{},
+			wantContinuing: []string{"TCS"},
+			wantDropped:    []string{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			diff := diffSignals(tt.current, tt.previous)
+			if !reflect.DeepEqual(diff.New, tt.wantNew) {
+				t.Errorf("New = %v, want %v", diff.New, tt.wantNew)
+			}
+			if !reflect.DeepEqual(diff.Continuing, tt.wantContinuing) {
+				t.Errorf("Continuing = %v, want %v", diff.Continuing, tt.wantContinuing)
+			}
+			if !reflect.DeepEqual(diff.Dropped, tt.wantDropped) {
+				t.Errorf("Dropped = %v, want %v", diff.Dropped, tt.wantDropped)
+			}
+		})
+	}
+}
@@ -0,0 +1,198 @@
+package strategy
+
+import (
+	"eeye/src/config"
+	"eeye/src/models"
+	"eeye/src/store"
+	"eeye/src/testutil"
+	"testing"
+)
+
+// run executes the strategy on the series and reports whether the stock was selected
+func run(t *testing.T, strategy models.Strategy, series *testutil.Series) bool {
+	t.Helper()
+
+	var (
+		source = store.NewMemory()
+		stock  = series.Stock()
+	)
+
+	source.Set(stock.Symbol, series.Candles())
+	strategy.SetSource(source)
+	strategy.Execute(stock)
+
+	return len(strategy.GetSink()) == 1
+}
+
+func TestStrategies(t *testing.T) {
+	tests := []struct {
+		name     string
+		strategy func() models.Strategy
+		series   *testutil.Series
+		want     bool
+	}{
+		{
+			name:     "bullish swing after pullback",
+			strategy: func() models.Strategy { return &BullishSwing{} },
+			series:   testutil.NewSeries("SWING").Flat(30, 100).Trend(6, -0.01).Trend(6, 0.004).Solid(0.01).Volume(3000),
+			want:     true,
+		},
+		{
+			name:     "bullish swing without volume",
+			strategy: func() models.Strategy { return &BullishSwing{} },
+			series:   testutil.NewSeries("SWING").Flat(30, 100).Trend(6, -0.01).Trend(6, 0.004).Solid(0.01).Volume(500),
+			want:     false,
+		},
+		{
+			name:     "lower bollinger band bounce",
+			strategy: func() models.Strategy { return &LowerBollingerBandBullish{} },
+			series:   testutil.NewSeries("LBB").Flat(30, 100).Trend(6, -0.01).Trend(6, 0.004).Solid(0.01),
+			want:     true,
+		},
+		{
+			name:     "lower bollinger band in uptrend",
+			strategy: func() models.Strategy { return &LowerBollingerBandBullish{} },
+			series:   testutil.NewSeries("LBB").Trend(40, 0.01).Solid(0.01),
+			want:     false,
+		},
+		{
+			name:     "ema fake breakdown",
+			strategy: func() models.Strategy { return &EmaFakeBreakdown{period: 50} },
+			series:   testutil.NewSeries("EMA").Flat(60, 100).Candle(99.6, 101.5, 99.2, 101.4),
+			want:     true,
+		},
+		{
+			name:     "ema far below price",
+			strategy: func() models.Strategy { return &EmaFakeBreakdown{period: 50} },
+			series:   testutil.NewSeries("EMA").Trend(60, 0.01).Solid(0.01),
+			want:     false,
+		},
+		{
+			name:     "fake breakdown of range support",
+			strategy: func() models.Strategy { return &FakeBreakdown{Window: 5, Tolerance: 0.01, Strength: 3, Delivery: true} },
+			series:   testutil.NewSeries("FAKE").Range(40, 95, 105).Candle(94.9, 96.6, 94.7, 96.5).Volume(2000).Delivery(60),
+			want:     true,
+		},
+		{
+			name:     "fake breakdown with low delivery",
+			strategy: func() models.Strategy { return &FakeBreakdown{Window: 5, Tolerance: 0.01, Strength: 3, Delivery: true} },
+			series:   testutil.NewSeries("FAKE").Range(40, 95, 105).Candle(94.9, 96.6, 94.7, 96.5).Volume(2000).Delivery(20),
+			want:     false,
+		},
+		{
+			name:     "fake breakdown without delivery confirmation",
+			strategy: func() models.Strategy { return &FakeBreakdown{Window: 5, Tolerance: 0.01, Strength: 3} },
+			series:   testutil.NewSeries("FAKE").Range(40, 95, 105).Candle(94.9, 96.6, 94.7, 96.5).Volume(2000).Delivery(20),
+			want:     true,
+		},
+		{
+			name:     "close below support is a real breakdown",
+			strategy: func() models.Strategy { return &FakeBreakdown{Window: 5, Tolerance: 0.01, Strength: 3} },
+			series:   testutil.NewSeries("FAKE").Range(40, 95, 105).Candle(93.0, 94.6, 92.9, 94.5).Volume(2000),
+			want:     false,
+		},
+		{
+			name:     "rsi enters swing zone",
+			strategy: func() models.Strategy { return &RsiEntersBullishSwingZone{baseLine: 40, upperBound: 60} },
+			series:   testutil.NewSeries("RSI").Flat(30, 100).Trend(4, -0.01).Trend(4, 0.004).Hammer(),
+			want:     true,
+		},
+		{
+			name:     "rsi already overbought",
+			strategy: func() models.Strategy { return &RsiEntersBullishSwingZone{baseLine: 40, upperBound: 60} },
+			series:   testutil.NewSeries("RSI").Trend(30, 0.01).Hammer(),
+			want:     false,
+		},
+		{
+			name:     "invalid rsi bounds",
+			strategy: func() models.Strategy { return &RsiEntersBullishSwingZone{baseLine: 60, upperBound: 40} },
+			series:   testutil.NewSeries("RSI").Flat(30, 100).Trend(4, -0.01).Trend(4, 0.004).Hammer(),
+			want:     false,
+		},
+		{
+			name:     "momentum breakout",
+			strategy: func() models.Strategy { return &BullishMomentumBreakout{} },
+			series:   testutil.NewSeries("MOM").Zigzag(130, 0.01, 0.008).Solid(0.03),
+			want:     true,
+		},
+		{
+			name:     "momentum without long history",
+			strategy: func() models.Strategy { return &BullishMomentumBreakout{} },
+			series:   testutil.NewSeries("MOM").Zigzag(60, 0.01, 0.008).Solid(0.03),
+			want:     false,
+		},
+		{
+			name:     "momentum in downtrend",
+			strategy: func() models.Strategy { return &BullishMomentumBreakout{} },
+			series:   testutil.NewSeries("MOM").Zigzag(130, 0.008, 0.01).Solid(0.03),
+			want:     false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := run(t, tt.strategy(), tt.series); got != tt.want {
+				t.Errorf("selected = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestStrategyWithoutCandles(t *testing.T) {
+	strategy := &BullishSwing{}
+	strategy.SetSource(store.NewMemory())
+	strategy.Execute(&models.Stock{Symbol: "MISSING"})
+
+	if len(strategy.GetSink()) != 0 {
+		t.Errorf("stock without candles was selected")
+	}
+}
+
+// fullHistory is a strategy which needs every stored candle
+type fullHistory struct {
+	BullishSwing
+}
+
+func (f *fullHistory) Lookback() int {
+	return 0
+}
+
+func TestLookback(t *testing.T) {
+	tests := []struct {
+		name       string
+		trim       bool
+		strategies []models.Strategy
+		want       int
+	}{
+		{
+			name:       "trimming disabled",
+			trim:       false,
+			strategies: []models.Strategy{&BullishSwing{}, &BullishMomentumBreakout{}},
+			want:       0,
+		},
+		{
+			name:       "longest lookback wins",
+			trim:       true,
+			strategies: []models.Strategy{&BullishSwing{}, &EmaFakeBreakdown{period: 50}, &BullishMomentumBreakout{}},
+			want:       600,
+		},
+		{
+			name:       "strategy needing full history",
+			trim:       true,
+			strategies: []models.Strategy{&BullishSwing{}, &fullHistory{}},
+			want:       0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			previous := config.Analysis.Trim
+			config.Analysis.Trim = tt.trim
+			defer func() { config.Analysis.Trim = previous }()
+
+			if got := lookback(tt.strategies); got != tt.want {
+				t.Errorf("lookback() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
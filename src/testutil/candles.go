@@ -0,0 +1,193 @@
+// Package testutil provides synthetic market data for tests.
+// Series builds a daily candle history step by step (trends, ranges, gaps and
+// candlestick patterns) so that indicators and strategies can be exercised
+// without a database or network access.
+package testutil
+
+import (
+	"eeye/src/models"
+	"math"
+	"time"
+)
+
+const (
+	// DefaultVolume is the volume of generated candles
+	DefaultVolume = 1000
+
+	// DefaultDeliveryPct is the delivery percentage of generated candles
+	DefaultDeliveryPct = 40
+
+	// wick is the distance of generated highs/lows from the candle body (0.2%)
+	wick = 0.002
+)
+
+// Start is the day of the first candle of every series
+var Start = time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+// Series is a builder of daily candles for a single symbol.
+// Every method appends candles after the last one and returns the series for chaining.
+type Series struct {
+	symbol  string
+	candles []models.Candle
+}
+
+// NewSeries creates an empty series for the given symbol
+func NewSeries(symbol string) *Series {
+	return &Series{symbol: symbol, candles: make([]models.Candle, 0)}
+}
+
+// Candles returns a copy of the built candles
+func (s *Series) Candles() []models.Candle {
+	return append([]models.Candle(nil), s.candles...)
+}
+
+// Stock returns the stock the series belongs to
+func (s *Series) Stock() *models.Stock {
+	return &models.Stock{Symbol: s.symbol, Name: s.symbol, Exchange: "NSE", Segment: "CASH"}
+}
+
+// LastClose returns the close of the last candle, or zero for an empty series
+func (s *Series) LastClose() float64 {
+	if len(s.candles) == 0 {
+		return 0
+	}
+	return s.candles[len(s.candles)-1].Close
+}
+
+// Candle appends a candle with explicit prices and the default volume
+func (s *Series) Candle(open float64, high float64, low float64, closePrice float64) *Series {
+	s.candles = append(s.candles, models.Candle{
+		Symbol:      s.symbol,
+		Open:        open,
+		High:        high,
+		Low:         low,
+		Close:       closePrice,
+		Timestamp:   Start.AddDate(0, 0, len(s.candles)),
+		Volume:      DefaultVolume,
+		DeliveryPct: DefaultDeliveryPct,
+	})
+	return s
+}
+
+// body appends a candle moving from open to close with small wicks on both sides
+func (s *Series) body(open float64, closePrice float64) *Series {
+	var (
+		high = math.Max(open, closePrice) * (1 + wick)
+		low  = math.Min(open, closePrice) * (1 - wick)
+	)
+	return s.Candle(open, high, low, closePrice)
+}
+
+// Flat appends n candles that open and close at price
+func (s *Series) Flat(n int, price float64) *Series {
+	for range n {
+		s.body(price, price)
+	}
+	return s
+}
+
+// Trend appends n candles, each closing rate (e.g. 0.01 for 1%, -0.01 for -1%)
+// away from the previous close. The first candle opens at the last close, or at
+// 100 for an empty series.
+func (s *Series) Trend(n int, rate float64) *Series {
+	price := s.LastClose()
+	if price == 0 {
+		price = 100
+	}
+
+	for range n {
+		next := price * (1 + rate)
+		s.body(price, next)
+		price = next
+	}
+	return s
+}
+
+// Zigzag appends n pairs of candles, the first gaining up and the second losing
+// down (e.g. 0.01 and 0.008), i.e. a trend with pullbacks
+func (s *Series) Zigzag(n int, up float64, down float64) *Series {
+	for range n {
+		s.Trend(1, up).Trend(1, -down)
+	}
+	return s
+}
+
+// Range appends n candles alternating between closes at low and high, so that the
+// highs and lows of the range are touched repeatedly
+func (s *Series) Range(n int, low float64, high float64) *Series {
+	for i := range n {
+		if i%2 == 0 {
+			s.body(high, low)
+		} else {
+			s.body(low, high)
+		}
+	}
+	return s
+}
+
+// Gap appends a candle opening rate (e.g. 0.05 for 5%) away from the last close
+// and closing at its open
+func (s *Series) Gap(rate float64) *Series {
+	price := s.LastClose() * (1 + rate)
+	return s.body(price, price)
+}
+
+// Solid appends a solid bullish candle gaining rate from the last close with tiny wicks
+func (s *Series) Solid(rate float64) *Series {
+	var (
+		open       = s.LastClose()
+		closePrice = open * (1 + rate)
+		body       = closePrice - open
+	)
+	return s.Candle(open, closePrice+0.1*body, open-0.1*body, closePrice)
+}
+
+// Hammer appends a bullish hammer opening at the last close: a small body on top
+// of a lower wick three times its size
+func (s *Series) Hammer() *Series {
+	var (
+		open       = s.LastClose()
+		body       = open * 0.005
+		closePrice = open + body
+	)
+	return s.Candle(open, closePrice, open-3*body, closePrice)
+}
+
+// Engulfing appends a bearish candle followed by a bullish candle engulfing its body
+func (s *Series) Engulfing() *Series {
+	var (
+		open       = s.LastClose()
+		closePrice = open * 0.98
+	)
+	s.body(open, closePrice)
+	return s.body(closePrice*0.995, open*1.005)
+}
+
+// Piercing appends a bearish candle followed by a bullish candle opening below its
+// close and closing above its midpoint (but below its open)
+func (s *Series) Piercing() *Series {
+	var (
+		open       = s.LastClose()
+		closePrice = open * 0.96
+	)
+	s.body(open, closePrice)
+	return s.body(closePrice*0.99, open*0.99)
+}
+
+// Bearish appends a bearish candle losing rate from the last close
+func (s *Series) Bearish(rate float64) *Series {
+	open := s.LastClose()
+	return s.body(open, open*(1-rate))
+}
+
+// Volume sets the volume of the last candle
+func (s *Series) Volume(volume uint64) *Series {
+	s.candles[len(s.candles)-1].Volume = volume
+	return s
+}
+
+// Delivery sets the delivery percentage of the last candle
+func (s *Series) Delivery(pct float64) *Series {
+	s.candles[len(s.candles)-1].DeliveryPct = pct
+	return s
+}
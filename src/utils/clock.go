@@ -0,0 +1,22 @@
+package utils
+
+import "time"
+
+// frozenAt is the fixed current time used while replaying a captured day
+var frozenAt time.Time
+
+// Now returns the current time, or the frozen time if FreezeTime was called.
+// Date logic of the pipeline (last session, backfill ranges) goes through Now so
+// that a recorded run can be replayed as if it happened at the recording time.
+func Now() time.Time {
+	if !frozenAt.IsZero() {
+		return frozenAt
+	}
+	return time.Now()
+}
+
+// FreezeTime makes Now return t from now on.
+// It is meant to be called once during startup, before any goroutine uses Now.
+func FreezeTime(t time.Time) {
+	frozenAt = t
+}